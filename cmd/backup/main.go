@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"concurrency_go_course/internal/backup"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/pkg/logger"
+)
+
+const usage = `Usage: backup <command> [flags]
+
+Commands:
+  create    ask running server to write backup archive
+  restore   extract backup archive into data directory of new node
+
+Run "backup <command> -h" to see flags of command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger.Set(zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.Lock(os.Stderr), zap.WarnLevel)))
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+
+	switch command {
+	case "create":
+		address := flags.String("addr", "127.0.0.1:3223", "database server address")
+		out := flags.String("out", "", "path of archive in backup directory of server")
+		user := flags.String("user", "", "user authenticated on server")
+		password := flags.String("password", "", "password of user")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *out == "" {
+			return fmt.Errorf("archive path isn't set")
+		}
+		if *user == "" {
+			return fmt.Errorf("user isn't set, backup requires authentication")
+		}
+
+		return create(*address, *user, *password, *out)
+	case "restore":
+		archive := flags.String("archive", "", "path of backup archive")
+		dir := flags.String("dir", "", "empty data directory of new node")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *archive == "" || *dir == "" {
+			return fmt.Errorf("archive and directory must be set")
+		}
+
+		manifest, err := backup.Restore(*archive, *dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("restored backup created at %s with point %s into %s\n",
+			manifest.Created, manifest.Point, *dir)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+	return nil
+}
+
+// create authenticates user and sends BACKUP command to server.
+// Server responds with error text if backup isn't created
+func create(address, user, password, out string) error {
+	client, err := network.NewClient(address)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	response, err := client.Send([]byte(fmt.Sprintf("AUTH %s %s\n", user, password)))
+	if err != nil {
+		return err
+	}
+	if string(response) != "OK" {
+		return fmt.Errorf("server error: %s", response)
+	}
+
+	response, err = client.Send([]byte("BACKUP " + out + "\n"))
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(string(response), "path:") {
+		return fmt.Errorf("server error: %s", response)
+	}
+
+	fmt.Println(string(response))
+	return nil
+}
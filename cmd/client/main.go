@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
@@ -41,6 +42,24 @@ func main() {
 		}
 
 		fmt.Println("Server response: ", string(resp))
+
+		if bytes.HasPrefix(bytes.TrimSpace(request), []byte("SUBSCRIBE ")) {
+			receive(client)
+		}
+
 		fmt.Println("Enter request:")
 	}
 }
+
+// receive prints messages pushed by server after subscription
+func receive(client *network.TCPClient) {
+	for {
+		message, err := client.Receive()
+		if err != nil {
+			fmt.Printf("unable to receive message: %v\n", err)
+			return
+		}
+
+		fmt.Println(string(message))
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/keyspace"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/redisimport"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+const usage = `Usage: keyspace <command> [flags]
+
+Commands:
+  export        write keys as JSON Lines or CSV
+  import        load keys written by export
+  import-redis  load keys of Redis AOF or RDB file into data directory
+
+Keys are read from or written to data directory of stopped server with -dir,
+or running server with -addr. Files of running server are in its export
+directory, user authenticated by -user is required.
+Run "keyspace <command> -h" to see flags of command.
+`
+
+type options struct {
+	dir        string
+	configPath string
+	address    string
+	user       string
+	password   string
+	format     string
+	prefix     string
+	path       string
+	db         int
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger.Set(zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.Lock(os.Stderr), zap.WarnLevel)))
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "keyspace:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string) error {
+	if command == "import-redis" {
+		return importRedis(args)
+	}
+
+	if command != "export" && command != "import" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var opts options
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.StringVar(&opts.dir, "dir", "", "data directory of stopped server")
+	flags.StringVar(&opts.configPath, "config-path", "", "path to server config with WAL settings")
+	flags.StringVar(&opts.address, "addr", "", "address of running server")
+	flags.StringVar(&opts.user, "user", "", "user authenticated on running server")
+	flags.StringVar(&opts.password, "password", "", "password of user")
+	flags.StringVar(&opts.format, "format", string(keyspace.FormatJSONL), "jsonl or csv")
+	flags.StringVar(&opts.prefix, "prefix", "", "process only keys with prefix")
+	flags.IntVar(&opts.db, "db", 0, "logical database")
+	if command == "export" {
+		flags.StringVar(&opts.path, "out", "", "output file, stdout by default for data directory")
+	} else {
+		flags.StringVar(&opts.path, "in", "", "input file, stdin by default for data directory")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := keyspace.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+
+	if opts.address != "" {
+		return remote(command, opts)
+	}
+
+	settings, err := walSettings(opts)
+	if err != nil {
+		return err
+	}
+
+	if command == "export" {
+		return exportDir(settings, format, opts)
+	}
+
+	return importDir(settings, format, opts)
+}
+
+// remote sends EXPORT or IMPORT command to running server
+func remote(command string, opts options) error {
+	if opts.path == "" {
+		return fmt.Errorf("file on server host must be set")
+	}
+
+	client, err := network.NewClient(opts.address)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if opts.user != "" {
+		response, err := client.Send([]byte(fmt.Sprintf("%s %s %s\n", compute.CommandAuth, opts.user, opts.password)))
+		if err != nil {
+			return err
+		}
+		if string(response) != "OK" {
+			return fmt.Errorf("server error: %s", response)
+		}
+	}
+
+	if opts.db != 0 {
+		response, err := client.Send([]byte(fmt.Sprintf("%s %d\n", compute.CommandSelect, opts.db)))
+		if err != nil {
+			return err
+		}
+		if string(response) != "OK" {
+			return fmt.Errorf("server error: %s", response)
+		}
+	}
+
+	request := []string{strings.ToUpper(command), opts.path, opts.format}
+	if opts.prefix != "" {
+		request = append(request, opts.prefix)
+	}
+
+	response, err := client.Send([]byte(strings.Join(request, " ") + "\n"))
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(string(response), "keys:") {
+		return fmt.Errorf("server error: %s", response)
+	}
+
+	fmt.Println(string(response))
+	return nil
+}
+
+func exportDir(settings *wal.Settings, format keyspace.Format, opts options) error {
+	snapshot, err := keyspace.Snapshot(settings.DataDirectory, settings.Keyring)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if opts.path != "" {
+		file, err := os.OpenFile(opts.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close() //nolint:errcheck
+		out = file
+	}
+
+	exported, err := keyspace.Export(keyspace.InDatabase(snapshot, opts.db), out, format, opts.prefix)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d keys\n", exported)
+	return nil
+}
+
+// importDir appends imported keys to new segments of data directory,
+// they replace existing keys when server is started
+func importDir(settings *wal.Settings, format keyspace.Format, opts options) error {
+	var in io.Reader = os.Stdin
+	if opts.path != "" {
+		file, err := os.Open(opts.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close() //nolint:errcheck
+		in = file
+	}
+
+	var requests []wal.Request
+	imported, err := keyspace.Import(in, format, opts.prefix, func(batch []wal.Request) error {
+		requests = append(requests, keyspace.ToDatabase(batch, opts.db)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(settings.DataDirectory, 0o750); err != nil {
+		return err
+	}
+	if err := wal.WriteRequests(settings.DataDirectory, settings, requests); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d keys\n", imported)
+	return nil
+}
+
+// importRedis loads keys of Redis files into new segments of data
+// directory, they replace existing keys when server is started
+func importRedis(args []string) error {
+	var opts options
+	flags := flag.NewFlagSet("import-redis", flag.ExitOnError)
+	flags.StringVar(&opts.dir, "dir", "", "data directory of stopped server")
+	flags.StringVar(&opts.configPath, "config-path", "", "path to server config with WAL settings")
+	rdbPath := flags.String("rdb", "", "Redis RDB file, loaded before AOF")
+	aofPath := flags.String("aof", "", "Redis append only file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *rdbPath == "" && *aofPath == "" {
+		return fmt.Errorf("RDB or AOF file must be set")
+	}
+
+	settings, err := walSettings(opts)
+	if err != nil {
+		return err
+	}
+
+	importer, err := redisimport.New()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []struct {
+		path string
+		read func(io.Reader) error
+	}{
+		{path: *rdbPath, read: importer.ReadRDB},
+		{path: *aofPath, read: importer.ReadAOF},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if err := readFile(file.path, file.read); err != nil {
+			return fmt.Errorf("%s: %w", file.path, err)
+		}
+	}
+
+	if err := os.MkdirAll(settings.DataDirectory, 0o750); err != nil {
+		return err
+	}
+	requests, keys := importer.Requests()
+	if err := wal.WriteRequests(settings.DataDirectory, settings, requests); err != nil {
+		return err
+	}
+
+	stats := importer.Stats()
+	fmt.Fprintf(os.Stderr, "imported %d keys from %d RDB entries and %d AOF commands\n",
+		keys, stats.Entries, stats.Commands)
+	if stats.OtherDB != 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d entries and commands of databases other than 0\n", stats.OtherDB)
+	}
+	if stats.Expired != 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d expired keys\n", stats.Expired)
+	}
+	for name, count := range stats.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %d unsupported %s\n", count, name)
+	}
+	if stats.Invalid != 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d entries and commands with whitespaces in keys or values\n", stats.Invalid)
+	}
+	if stats.Truncated {
+		fmt.Fprintln(os.Stderr, "incomplete last AOF command was ignored")
+	}
+
+	return nil
+}
+
+func readFile(path string, read func(io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	return read(file)
+}
+
+// walSettings returns WAL settings of config. Config is needed to read
+// encrypted segments and to write compressed or encrypted ones
+func walSettings(opts options) (*wal.Settings, error) {
+	walCfg := &config.WALCfg{WalConfig: &config.WALSettings{}}
+	if opts.configPath != "" {
+		cfg, err := config.NewWALConfig(opts.configPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config: %w", err)
+		}
+		if cfg == nil || cfg.WalConfig == nil {
+			return nil, fmt.Errorf("config %s has no WAL settings", opts.configPath)
+		}
+		walCfg = cfg
+	}
+
+	if opts.dir != "" {
+		walCfg.WalConfig.DataDirectory = opts.dir
+	}
+	if walCfg.WalConfig.DataDirectory == "" {
+		return nil, fmt.Errorf("data directory or server address must be set")
+	}
+
+	return wal.NewSettings(walCfg)
+}
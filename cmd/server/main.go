@@ -5,13 +5,10 @@ import (
 	"flag"
 	"log"
 	"os/signal"
-	"sync"
 	"syscall"
 
 	"concurrency_go_course/internal/app"
 	"concurrency_go_course/internal/config"
-	"concurrency_go_course/internal/network"
-	"concurrency_go_course/internal/replication"
 	"concurrency_go_course/pkg/logger"
 )
 
@@ -19,6 +16,10 @@ var configPathMaster = "config.yaml"
 
 func main() {
 	configPath := flag.String("config-path", configPathMaster, "path to config file")
+	recoverToTime := flag.String("recover-to-time", "",
+		"RFC3339 time to recover state to, WAL requests written later are archived")
+	recoverToPosition := flag.String("recover-to-position", "",
+		"segment:offset of the first WAL request to archive on recovery, see waltool dump")
 	flag.Parse()
 
 	cfg, err := config.NewConfig(*configPath)
@@ -38,58 +39,15 @@ func main() {
 		logger.Info("unable to set WAL settings, WAL is disabled")
 	}
 
-	db, wal, repl, err := app.Init(cfg, walCfg)
-	if err != nil {
-		log.Fatal("unable to init app")
-	}
-
-	wg := sync.WaitGroup{}
-	if wal != nil && (cfg.Replication == nil ||
-		cfg.Replication != nil &&
-			cfg.Replication.ReplicaType == replication.ReplicaTypeMaster) {
-		wg.Add(1)
-		go func() {
-			defer func() {
-				wg.Done()
-			}()
-
-			logger.Debug("starting WAL")
-			wal.Start(ctx)
-		}()
-	}
-
-	if cfg.Replication != nil {
-		logger.Debug("starting replication")
-		if repl.Master != nil {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				repl.Master.Start(ctx)
-			}()
-		} else if repl.Slave != nil {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				repl.Slave.Start(ctx)
-			}()
+	if *recoverToTime != "" || *recoverToPosition != "" {
+		if walCfg == nil || walCfg.WalConfig == nil {
+			log.Fatal("unable to start server: recovery target is set but WAL is disabled")
 		}
+		walCfg.WalConfig.RecoverToTime = *recoverToTime
+		walCfg.WalConfig.RecoverToPosition = *recoverToPosition
 	}
 
-	server, err := network.NewServer(cfg, cfg.Network.Address)
-	if err != nil {
-		log.Fatal("unable to start server")
+	if err := app.Run(ctx, cfg, walCfg); err != nil {
+		log.Fatal(err)
 	}
-
-	server.Run(ctx, func(_ context.Context, s []byte) []byte {
-		response, err := db.Handle(string(s) + "\n")
-		if err != nil {
-			logger.ErrorWithMsg("unable to handle query:", err)
-			response = err.Error()
-		}
-		return []byte(response)
-	})
-
-	wg.Wait()
 }
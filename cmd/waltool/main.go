@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/internal/waltool"
+	"concurrency_go_course/pkg/logger"
+	"concurrency_go_course/pkg/parser"
+)
+
+const usage = `Usage: waltool <command> [flags]
+
+Commands:
+  list       list segments with sizes and numbers of requests
+  dump       print requests as JSON lines
+  verify     check that every segment decodes
+  truncate   cut corrupted tail of segment
+  replay     write state of database to fresh segments in directory
+
+Run "waltool <command> -h" to see flags of command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger.Set(zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.Lock(os.Stderr), zap.WarnLevel)))
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "waltool:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dir := flags.String("dir", "", "WAL data directory, data_directory of config by default")
+	configPath := flags.String("config-path", "", "path to server config with WAL settings")
+
+	var segment, out *string
+	var dryRun *bool
+
+	switch command {
+	case "list", "verify":
+	case "dump":
+		segment = flags.String("segment", "", "segment to dump, all segments by default")
+	case "truncate":
+		segment = flags.String("segment", "", "segment to truncate, the last one by default")
+		dryRun = flags.Bool("dry-run", false, "print what would be cut without changing segment")
+	case "replay":
+		out = flags.String("out", "", "empty directory for fresh segments")
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tool, err := newTool(*dir, *configPath)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return tool.List()
+	case "dump":
+		return tool.Dump(*segment)
+	case "verify":
+		return tool.Verify()
+	case "truncate":
+		return tool.Truncate(*segment, *dryRun)
+	case "replay":
+		if *out == "" {
+			return fmt.Errorf("out directory isn't set")
+		}
+		return tool.Replay(*out)
+	}
+
+	return nil
+}
+
+// newTool returns tool with WAL settings of config. Config is needed
+// to read encrypted segments and to write compressed or encrypted ones
+func newTool(dir, configPath string) (*waltool.Tool, error) {
+	var settings waltool.Settings
+
+	if configPath != "" {
+		walCfg, err := config.NewWALConfig(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config: %w", err)
+		}
+		if walCfg == nil || walCfg.WalConfig == nil {
+			return nil, fmt.Errorf("config %s has no WAL settings", configPath)
+		}
+
+		if dir == "" {
+			dir = walCfg.WalConfig.DataDirectory
+		}
+
+		settings.Compression, err = wal.ParseCompression(walCfg.WalConfig.Compression)
+		if err != nil {
+			return nil, err
+		}
+
+		if walCfg.WalConfig.Encryption != nil {
+			settings.Keyring, err = wal.NewKeyring(walCfg.WalConfig.Encryption)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if size, err := parser.ParseSize(walCfg.WalConfig.MaxSegmentSize); err == nil {
+			settings.MaxSegmentSize = size
+		}
+	}
+
+	if dir == "" {
+		return nil, fmt.Errorf("WAL directory isn't set")
+	}
+
+	return waltool.New(dir, settings, os.Stdout), nil
+}
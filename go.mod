@@ -2,16 +2,25 @@ module concurrency_go_course
 
 go 1.23.2
 
+require (
+	github.com/golang/mock v1.6.0
+	github.com/natefinch/lumberjack v2.0.0+incompatible
+	github.com/stretchr/testify v1.10.0
+	go.uber.org/zap v1.27.0
+	google.golang.org/grpc v1.72.2
+	google.golang.org/protobuf v1.36.5
+	gopkg.in/yaml.v2 v2.4.0
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/golang/mock v1.6.0 // indirect
 	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
-	go.uber.org/zap v1.27.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
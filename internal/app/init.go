@@ -2,19 +2,63 @@ package app
 
 import (
 	"fmt"
+	"time"
 
+	"concurrency_go_course/internal/backup"
 	"concurrency_go_course/internal/compute"
 	"concurrency_go_course/internal/config"
 	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/pubsub"
 	"concurrency_go_course/internal/replication"
+	"concurrency_go_course/internal/slowlog"
 	"concurrency_go_course/internal/storage"
 	"concurrency_go_course/internal/storage/wal"
 	"concurrency_go_course/pkg/logger"
+	"concurrency_go_course/pkg/parser"
 )
 
 // Init initializes new database and wal service and other objects
 func Init(cfg *config.Config, walCfg *config.WALCfg) (
 	database.Database, *wal.WAL, *replication.Replication, error,
+) {
+	storage, walObj, repl, broker, err := InitStorage(cfg, walCfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	requestParser := compute.NewRequestParser()
+	compute := compute.NewCompute(requestParser)
+
+	opts, err := limitOptions(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	opts = append(opts, database.WithDatabases(cfg.Engine.Databases))
+	if cfg.SlowLog != nil {
+		threshold, err := time.ParseDuration(cfg.SlowLog.Threshold)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("unable to parse slow log threshold: %v", err)
+		}
+		opts = append(opts, database.WithSlowLog(slowlog.New(threshold, cfg.SlowLog.MaxLen)))
+	}
+	if cfg.Keyspace != nil && cfg.Keyspace.Directory != "" {
+		opts = append(opts, database.WithExportDirectory(cfg.Keyspace.Directory))
+	}
+	// slave doesn't write own WAL, so it has no backup point
+	if walObj != nil && cfg.Backup != nil && cfg.Backup.Directory != "" &&
+		(cfg.Replication == nil || cfg.Replication.ReplicaType != replication.ReplicaTypeSlave) {
+		opts = append(opts, database.WithBackuper(backup.New(walObj), cfg.Backup.Directory))
+	}
+
+	db := database.NewDatabase(storage, compute, broker, opts...)
+
+	return db, walObj, repl, nil
+}
+
+// InitStorage initializes new storage with wal service, replication
+// and pub/sub broker
+func InitStorage(cfg *config.Config, walCfg *config.WALCfg) (
+	storage.Storage, *wal.WAL, *replication.Replication, *pubsub.Broker, error,
 ) {
 	var err error
 	var replicaType string
@@ -24,7 +68,7 @@ func Init(cfg *config.Config, walCfg *config.WALCfg) (
 	}
 
 	if cfg == nil {
-		return nil, nil, nil, fmt.Errorf("config is empty")
+		return nil, nil, nil, nil, fmt.Errorf("config is empty")
 	}
 
 	var walObj *wal.WAL
@@ -34,7 +78,7 @@ func Init(cfg *config.Config, walCfg *config.WALCfg) (
 	} else {
 		walObj, err = wal.New(walCfg)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("unable to create new WAL: %v", err)
+			return nil, nil, nil, nil, fmt.Errorf("unable to create new WAL: %v", err)
 		}
 	}
 
@@ -43,18 +87,15 @@ func Init(cfg *config.Config, walCfg *config.WALCfg) (
 	if replicaType == replication.ReplicaTypeMaster {
 		replServer, err := replication.NewReplicationServer(cfg, walCfg)
 		if err != nil {
-			logger.ErrorWithMsg("unable to create replication master server:", err)
-		} else {
-			repl.Master = replServer
+			return nil, nil, nil, nil, fmt.Errorf("unable to create replication master server: %v", err)
 		}
-
+		repl.Master = replServer
 	} else if replicaType == replication.ReplicaTypeSlave {
 		replClient, err := replication.NewReplicationClient(cfg, walCfg)
 		if err != nil {
-			logger.ErrorWithMsg("unable to create replication slave server:", err)
-		} else {
-			repl.Slave = replClient
+			return nil, nil, nil, nil, fmt.Errorf("unable to create replication slave server: %v", err)
 		}
+		repl.Slave = replClient
 	}
 
 	var replStream chan []wal.Request
@@ -62,17 +103,33 @@ func Init(cfg *config.Config, walCfg *config.WALCfg) (
 		replStream = repl.Slave.ReplicationStream()
 	}
 
-	engine := storage.NewEngine(cfg.Engine.PartitionsNumber)
+	broker := pubsub.NewBroker()
 
-	storage, err := storage.New(engine, walObj, replicaType, replStream)
+	var notifier storage.Notifier
+	if cfg.PubSub != nil && cfg.PubSub.KeyspaceNotifications {
+		notifier = broker
+	}
+
+	maxMemory, err := parser.ParseSize(cfg.Engine.MaxMemory)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("unable to init storage: %v", err)
+		return nil, nil, nil, nil, fmt.Errorf("unable to parse max memory: %v", err)
 	}
 
-	requestParser := compute.NewRequestParser()
-	compute := compute.NewCompute(requestParser)
+	engine, err := storage.NewEngineWithLimit(cfg.Engine.PartitionsNumber,
+		int64(maxMemory), cfg.Engine.EvictionPolicy)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("unable to init engine: %v", err)
+	}
 
-	db := database.NewDatabase(storage, compute)
+	// quotas are set before recovery, so recovered keys are counted
+	if err := setQuotas(engine, cfg.Limits); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("unable to init quotas: %v", err)
+	}
 
-	return db, walObj, repl, nil
+	storage, err := storage.New(engine, walObj, replicaType, replStream, notifier)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("unable to init storage: %v", err)
+	}
+
+	return storage, walObj, repl, broker, nil
 }
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/parser"
+)
+
+// limitOptions returns database options with rate limits and users of config
+func limitOptions(cfg *config.Config) ([]database.Option, error) {
+	var opts []database.Option
+
+	if cfg.Limits != nil {
+		client, err := rateLimiter("client", cfg.Limits.Client)
+		if err != nil {
+			return nil, err
+		}
+		user, err := rateLimiter("user", cfg.Limits.User)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, database.WithRateLimits(client, user))
+	}
+
+	if len(cfg.Users) != 0 {
+		users := make(map[string]string, len(cfg.Users))
+		for _, user := range cfg.Users {
+			if user.Name == "" || len(user.PasswordSHA256) != 64 {
+				return nil, fmt.Errorf("user %q must have name and hex encoded SHA-256 of password", user.Name)
+			}
+			users[user.Name] = user.PasswordSHA256
+		}
+		opts = append(opts, database.WithUsers(users))
+	}
+
+	return opts, nil
+}
+
+func rateLimiter(kind string, cfg *config.RateConfig) (*limits.Limiter, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	bytes, err := parser.ParseSize(cfg.BytesPerSecond)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s bytes per second: %v", kind, err)
+	}
+
+	return limits.NewLimiter(kind, limits.Rate{
+		OpsPerSecond:   float64(cfg.OpsPerSecond),
+		BytesPerSecond: float64(bytes),
+	}), nil
+}
+
+// setQuotas sets quotas of config to engine
+func setQuotas(engine storage.Engine, cfg *config.LimitsConfig) error {
+	if cfg == nil || len(cfg.Quotas) == 0 {
+		return nil
+	}
+
+	limiter, ok := engine.(storage.QuotaLimiter)
+	if !ok {
+		return fmt.Errorf("engine doesn't support quotas")
+	}
+
+	quotas := make([]storage.Quota, 0, len(cfg.Quotas))
+	for _, quota := range cfg.Quotas {
+		maxMemory, err := parser.ParseSize(quota.MaxMemory)
+		if err != nil {
+			return fmt.Errorf("unable to parse max memory of quota %q: %v", quota.Prefix, err)
+		}
+
+		quotas = append(quotas, storage.Quota{
+			DB:        quota.DB,
+			Prefix:    quota.Prefix,
+			MaxKeys:   quota.MaxKeys,
+			MaxMemory: int64(maxMemory),
+		})
+	}
+	limiter.SetQuotas(quotas)
+
+	return nil
+}
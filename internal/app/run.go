@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/gateway"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/replication"
+	"concurrency_go_course/internal/rpc"
+	"concurrency_go_course/pkg/logger"
+	"concurrency_go_course/pkg/metrics"
+)
+
+// Run runs database server until context is done. Shutdown is ordered:
+// servers stop accepting connections and drain in-flight requests,
+// then WAL flushes the last batch and replication is stopped
+func Run(ctx context.Context, cfg *config.Config, walCfg *config.WALCfg) error {
+	db, wal, repl, err := Init(cfg, walCfg)
+	if err != nil {
+		return fmt.Errorf("unable to init app: %w", err)
+	}
+
+	// WAL and replication are stopped after servers are drained,
+	// so they have own contexts
+	walCtx, stopWAL := context.WithCancel(context.Background())
+	defer stopWAL()
+
+	replCtx, stopReplication := context.WithCancel(context.Background())
+	defer stopReplication()
+
+	if wal != nil && (cfg.Replication == nil ||
+		cfg.Replication.ReplicaType == replication.ReplicaTypeMaster) {
+		logger.Debug("starting WAL")
+		wal.Start(walCtx)
+	} else {
+		wal = nil
+	}
+
+	var replWG sync.WaitGroup
+	if cfg.Replication != nil {
+		logger.Debug("starting replication")
+		if repl.Master != nil {
+			replWG.Add(1)
+			go func() {
+				defer replWG.Done()
+
+				repl.Master.Start(replCtx)
+			}()
+		} else if repl.Slave != nil {
+			replWG.Add(1)
+			go func() {
+				defer replWG.Done()
+
+				repl.Slave.Start(replCtx)
+			}()
+		}
+	}
+
+	server, err := network.NewServer(cfg, cfg.Network.Address)
+	if err != nil {
+		return fmt.Errorf("unable to start server: %w", err)
+	}
+
+	var wg sync.WaitGroup
+
+	if cfg.Metrics != nil && cfg.Metrics.Address != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			logger.Debug("starting metrics endpoint")
+			if err := metrics.ListenAndServe(ctx, cfg.Metrics.Address); err != nil {
+				logger.ErrorWithMsg("metrics endpoint error:", err)
+			}
+		}()
+	}
+
+	if cfg.HTTP != nil && cfg.HTTP.Address != "" {
+		httpServer, err := gateway.NewServer(cfg, db)
+		if err != nil {
+			return fmt.Errorf("unable to start HTTP gateway: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := httpServer.ListenAndServe(ctx, cfg.HTTP.Address); err != nil {
+				logger.ErrorWithMsg("HTTP gateway error:", err)
+			}
+		}()
+	}
+
+	if cfg.GRPC != nil && cfg.GRPC.Address != "" {
+		grpcServer, err := rpc.NewServer(cfg, db)
+		if err != nil {
+			return fmt.Errorf("unable to start gRPC server: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := grpcServer.ListenAndServe(ctx, cfg.GRPC.Address); err != nil {
+				logger.ErrorWithMsg("gRPC server error:", err)
+			}
+		}()
+	}
+
+	server.Run(ctx, func(ctx context.Context, s []byte) []byte {
+		response, err := db.HandleContext(ctx, string(s)+"\n")
+		if err != nil {
+			logger.ErrorWithMsg("unable to handle query:", err)
+			response = err.Error()
+		}
+		return []byte(response)
+	})
+
+	wg.Wait()
+	logger.Info("Servers were stopped")
+
+	if wal != nil {
+		stopWAL()
+		<-wal.Done()
+		logger.Info("WAL was flushed")
+	}
+
+	stopReplication()
+	replWG.Wait()
+	logger.Info("Replication was stopped")
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestRunNoAcknowledgedWriteLost(t *testing.T) {
+	logger.MockLogger()
+
+	addr := freeAddress(t)
+
+	cfg := config.DefaultConfig()
+	cfg.Network.Address = addr
+	cfg.Network.MaxConnections = 10
+	cfg.Network.ShutdownTimeout = "1s"
+
+	walCfg := &config.WALCfg{
+		WalConfig: &config.WALSettings{
+			FlushingBatchSize:    1000,
+			FlushingBatchTimeout: "50ms",
+			MaxSegmentSize:       "1MB",
+			DataDirectory:        t.TempDir(),
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- Run(ctx, cfg, walCfg)
+	}()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", addr)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	acknowledged := make(map[string]string)
+	buffer := make([]byte, 1024)
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for i := 0; ; i++ {
+		if time.Now().After(deadline) {
+			// shutdown starts while writes are in flight
+			cancel()
+		}
+
+		key, value := fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)
+		if _, err := conn.Write([]byte(fmt.Sprintf("SET %s %s", key, value))); err != nil {
+			break
+		}
+
+		size, err := conn.Read(buffer)
+		if err != nil {
+			break
+		}
+		if string(buffer[:size]) == "OK\n" {
+			acknowledged[key] = value
+		}
+	}
+
+	require.NoError(t, <-stopped)
+	require.NotEmpty(t, acknowledged)
+
+	walObj, err := wal.New(walCfg)
+	require.NoError(t, err)
+
+	requests, err := walObj.Recover()
+	require.NoError(t, err)
+
+	recovered := make(map[string]string)
+	for _, request := range requests {
+		if request.Command == compute.CommandSet {
+			recovered[request.Args[0]] = request.Args[1]
+		}
+	}
+
+	for key, value := range acknowledged {
+		assert.Equal(t, value, recovered[key], "acknowledged write of %s is lost", key)
+	}
+}
+
+// freeAddress returns address with port which is free at the moment,
+// Run listens on configured address, so it can't be given port 0
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
@@ -0,0 +1,331 @@
+// Package backup creates consistent archives of WAL of running server
+// and restores them into data directory of new node
+package backup
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+// Archive is a tar file with entries:
+//
+//	MANIFEST.json  manifest of backup
+//	wal_<ms>.log   snapshot segments with state before WAL tail
+//	wal_<ms>.log   WAL tail, the last segment at backup point
+//
+// Segments are written in order of their names, so archive extracted
+// into empty directory is a data directory recovering state at backup point
+const (
+	manifestName = "MANIFEST.json"
+
+	defaultPartitionsNumber = 256
+)
+
+var segmentNameRegexp = regexp.MustCompile(`^wal_\d+\.log$`)
+
+// ErrInProgress is returned if backup is started while another one is running
+var ErrInProgress = errors.New("backup is already in progress")
+
+// Manifest describes backup archive
+type Manifest struct {
+	Created time.Time `json:"created"`
+	// Point is the last segment at backup point, it is the WAL tail
+	// of archive. It is empty if nothing was written before backup
+	Point string `json:"point"`
+	// Snapshot is a list of segments with state of database built
+	// from segments before WAL tail
+	Snapshot []string `json:"snapshot"`
+	// Compacted is a number of segments replaced by snapshot
+	Compacted int `json:"compacted"`
+}
+
+// Backuper creates backups of WAL of running server
+type Backuper struct {
+	wal     *wal.WAL
+	fileLib filesystem.FileLib
+	mutex   sync.Mutex
+}
+
+// New returns new backuper of WAL
+func New(w *wal.WAL) *Backuper {
+	return &Backuper{
+		wal:     w,
+		fileLib: filesystem.NewFileLib(),
+	}
+}
+
+// Create writes backup archive to path. Writers are blocked only while WAL
+// segment is rotated at backup point, then segments before the point are
+// read from disk. Earlier segments are replayed into snapshot, the last
+// one is archived as is, so archive restores state at backup point
+func (b *Backuper) Create(path string) (Manifest, error) {
+	if !b.mutex.TryLock() {
+		return Manifest{}, ErrInProgress
+	}
+	defer b.mutex.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return Manifest{}, fmt.Errorf("backup file %s already exists", path)
+	}
+
+	point, err := b.wal.Rotate()
+	if err != nil {
+		return Manifest{}, fmt.Errorf("unable to rotate WAL: %w", err)
+	}
+
+	settings := b.wal.Settings()
+	filenames, err := b.fileLib.FilenamesFromDir(settings.DataDirectory)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	// segments created after backup point aren't archived
+	filenames = slices.DeleteFunc(filenames, func(name string) bool {
+		return point == "" || name > point
+	})
+
+	manifest := Manifest{Created: time.Now().UTC(), Point: point}
+	files := make([]archiveFile, 0, len(filenames))
+
+	if len(filenames) > 1 {
+		snapshotDir, err := os.MkdirTemp(filepath.Dir(path), ".backup-")
+		if err != nil {
+			return Manifest{}, err
+		}
+		defer os.RemoveAll(snapshotDir) //nolint:errcheck
+
+		snapshot, err := b.snapshot(&settings, filenames[:len(filenames)-1], snapshotDir)
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		// snapshot segments replace compacted ones, so they are named
+		// to be recovered right before WAL tail
+		tailTime := filesystem.SegmentTime(point)
+		for i, name := range snapshot {
+			archiveName := filesystem.SegmentName(tailTime - int64(len(snapshot)-i))
+			files = append(files, archiveFile{name: archiveName, path: filepath.Join(snapshotDir, name)})
+			manifest.Snapshot = append(manifest.Snapshot, archiveName)
+		}
+		manifest.Compacted = len(filenames) - 1
+	}
+
+	if point != "" {
+		files = append(files, archiveFile{name: point, path: filepath.Join(settings.DataDirectory, point)})
+	}
+
+	if err := writeArchive(path, manifest, files); err != nil {
+		return Manifest{}, fmt.Errorf("unable to write backup: %w", err)
+	}
+
+	logger.Info("Backup was created", zap.String("path", path), zap.String("point", point),
+		zap.Int("snapshot_segments", len(manifest.Snapshot)), zap.Int("compacted_segments", manifest.Compacted))
+
+	return manifest, nil
+}
+
+// snapshot replays segments and writes state of database to segments
+// in dir. It returns names of written segments
+func (b *Backuper) snapshot(settings *wal.Settings, filenames []string, dir string) ([]string, error) {
+	stor, err := storage.New(storage.NewEngine(defaultPartitionsNumber), nil, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range filenames {
+		data, err := b.fileLib.DataFromFiles(settings.DataDirectory, []string{name})
+		if err != nil {
+			return nil, err
+		}
+
+		requests, err := wal.DecodeRequests(filesystem.SegmentPayload(data[0]), settings.Keyring)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read segment %s: %w", name, err)
+		}
+		stor.Restore(requests)
+	}
+
+	if err := wal.WriteRequests(dir, settings, stor.Snapshot()); err != nil {
+		return nil, fmt.Errorf("unable to write snapshot: %w", err)
+	}
+
+	return b.fileLib.FilenamesFromDir(dir)
+}
+
+// archiveFile is a segment file written to archive under name
+type archiveFile struct {
+	name string
+	path string
+}
+
+// writeArchive writes archive to temporary file and renames it to path
+// when it is synced, so incomplete archive is never left at path
+func writeArchive(path string, manifest Manifest, files []archiveFile) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(filepath.Clean(tmpPath), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath) //nolint:errcheck
+	defer file.Close()       //nolint:errcheck
+
+	writer := tar.NewWriter(file)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(writer, manifestName, manifest.Created, data); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Clean(f.path))
+		if err != nil {
+			return err
+		}
+
+		// preallocated segment is archived without header and padding
+		if err := writeEntry(writer, f.name, manifest.Created, filesystem.SegmentPayload(data)); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func writeEntry(writer *tar.Writer, name string, modTime time.Time, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := writer.Write(data)
+	return err
+}
+
+// Restore extracts archive into data directory of new node. Directory
+// must be empty or not exist, it recovers state at backup point on start
+func Restore(archivePath, dir string) (Manifest, error) {
+	if err := emptyDir(dir); err != nil {
+		return Manifest{}, err
+	}
+
+	file, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	var manifest *Manifest
+	var segments []string
+
+	fileLib := filesystem.NewFileLib()
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("unable to read backup: %w", err)
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("unable to read backup: %w", err)
+		}
+
+		if header.Name == manifestName {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return Manifest{}, fmt.Errorf("unable to read backup manifest: %w", err)
+			}
+			continue
+		}
+
+		if !segmentNameRegexp.MatchString(header.Name) {
+			return Manifest{}, fmt.Errorf("unexpected file %s in backup", header.Name)
+		}
+
+		if err := writeSegment(fileLib, filepath.Join(dir, header.Name), data); err != nil {
+			return Manifest{}, err
+		}
+		segments = append(segments, header.Name)
+	}
+
+	if manifest == nil {
+		return Manifest{}, fmt.Errorf("backup has no manifest")
+	}
+
+	expected := manifest.Snapshot
+	if manifest.Point != "" {
+		expected = append(slices.Clone(expected), manifest.Point)
+	}
+	if !slices.Equal(expected, segments) {
+		return Manifest{}, fmt.Errorf("backup is incomplete: expected segments %v, got %v", expected, segments)
+	}
+
+	return *manifest, nil
+}
+
+func writeSegment(fileLib filesystem.FileLib, path string, data []byte) error {
+	file, err := fileLib.CreateFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	if _, err := fileLib.WriteFile(file, data); err != nil {
+		return err
+	}
+
+	return fileLib.SyncFile(file)
+}
+
+func emptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0o750)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(entries) != 0 {
+		return fmt.Errorf("directory %s isn't empty", dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.MockLogger()
+
+	os.Exit(m.Run())
+}
+
+func openStorage(t *testing.T, dir string) (storage.Storage, *wal.WAL, context.CancelFunc) {
+	t.Helper()
+
+	walObj, err := wal.New(&config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        dir,
+		MaxSegmentSize:       "200B",
+		FlushingBatchSize:    1,
+		FlushingBatchTimeout: "1ms",
+	}})
+	require.NoError(t, err)
+
+	stor, err := storage.New(storage.NewEngine(4), walObj, "", nil, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	walObj.Start(ctx)
+
+	return stor, walObj, func() {
+		cancel()
+		<-walObj.Done()
+	}
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	stor, walObj, stop := openStorage(t, dir)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, stor.Set(context.Background(), "key"+strconv.Itoa(i), "value"+strconv.Itoa(i)))
+	}
+	_, err := stor.Incr(context.Background(), "counter", 5)
+	require.NoError(t, err)
+	_, err = stor.RPush(context.Background(), "list", []string{"a", "b"})
+	require.NoError(t, err)
+	require.NoError(t, stor.Del(context.Background(), "key0"))
+
+	// keys of logical databases are restored into their databases
+	require.NoError(t, stor.Select(1).Set(context.Background(), "key1", "db1"))
+	require.NoError(t, stor.Select(2).Set(context.Background(), "key1", "db2"))
+	require.NoError(t, stor.Select(2).FlushDB(context.Background()))
+
+	path := filepath.Join(t.TempDir(), "backup.tar")
+	manifest, err := New(walObj).Create(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, manifest.Point)
+	assert.NotEmpty(t, manifest.Snapshot)
+	assert.Positive(t, manifest.Compacted)
+
+	_, err = New(walObj).Create(path)
+	assert.Error(t, err, "existing backup isn't overwritten")
+
+	// writes after backup point aren't in archive
+	require.NoError(t, stor.Set(context.Background(), "after", "backup"))
+	_, err = stor.Incr(context.Background(), "counter", 1)
+	require.NoError(t, err)
+	stop()
+
+	restoreDir := filepath.Join(t.TempDir(), "data")
+	restored, err := Restore(path, restoreDir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Point, restored.Point)
+	assert.Equal(t, manifest.Snapshot, restored.Snapshot)
+
+	stor, _, stop = openStorage(t, restoreDir)
+	defer stop()
+
+	_, ok := stor.Get("key0")
+	assert.False(t, ok)
+	for i := 1; i < 20; i++ {
+		value, ok := stor.Get("key" + strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "value"+strconv.Itoa(i), value)
+	}
+
+	value, _ := stor.Get("counter")
+	assert.Equal(t, "5", value)
+
+	list, err := stor.LRange("list", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	_, ok = stor.Get("after")
+	assert.False(t, ok)
+
+	value, _ = stor.Select(1).Get("key1")
+	assert.Equal(t, "db1", value)
+	_, ok = stor.Select(2).Get("key1")
+	assert.False(t, ok)
+}
+
+func TestCreateEmpty(t *testing.T) {
+	dir := t.TempDir()
+	_, walObj, stop := openStorage(t, dir)
+	defer stop()
+
+	path := filepath.Join(t.TempDir(), "backup.tar")
+	manifest, err := New(walObj).Create(path)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Point)
+
+	restored, err := Restore(path, t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, restored.Point)
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wal_1.log"), []byte("data"), 0o600))
+
+	_, err := Restore(filepath.Join(t.TempDir(), "backup.tar"), dir)
+	assert.ErrorContains(t, err, "isn't empty")
+
+	writeTar := func(t *testing.T, names ...string) string {
+		path := filepath.Join(t.TempDir(), "backup.tar")
+		file, err := os.Create(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		writer := tar.NewWriter(file)
+		for _, name := range names {
+			require.NoError(t, writeEntry(writer, name, time.Now(), []byte("{}")))
+		}
+		require.NoError(t, writer.Close())
+
+		return path
+	}
+
+	_, err = Restore(writeTar(t, "wal_1.log"), t.TempDir())
+	assert.ErrorContains(t, err, "no manifest")
+
+	_, err = Restore(writeTar(t, manifestName, "../wal_1.log"), t.TempDir())
+	assert.ErrorContains(t, err, "unexpected file")
+
+	_, err = Restore(writeTar(t, manifestName, "wal_1.log"), t.TempDir())
+	assert.ErrorContains(t, err, "incomplete")
+}
+
+func TestCreateWithConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	stor, walObj, stop := openStorage(t, dir)
+	defer stop()
+
+	const writers = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for n := 0; ctx.Err() == nil; n++ {
+				assert.NoError(t, stor.Set(context.Background(), fmt.Sprintf("w%d-%d", w, n), "value"))
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	path := filepath.Join(t.TempDir(), "backup.tar")
+	_, err := New(walObj).Create(path)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	restoreDir := filepath.Join(t.TempDir(), "data")
+	_, err = Restore(path, restoreDir)
+	require.NoError(t, err)
+
+	restored, _, stopRestored := openStorage(t, restoreDir)
+	defer stopRestored()
+
+	// every writer's keys are a prefix of its writes
+	for w := 0; w < writers; w++ {
+		n := 0
+		for ; ; n++ {
+			if _, ok := restored.Get(fmt.Sprintf("w%d-%d", w, n)); !ok {
+				break
+			}
+		}
+		_, ok := restored.Get(fmt.Sprintf("w%d-%d", w, n+1))
+		assert.False(t, ok, "writer %d has gap after %d keys", w, n)
+	}
+}
@@ -13,6 +13,77 @@ const (
 	CommandSet = "SET"
 	// CommandDelete is a delete command
 	CommandDelete = "DEL"
+	// CommandIncr is an increment command
+	CommandIncr = "INCR"
+	// CommandDecr is a decrement command
+	CommandDecr = "DECR"
+	// CommandIncrBy is an increment by value command
+	CommandIncrBy = "INCRBY"
+	// CommandAppend is an append command
+	CommandAppend = "APPEND"
+	// CommandGetSet is a get and set command
+	CommandGetSet = "GETSET"
+
+	// CommandHSet is a hash set command
+	CommandHSet = "HSET"
+	// CommandHGet is a hash get command
+	CommandHGet = "HGET"
+	// CommandHDel is a hash delete command
+	CommandHDel = "HDEL"
+	// CommandHGetAll is a hash get all command
+	CommandHGetAll = "HGETALL"
+
+	// CommandLPush is a list push to head command
+	CommandLPush = "LPUSH"
+	// CommandRPush is a list push to tail command
+	CommandRPush = "RPUSH"
+	// CommandLPop is a list pop from head command
+	CommandLPop = "LPOP"
+	// CommandLRange is a list range command
+	CommandLRange = "LRANGE"
+
+	// CommandSAdd is a set add command
+	CommandSAdd = "SADD"
+	// CommandSRem is a set remove command
+	CommandSRem = "SREM"
+	// CommandSMembers is a set members command
+	CommandSMembers = "SMEMBERS"
+
+	// CommandSubscribe is a subscribe on channels command
+	CommandSubscribe = "SUBSCRIBE"
+	// CommandPublish is a publish message command
+	CommandPublish = "PUBLISH"
+
+	// CommandWatch is a blocking watch on key command
+	CommandWatch = "WATCH"
+
+	// CommandInfo is a server statistics command
+	CommandInfo = "INFO"
+	// CommandBackup is a backup to archive command
+	CommandBackup = "BACKUP"
+	// CommandExport is an export of keys to file command
+	CommandExport = "EXPORT"
+	// CommandImport is an import of keys from file command
+	CommandImport = "IMPORT"
+
+	// CommandSelect is a select of logical database command
+	CommandSelect = "SELECT"
+	// CommandUse is an alias of select command
+	CommandUse = "USE"
+	// CommandFlushDB is a delete of all keys of logical database command
+	CommandFlushDB = "FLUSHDB"
+
+	// CommandAuth is an authentication of client session command
+	CommandAuth = "AUTH"
+
+	// CommandSlowLog is a slow log read and reset command
+	CommandSlowLog = "SLOWLOG"
+	// SlowLogGet is a subcommand returning last slow requests
+	SlowLogGet = "GET"
+	// SlowLogReset is a subcommand clearing slow log
+	SlowLogReset = "RESET"
+	// SlowLogLen is a subcommand returning number of slow requests
+	SlowLogLen = "LEN"
 )
 
 // Compute is interface for compute object
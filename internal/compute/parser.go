@@ -3,7 +3,9 @@ package compute
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Parser is interface for parser
@@ -29,7 +31,17 @@ func (r *RequestParser) Parse(query string) (Query, error) {
 
 	command := queryFields[0]
 
-	allCommands := []string{CommandGet, CommandSet, CommandDelete}
+	allCommands := []string{
+		CommandGet, CommandSet, CommandDelete,
+		CommandIncr, CommandDecr, CommandIncrBy, CommandAppend, CommandGetSet,
+		CommandHSet, CommandHGet, CommandHDel, CommandHGetAll,
+		CommandLPush, CommandRPush, CommandLPop, CommandLRange,
+		CommandSAdd, CommandSRem, CommandSMembers,
+		CommandSubscribe, CommandPublish, CommandWatch, CommandInfo,
+		CommandBackup, CommandExport, CommandImport,
+		CommandSelect, CommandUse, CommandFlushDB, CommandAuth,
+		CommandSlowLog,
+	}
 	if !slices.Contains(allCommands, command) {
 		return Query{}, fmt.Errorf("invalid command %s", command)
 	}
@@ -37,22 +49,120 @@ func (r *RequestParser) Parse(query string) (Query, error) {
 	argsLen := len(queryFields[1:])
 
 	switch command {
-	case CommandGet:
-		if len(queryFields[1:]) != 1 {
+	case CommandInfo, CommandFlushDB:
+		if argsLen != 0 {
+			return Query{}, fmt.Errorf("for command %s expected 0 arguments, got %d",
+				command, argsLen)
+		}
+	case CommandGet, CommandDelete, CommandIncr, CommandDecr,
+		CommandHGetAll, CommandLPop, CommandSMembers, CommandBackup:
+		if argsLen != 1 {
 			return Query{}, fmt.Errorf("for command %s expected 1 argument, got %d",
-				CommandGet, argsLen)
+				command, argsLen)
+		}
+	case CommandSet, CommandAppend, CommandGetSet, CommandHGet, CommandPublish, CommandAuth:
+		if argsLen != 2 {
+			return Query{}, fmt.Errorf("for command %s expected 2 arguments, got %d",
+				command, argsLen)
 		}
-	case CommandSet:
-		if len(queryFields[1:]) != 2 {
+	case CommandIncrBy:
+		if argsLen != 2 {
 			return Query{}, fmt.Errorf("for command %s expected 2 arguments, got %d",
-				CommandSet, argsLen)
+				command, argsLen)
+		}
+		if _, err := strconv.ParseInt(queryFields[2], 10, 64); err != nil {
+			return Query{}, fmt.Errorf("for command %s expected integer increment, got %s",
+				command, queryFields[2])
+		}
+	case CommandHSet:
+		if argsLen < 3 || argsLen%2 == 0 {
+			return Query{}, fmt.Errorf("for command %s expected key and field value pairs, got %d arguments",
+				command, argsLen)
 		}
-	case CommandDelete:
-		if len(queryFields[1:]) != 1 {
+	case CommandWatch:
+		if argsLen != 1 && argsLen != 2 {
+			return Query{}, fmt.Errorf("for command %s expected 1 or 2 arguments, got %d",
+				command, argsLen)
+		}
+		if argsLen == 2 {
+			if _, err := time.ParseDuration(queryFields[2]); err != nil {
+				return Query{}, fmt.Errorf("for command %s expected duration timeout, got %s",
+					command, queryFields[2])
+			}
+		}
+	case CommandSelect, CommandUse:
+		if argsLen != 1 {
 			return Query{}, fmt.Errorf("for command %s expected 1 argument, got %d",
-				CommandDelete, argsLen)
+				command, argsLen)
+		}
+		if db, err := strconv.Atoi(queryFields[1]); err != nil || db < 0 {
+			return Query{}, fmt.Errorf("for command %s expected non-negative database number, got %s",
+				command, queryFields[1])
+		}
+	case CommandExport, CommandImport:
+		if argsLen != 2 && argsLen != 3 {
+			return Query{}, fmt.Errorf("for command %s expected path, format and optional prefix, got %d arguments",
+				command, argsLen)
+		}
+	case CommandSlowLog:
+		if err := parseSlowLog(queryFields[1:]); err != nil {
+			return Query{}, err
+		}
+	case CommandSubscribe:
+		if argsLen == 0 {
+			return Query{}, fmt.Errorf("for command %s expected at least 1 argument, got 0",
+				command)
+		}
+	case CommandHDel, CommandLPush, CommandRPush, CommandSAdd, CommandSRem:
+		if argsLen < 2 {
+			return Query{}, fmt.Errorf("for command %s expected at least 2 arguments, got %d",
+				command, argsLen)
+		}
+	case CommandLRange:
+		if argsLen != 3 {
+			return Query{}, fmt.Errorf("for command %s expected 3 arguments, got %d",
+				command, argsLen)
+		}
+		for _, index := range queryFields[2:] {
+			if _, err := strconv.Atoi(index); err != nil {
+				return Query{}, fmt.Errorf("for command %s expected integer index, got %s",
+					command, index)
+			}
 		}
 	}
 
 	return NewQuery(command, queryFields[1:]), nil
 }
+
+// parseSlowLog checks arguments of SLOWLOG: GET with optional count,
+// RESET or LEN subcommand
+func parseSlowLog(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("for command %s expected subcommand %s, %s or %s",
+			CommandSlowLog, SlowLogGet, SlowLogReset, SlowLogLen)
+	}
+
+	switch args[0] {
+	case SlowLogGet:
+		if len(args) > 2 {
+			return fmt.Errorf("for command %s %s expected optional count, got %d arguments",
+				CommandSlowLog, SlowLogGet, len(args)-1)
+		}
+		if len(args) == 2 {
+			if count, err := strconv.Atoi(args[1]); err != nil || count < 0 {
+				return fmt.Errorf("for command %s %s expected non-negative count, got %s",
+					CommandSlowLog, SlowLogGet, args[1])
+			}
+		}
+	case SlowLogReset, SlowLogLen:
+		if len(args) != 1 {
+			return fmt.Errorf("for command %s %s expected 0 arguments, got %d",
+				CommandSlowLog, args[0], len(args)-1)
+		}
+	default:
+		return fmt.Errorf("for command %s expected subcommand %s, %s or %s, got %s",
+			CommandSlowLog, SlowLogGet, SlowLogReset, SlowLogLen, args[0])
+	}
+
+	return nil
+}
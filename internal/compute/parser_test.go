@@ -77,6 +77,111 @@ func TestParseRequestParserNeg(t *testing.T) {
 			query: Query{},
 			err:   fmt.Errorf("for command DEL expected 1 argument, got 2"),
 		},
+		"INCR: with 2 args": {
+			in:    "INCR key 1",
+			query: Query{},
+			err:   fmt.Errorf("for command INCR expected 1 argument, got 2"),
+		},
+		"DECR: without args": {
+			in:    "DECR",
+			query: Query{},
+			err:   fmt.Errorf("for command DECR expected 1 argument, got 0"),
+		},
+		"INCRBY: with 1 args": {
+			in:    "INCRBY key",
+			query: Query{},
+			err:   fmt.Errorf("for command INCRBY expected 2 arguments, got 1"),
+		},
+		"INCRBY: not integer increment": {
+			in:    "INCRBY key abc",
+			query: Query{},
+			err:   fmt.Errorf("for command INCRBY expected integer increment, got abc"),
+		},
+		"APPEND: with 1 args": {
+			in:    "APPEND key",
+			query: Query{},
+			err:   fmt.Errorf("for command APPEND expected 2 arguments, got 1"),
+		},
+		"GETSET: with 3 args": {
+			in:    "GETSET key value value",
+			query: Query{},
+			err:   fmt.Errorf("for command GETSET expected 2 arguments, got 3"),
+		},
+		"HSET: without value": {
+			in:    "HSET key field",
+			query: Query{},
+			err:   fmt.Errorf("for command HSET expected key and field value pairs, got 2 arguments"),
+		},
+		"HSET: with incomplete pair": {
+			in:    "HSET key f1 v1 f2",
+			query: Query{},
+			err:   fmt.Errorf("for command HSET expected key and field value pairs, got 4 arguments"),
+		},
+		"LPUSH: without values": {
+			in:    "LPUSH key",
+			query: Query{},
+			err:   fmt.Errorf("for command LPUSH expected at least 2 arguments, got 1"),
+		},
+		"LRANGE: not integer index": {
+			in:    "LRANGE key 0 end",
+			query: Query{},
+			err:   fmt.Errorf("for command LRANGE expected integer index, got end"),
+		},
+		"SUBSCRIBE: without channels": {
+			in:    "SUBSCRIBE",
+			query: Query{},
+			err:   fmt.Errorf("for command SUBSCRIBE expected at least 1 argument, got 0"),
+		},
+		"PUBLISH: without message": {
+			in:    "PUBLISH channel",
+			query: Query{},
+			err:   fmt.Errorf("for command PUBLISH expected 2 arguments, got 1"),
+		},
+		"WATCH: with 3 args": {
+			in:    "WATCH key 1s 2s",
+			query: Query{},
+			err:   fmt.Errorf("for command WATCH expected 1 or 2 arguments, got 3"),
+		},
+		"WATCH: invalid timeout": {
+			in:    "WATCH key soon",
+			query: Query{},
+			err:   fmt.Errorf("for command WATCH expected duration timeout, got soon"),
+		},
+		"SMEMBERS: with 2 args": {
+			in:    "SMEMBERS key member",
+			query: Query{},
+			err:   fmt.Errorf("for command SMEMBERS expected 1 argument, got 2"),
+		},
+		"SELECT: not a number": {
+			in:    "SELECT users",
+			query: Query{},
+			err:   fmt.Errorf("for command SELECT expected non-negative database number, got users"),
+		},
+		"FLUSHDB: with args": {
+			in:    "FLUSHDB key",
+			query: Query{},
+			err:   fmt.Errorf("for command FLUSHDB expected 0 arguments, got 1"),
+		},
+		"AUTH: without password": {
+			in:    "AUTH alice",
+			query: Query{},
+			err:   fmt.Errorf("for command AUTH expected 2 arguments, got 1"),
+		},
+		"SLOWLOG: without subcommand": {
+			in:    "SLOWLOG",
+			query: Query{},
+			err:   fmt.Errorf("for command SLOWLOG expected subcommand GET, RESET or LEN"),
+		},
+		"SLOWLOG: unknown subcommand": {
+			in:    "SLOWLOG CLEAR",
+			query: Query{},
+			err:   fmt.Errorf("for command SLOWLOG expected subcommand GET, RESET or LEN, got CLEAR"),
+		},
+		"SLOWLOG GET: negative count": {
+			in:    "SLOWLOG GET -1",
+			query: Query{},
+			err:   fmt.Errorf("for command SLOWLOG GET expected non-negative count, got -1"),
+		},
 	}
 
 	for name, test := range negTests {
@@ -108,6 +213,62 @@ func TestParseRequestParserPos(t *testing.T) {
 			in:    "DEL key",
 			query: Query{Command: "DEL", Args: []string{"key"}},
 		},
+		"correct INCR test": {
+			in:    "INCR key",
+			query: Query{Command: "INCR", Args: []string{"key"}},
+		},
+		"correct DECR test": {
+			in:    "DECR key",
+			query: Query{Command: "DECR", Args: []string{"key"}},
+		},
+		"correct INCRBY test": {
+			in:    "INCRBY key -5",
+			query: Query{Command: "INCRBY", Args: []string{"key", "-5"}},
+		},
+		"correct APPEND test": {
+			in:    "APPEND key value",
+			query: Query{Command: "APPEND", Args: []string{"key", "value"}},
+		},
+		"correct GETSET test": {
+			in:    "GETSET key value",
+			query: Query{Command: "GETSET", Args: []string{"key", "value"}},
+		},
+		"correct HSET test": {
+			in:    "HSET key f1 v1 f2 v2",
+			query: Query{Command: "HSET", Args: []string{"key", "f1", "v1", "f2", "v2"}},
+		},
+		"correct HDEL test": {
+			in:    "HDEL key f1 f2",
+			query: Query{Command: "HDEL", Args: []string{"key", "f1", "f2"}},
+		},
+		"correct LRANGE test": {
+			in:    "LRANGE key 0 -1",
+			query: Query{Command: "LRANGE", Args: []string{"key", "0", "-1"}},
+		},
+		"correct SUBSCRIBE test": {
+			in:    "SUBSCRIBE ch1 ch2",
+			query: Query{Command: "SUBSCRIBE", Args: []string{"ch1", "ch2"}},
+		},
+		"correct PUBLISH test": {
+			in:    "PUBLISH ch1 hello",
+			query: Query{Command: "PUBLISH", Args: []string{"ch1", "hello"}},
+		},
+		"correct WATCH test": {
+			in:    "WATCH key 10s",
+			query: Query{Command: "WATCH", Args: []string{"key", "10s"}},
+		},
+		"correct SADD test": {
+			in:    "SADD key m1 m2",
+			query: Query{Command: "SADD", Args: []string{"key", "m1", "m2"}},
+		},
+		"correct USE test": {
+			in:    "USE 2",
+			query: Query{Command: "USE", Args: []string{"2"}},
+		},
+		"correct SLOWLOG test": {
+			in:    "SLOWLOG GET 5",
+			query: Query{Command: "SLOWLOG", Args: []string{"GET", "5"}},
+		},
 	}
 
 	for name, test := range posTests {
@@ -1,5 +1,11 @@
 package compute
 
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
 // Query is a struct for query
 type Query struct {
 	Command string
@@ -23,3 +29,18 @@ func (q *Query) CommandName() string {
 func (q *Query) Arguments() []string {
 	return q.Args
 }
+
+// ErrInvalidArgument is returned if argument can't be written to request
+var ErrInvalidArgument = errors.New("arguments must be non-empty and must not contain whitespaces")
+
+// Format returns request string of query. Arguments are separated
+// by whitespaces in request, so they can't be empty or contain them
+func (q *Query) Format() (string, error) {
+	for _, arg := range q.Args {
+		if arg == "" || strings.ContainsFunc(arg, unicode.IsSpace) {
+			return "", ErrInvalidArgument
+		}
+	}
+
+	return strings.Join(append([]string{q.Command}, q.Args...), " ") + "\n", nil
+}
@@ -0,0 +1,44 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		query    Query
+		expected string
+		err      error
+	}{
+		"set": {
+			query:    NewQuery(CommandSet, []string{"key", "value"}),
+			expected: "SET key value\n",
+		},
+		"info": {
+			query:    NewQuery(CommandInfo, nil),
+			expected: "INFO\n",
+		},
+		"empty argument": {
+			query: NewQuery(CommandGet, []string{""}),
+			err:   ErrInvalidArgument,
+		},
+		"argument with whitespace": {
+			query: NewQuery(CommandSet, []string{"key", "a b"}),
+			err:   ErrInvalidArgument,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request, err := test.query.Format()
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.expected, request)
+		})
+	}
+}
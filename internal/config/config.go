@@ -14,11 +14,13 @@ const (
 	defaultEngine           = "in_memory"
 	defaultPartitionsNumber = 256
 
-	defaultHost           = "127.0.0.1"
-	defaultPort           = "3223"
-	defaultMaxConnections = 0
-	defaultMaxMessageSize = "4KB"
-	defaultIdleTimeout    = "5m"
+	defaultHost            = "127.0.0.1"
+	defaultPort            = "3223"
+	defaultMaxConnections  = 100
+	defaultMaxMessageSize  = "4KB"
+	defaultIdleTimeout     = "5m"
+	defaultShutdownTimeout = "5s"
+	defaultQueueTimeout    = "1s"
 
 	defaultLogLevel  = "info"
 	defaultLogOutput = "log/output.log"
@@ -28,6 +30,10 @@ const (
 type EngineConfig struct {
 	Type             string `yaml:"type"`
 	PartitionsNumber int    `yaml:"partitions_number"`
+	MaxMemory        string `yaml:"max_memory"`
+	EvictionPolicy   string `yaml:"eviction_policy"`
+	// Databases is a number of logical databases, 16 if it isn't set
+	Databases int `yaml:"databases"`
 }
 
 // NetworkConfig is a struct for network config
@@ -36,6 +42,33 @@ type NetworkConfig struct {
 	MaxConnections int    `yaml:"max_connections"`
 	MaxMessageSize string `yaml:"max_message_size"`
 	IdleTimeout    string `yaml:"idle_timeout"`
+	// ShutdownTimeout limits waiting for in-flight requests on shutdown
+	ShutdownTimeout string `yaml:"shutdown_timeout"`
+	// MaxQueueSize limits connections waiting for free connection slot,
+	// connections over it are rejected at once
+	MaxQueueSize int `yaml:"max_queue_size"`
+	// QueueTimeout limits waiting of queued connection for free slot
+	QueueTimeout string `yaml:"queue_timeout"`
+}
+
+// GetShutdownTimeout returns shutdown timeout or default one if it isn't set
+func (c *NetworkConfig) GetShutdownTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.ShutdownTimeout)
+	if err != nil {
+		timeout, _ = time.ParseDuration(defaultShutdownTimeout)
+	}
+
+	return timeout
+}
+
+// GetQueueTimeout returns queue timeout or default one if it isn't set
+func (c *NetworkConfig) GetQueueTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.QueueTimeout)
+	if err != nil {
+		timeout, _ = time.ParseDuration(defaultQueueTimeout)
+	}
+
+	return timeout
 }
 
 // LoggingConfig is a struct for logging config
@@ -49,6 +82,84 @@ type ReplicationConfig struct {
 	ReplicaType   string        `yaml:"replica_type"`
 	MasterAddress string        `yaml:"master_address"`
 	SyncInterval  time.Duration `yaml:"sync_interval"`
+	// Compression of segments requested by slave from master, none or gzip
+	Compression string `yaml:"compression"`
+}
+
+// PubSubConfig is a struct for publish/subscribe config
+type PubSubConfig struct {
+	KeyspaceNotifications bool `yaml:"keyspace_notifications"`
+}
+
+// MetricsConfig is a struct for metrics config.
+// Metrics endpoint is disabled if address is empty
+type MetricsConfig struct {
+	Address string `yaml:"address"`
+}
+
+// HTTPConfig is a struct for HTTP gateway config.
+// Gateway is disabled if address is empty
+type HTTPConfig struct {
+	Address string `yaml:"address"`
+}
+
+// GRPCConfig is a struct for gRPC server config.
+// gRPC server is disabled if address is empty
+type GRPCConfig struct {
+	Address string `yaml:"address"`
+}
+
+// LimitsConfig is a struct for rate limits and quotas config
+type LimitsConfig struct {
+	// Client limits requests of every client IP address
+	Client *RateConfig `yaml:"client"`
+	// User limits requests of every user authenticated by AUTH
+	User   *RateConfig   `yaml:"user"`
+	Quotas []QuotaConfig `yaml:"quotas"`
+}
+
+// RateConfig is a struct for rate limit config. Zero limit isn't checked
+type RateConfig struct {
+	OpsPerSecond   int    `yaml:"ops_per_second"`
+	BytesPerSecond string `yaml:"bytes_per_second"`
+}
+
+// QuotaConfig is a struct for quota of keys with prefix in logical
+// database. Empty prefix limits the whole database
+type QuotaConfig struct {
+	DB        int    `yaml:"db"`
+	Prefix    string `yaml:"prefix"`
+	MaxKeys   int64  `yaml:"max_keys"`
+	MaxMemory string `yaml:"max_memory"`
+}
+
+// SlowLogConfig is a struct for slow log config
+type SlowLogConfig struct {
+	// Threshold is a minimal duration of logged request
+	Threshold string `yaml:"threshold"`
+	// MaxLen is a number of kept requests, zero disables slow log
+	MaxLen int `yaml:"max_len"`
+}
+
+// BackupConfig is a struct for BACKUP command config
+type BackupConfig struct {
+	// Directory is a directory of backup archives on server host, paths
+	// of BACKUP are relative to it. Command is disabled if it is empty
+	Directory string `yaml:"directory"`
+}
+
+// KeyspaceConfig is a struct for EXPORT and IMPORT commands config
+type KeyspaceConfig struct {
+	// Directory is a directory of export and import files on server host,
+	// paths of commands are relative to it. Commands are disabled if it is empty
+	Directory string `yaml:"directory"`
+}
+
+// UserConfig is a struct for user authenticated by AUTH command
+type UserConfig struct {
+	Name string `yaml:"name"`
+	// PasswordSHA256 is hex encoded SHA-256 hash of password
+	PasswordSHA256 string `yaml:"password_sha256"`
 }
 
 // Config is a struct for server config
@@ -57,6 +168,15 @@ type Config struct {
 	Network     *NetworkConfig     `yaml:"network"`
 	Logging     *LoggingConfig     `yaml:"logging"`
 	Replication *ReplicationConfig `yaml:"replication"`
+	PubSub      *PubSubConfig      `yaml:"pubsub"`
+	Metrics     *MetricsConfig     `yaml:"metrics"`
+	HTTP        *HTTPConfig        `yaml:"http"`
+	GRPC        *GRPCConfig        `yaml:"grpc"`
+	Limits      *LimitsConfig      `yaml:"limits"`
+	Users       []UserConfig       `yaml:"users"`
+	SlowLog     *SlowLogConfig     `yaml:"slowlog"`
+	Keyspace    *KeyspaceConfig    `yaml:"keyspace"`
+	Backup      *BackupConfig      `yaml:"backup"`
 }
 
 // WALSettings is a struct for WAL settings
@@ -65,6 +185,39 @@ type WALSettings struct {
 	FlushingBatchTimeout string `yaml:"flushing_batch_timeout"`
 	MaxSegmentSize       string `yaml:"max_segment_size"`
 	DataDirectory        string `yaml:"data_directory"`
+	// SyncMode is one of always, interval or none
+	SyncMode     string `yaml:"sync_mode"`
+	SyncInterval string `yaml:"sync_interval"`
+	// Preallocate reserves disk space of segment up to max segment size
+	Preallocate bool `yaml:"preallocate"`
+	// Datasync syncs segments by fdatasync instead of fsync
+	Datasync bool `yaml:"datasync"`
+	// Compression of batches is none or gzip
+	Compression string `yaml:"compression"`
+	// Encryption of batches is disabled if it isn't set
+	Encryption *EncryptionConfig `yaml:"encryption"`
+	// RecoverToTime is RFC3339 time to recover state to,
+	// requests written later are ignored
+	RecoverToTime string `yaml:"recover_to_time"`
+	// RecoverToPosition is segment:offset position of the first
+	// request to ignore on recovery
+	RecoverToPosition string `yaml:"recover_to_position"`
+}
+
+// EncryptionConfig is a struct for WAL encryption config.
+// New records are encrypted by active key, other keys
+// are kept to read records written before key rotation
+type EncryptionConfig struct {
+	ActiveKeyID string      `yaml:"active_key_id"`
+	Keys        []KeyConfig `yaml:"keys"`
+}
+
+// KeyConfig is a struct for encryption key config. Hex encoded
+// AES key is read from file or from environment variable
+type KeyConfig struct {
+	ID   string `yaml:"id"`
+	File string `yaml:"file"`
+	Env  string `yaml:"env"`
 }
 
 // WALCfg is a struct for WAL config
@@ -80,10 +233,11 @@ func DefaultConfig() *Config {
 			PartitionsNumber: defaultPartitionsNumber,
 		},
 		Network: &NetworkConfig{
-			Address:        defaultHost + ":" + defaultPort,
-			MaxConnections: defaultMaxConnections,
-			MaxMessageSize: defaultMaxMessageSize,
-			IdleTimeout:    defaultIdleTimeout,
+			Address:         defaultHost + ":" + defaultPort,
+			MaxConnections:  defaultMaxConnections,
+			MaxMessageSize:  defaultMaxMessageSize,
+			IdleTimeout:     defaultIdleTimeout,
+			ShutdownTimeout: defaultShutdownTimeout,
 		},
 		Logging: &LoggingConfig{
 			Level:  defaultLogLevel,
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/pkg/logger"
+)
+
+var (
+	// ErrInvalidCredentials is returned by AUTH for unknown user or wrong password
+	ErrInvalidCredentials = errors.New("invalid user or password")
+	// ErrAuthRequired is returned by commands of authenticated users
+	// if client session isn't authenticated
+	ErrAuthRequired = errors.New("authentication required")
+)
+
+// auth authenticates user of client session. Authenticated user
+// has own rate limit besides limit of client address
+func (s *database) auth(ctx context.Context, user, password string) (string, error) {
+	if len(s.users) == 0 {
+		return "", fmt.Errorf("authentication is disabled, no users are configured")
+	}
+
+	session, ok := network.SessionFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("unable to authenticate: no client session")
+	}
+
+	expected, ok := s.users[user]
+	hash := sha256.Sum256([]byte(password))
+	if !ok || subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hash[:])), []byte(expected)) != 1 {
+		logger.Info("Authentication failed", zap.String("user", user),
+			zap.String("address", session.RemoteAddr().String()))
+		return "", ErrInvalidCredentials
+	}
+
+	session.SetUser(user)
+	logger.Debug("Session was authenticated", zap.String("user", user))
+
+	return resultOK, nil
+}
+
+// authenticated returns error if client session isn't authenticated by AUTH
+func authenticated(ctx context.Context) error {
+	if session, ok := network.SessionFromContext(ctx); ok && session.User() != "" {
+		return nil
+	}
+
+	return ErrAuthRequired
+}
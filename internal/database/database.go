@@ -1,54 +1,203 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"concurrency_go_course/internal/backup"
 	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/pubsub"
+	"concurrency_go_course/internal/slowlog"
 	"concurrency_go_course/internal/storage"
 	"concurrency_go_course/pkg/logger"
 
 	"go.uber.org/zap"
 )
 
-var resultOK = "OK"
+// ErrNotFound is returned when value for key is not found
+var ErrNotFound = errors.New("value not found")
+
+var (
+	resultOK    = "OK"
+	resultNil   = "(nil)"
+	resultEmpty = "(empty)"
+)
 
 // Database is interface for database
 type Database interface {
 	Handle(request string) (string, error)
+	HandleContext(ctx context.Context, request string) (string, error)
+	WatchKey(ctx context.Context, key string, send func(value string, found bool) error) error
 }
 
+// ErrBackupDisabled is returned on backup if WAL isn't used
+// or backup directory isn't configured
+var ErrBackupDisabled = errors.New("backup requires WAL on master and backup directory")
+
+// DefaultDatabases is a default number of logical databases
+const DefaultDatabases = 16
+
 type database struct {
-	storage storage.Storage
-	compute compute.Compute
+	storage   storage.Storage
+	compute   compute.Compute
+	broker    *pubsub.Broker
+	backuper  *backup.Backuper
+	backupDir string
+	databases int
+
+	clientLimiter *limits.Limiter
+	userLimiter   *limits.Limiter
+	// users are hex encoded SHA-256 hashes of passwords by user name
+	users map[string]string
+
+	slowLog *slowlog.Log
+
+	// exportDir is a directory of EXPORT and IMPORT files
+	exportDir string
+}
+
+// Option is a func configuring database
+type Option func(*database)
+
+// WithBackuper enables BACKUP command creating archives by backuper
+// in directory dir
+func WithBackuper(backuper *backup.Backuper, dir string) Option {
+	return func(d *database) {
+		d.backuper = backuper
+		d.backupDir = dir
+	}
+}
+
+// WithDatabases sets number of logical databases selected by SELECT
+func WithDatabases(databases int) Option {
+	return func(d *database) {
+		if databases > 0 {
+			d.databases = databases
+		}
+	}
+}
+
+// WithRateLimits limits rate of requests of every client address
+// and authenticated user. Nil limiter doesn't limit requests
+func WithRateLimits(client, user *limits.Limiter) Option {
+	return func(d *database) {
+		d.clientLimiter = client
+		d.userLimiter = user
+	}
+}
+
+// WithUsers enables AUTH command authenticating users. Users are
+// hex encoded SHA-256 hashes of passwords by user name
+func WithUsers(users map[string]string) Option {
+	return func(d *database) {
+		d.users = users
+	}
+}
+
+// WithExportDirectory enables EXPORT and IMPORT commands reading and
+// writing files of directory dir
+func WithExportDirectory(dir string) Option {
+	return func(d *database) {
+		d.exportDir = dir
+	}
+}
+
+// WithSlowLog enables recording of slow requests to log read by SLOWLOG
+func WithSlowLog(log *slowlog.Log) Option {
+	return func(d *database) {
+		d.slowLog = log
+	}
 }
 
 // NewDatabase returns new database
 func NewDatabase(
 	storage storage.Storage,
 	compute compute.Compute,
+	broker *pubsub.Broker,
+	opts ...Option,
 ) Database {
-	return &database{
-		storage: storage,
-		compute: compute,
+	d := &database{
+		storage:   storage,
+		compute:   compute,
+		broker:    broker,
+		databases: DefaultDatabases,
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 // Handle handles request
 func (s *database) Handle(request string) (string, error) {
+	return s.HandleContext(context.Background(), request)
+}
+
+// HandleContext handles request of client connection.
+// Context holds client session or address if request came from network,
+// requests over rate limits of client are rejected with THROTTLED error.
+// Requests slower than slow log threshold are added to slow log.
+// Context is passed to storage and WAL waits: write whose context is
+// done before its group commit isn't applied and context error is returned
+func (s *database) HandleContext(ctx context.Context, request string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if err := s.throttle(ctx, len(request)); err != nil {
+		return "", err
+	}
+
+	ctx, trace, finish := s.trace(ctx)
+	defer finish()
+
+	parseStart := time.Now()
 	query, err := s.compute.Handle(request)
+	trace.Observe(slowlog.PhaseParse, parseStart)
 	if err != nil {
 		logger.ErrorWithMsg("Parsing request error:", err)
+		commandErrors.With(commandUnknown).Inc()
 
 		return "", err
 	}
+	s.record(ctx, trace, query)
+
+	start := time.Now()
+	response, err := s.handle(ctx, query)
+	elapsed := time.Since(start)
+	trace.Add(slowlog.PhaseApply, max(elapsed-trace.Phase(slowlog.PhaseWAL), 0))
+
+	commandsTotal.With(query.Command).Inc()
+	commandDuration.With(query.Command).Observe(elapsed.Seconds())
+	if err != nil {
+		commandErrors.With(query.Command).Inc()
+	}
+
+	return response, err
+}
+
+func (s *database) handle(ctx context.Context, query compute.Query) (string, error) {
+	var err error
+	stor := s.traced(ctx, s.selected(ctx))
 
 	switch query.Command {
 	case compute.CommandGet:
-		v, ok := s.storage.Get(query.Args[0])
+		v, ok, err := stor.GetString(query.Args[0])
+		if err != nil {
+			return "", err
+		}
 		if !ok {
 			logger.Error("get error: value not found")
 
-			return "", fmt.Errorf("value not found")
+			return "", ErrNotFound
 		}
 
 		logger.Debug("Value for key was found",
@@ -56,7 +205,7 @@ func (s *database) Handle(request string) (string, error) {
 
 		return v, nil
 	case compute.CommandSet:
-		err = s.storage.Set(query.Args[0], query.Args[1])
+		err = stor.Set(ctx, query.Args[0], query.Args[1])
 		if err != nil {
 			return "", err
 		}
@@ -66,15 +215,250 @@ func (s *database) Handle(request string) (string, error) {
 
 		return resultOK, nil
 	case compute.CommandDelete:
-		err = s.storage.Del(query.Args[0])
+		err = stor.Del(ctx, query.Args[0])
 		if err != nil {
 			return "", err
 		}
 
 		logger.Debug("Key was deleted", zap.String("key", query.Args[0]))
 
+		return resultOK, nil
+	case compute.CommandIncr, compute.CommandDecr, compute.CommandIncrBy:
+		delta, err := incrDelta(query)
+		if err != nil {
+			return "", err
+		}
+
+		v, err := stor.Incr(ctx, query.Args[0], delta)
+		if err != nil {
+			return "", err
+		}
+
+		logger.Debug("Key was incremented",
+			zap.String("key", query.Args[0]), zap.Int64("value", v))
+
+		return strconv.FormatInt(v, 10), nil
+	case compute.CommandAppend:
+		length, err := stor.Append(ctx, query.Args[0], query.Args[1])
+		if err != nil {
+			return "", err
+		}
+
+		logger.Debug("Value was appended",
+			zap.String("key", query.Args[0]), zap.String("value", query.Args[1]))
+
+		return strconv.Itoa(length), nil
+	case compute.CommandGetSet:
+		v, ok, err := stor.GetSet(ctx, query.Args[0], query.Args[1])
+		if err != nil {
+			return "", err
+		}
+
+		logger.Debug("Key with value was saved",
+			zap.String("key", query.Args[0]), zap.String("value", query.Args[1]))
+
+		if !ok {
+			return resultNil, nil
+		}
+
+		return v, nil
+	case compute.CommandHSet:
+		created, err := stor.HSet(ctx, query.Args[0], query.Args[1:])
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(created), nil
+	case compute.CommandHGet:
+		v, ok, err := stor.HGet(query.Args[0], query.Args[1])
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrNotFound
+		}
+
+		return v, nil
+	case compute.CommandHDel:
+		deleted, err := stor.HDel(ctx, query.Args[0], query.Args[1:])
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(deleted), nil
+	case compute.CommandHGetAll:
+		hash, err := stor.HGetAll(query.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		fields := make([]string, 0, len(hash))
+		for field := range hash {
+			fields = append(fields, field)
+		}
+		slices.Sort(fields)
+
+		pairs := make([]string, 0, 2*len(fields))
+		for _, field := range fields {
+			pairs = append(pairs, field, hash[field])
+		}
+
+		return formatList(pairs), nil
+	case compute.CommandLPush, compute.CommandRPush:
+		push := stor.LPush
+		if query.Command == compute.CommandRPush {
+			push = stor.RPush
+		}
+
+		length, err := push(ctx, query.Args[0], query.Args[1:])
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(length), nil
+	case compute.CommandLPop:
+		v, ok, err := stor.LPop(ctx, query.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return resultNil, nil
+		}
+
+		return v, nil
+	case compute.CommandLRange:
+		start, _ := strconv.Atoi(query.Args[1])
+		stop, _ := strconv.Atoi(query.Args[2])
+
+		values, err := stor.LRange(query.Args[0], start, stop)
+		if err != nil {
+			return "", err
+		}
+
+		return formatList(values), nil
+	case compute.CommandSAdd:
+		added, err := stor.SAdd(ctx, query.Args[0], query.Args[1:])
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(added), nil
+	case compute.CommandSRem:
+		removed, err := stor.SRem(ctx, query.Args[0], query.Args[1:])
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(removed), nil
+	case compute.CommandSMembers:
+		members, err := stor.SMembers(query.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		return formatList(members), nil
+	case compute.CommandSubscribe:
+		return s.subscribe(ctx, query.Args)
+	case compute.CommandPublish:
+		return s.publish(query.Args[0], query.Args[1])
+	case compute.CommandWatch:
+		return s.watch(ctx, stor, query.Args)
+	case compute.CommandInfo:
+		return s.info(), nil
+	case compute.CommandBackup:
+		return s.backup(ctx, query.Args[0])
+	case compute.CommandExport:
+		return s.exportKeys(ctx, query.Args)
+	case compute.CommandImport:
+		return s.importKeys(ctx, query.Args)
+	case compute.CommandSelect, compute.CommandUse:
+		return s.selectDB(ctx, query.Args[0])
+	case compute.CommandAuth:
+		return s.auth(ctx, query.Args[0], query.Args[1])
+	case compute.CommandSlowLog:
+		return s.slowLogCommand(query.Args)
+	case compute.CommandFlushDB:
+		if err := stor.FlushDB(ctx); err != nil {
+			return "", err
+		}
+
 		return resultOK, nil
 	}
 
 	return "", fmt.Errorf("unknown command: %s", query.Command)
 }
+
+func incrDelta(query compute.Query) (int64, error) {
+	switch query.Command {
+	case compute.CommandIncr:
+		return 1, nil
+	case compute.CommandDecr:
+		return -1, nil
+	}
+
+	delta, err := strconv.ParseInt(query.Args[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid increment %s", query.Args[1])
+	}
+
+	return delta, nil
+}
+
+// formatList joins values with spaces. Values can't contain whitespaces
+// because request arguments are split by them
+func formatList(values []string) string {
+	if len(values) == 0 {
+		return resultEmpty
+	}
+
+	return strings.Join(values, " ")
+}
+
+// info returns server statistics as space separated name:value pairs
+func (s *database) info() string {
+	stats := s.storage.MemoryStats()
+
+	return fmt.Sprintf("used_memory:%d max_memory:%d eviction_policy:%s evicted_keys:%d",
+		stats.UsedMemory, stats.MaxMemory, stats.EvictionPolicy, stats.EvictedKeys)
+}
+
+// backup writes backup archive to path of backup directory and returns
+// its point and contents as space separated name:value pairs. Archives
+// are written by authenticated users only
+func (s *database) backup(ctx context.Context, path string) (string, error) {
+	if s.backuper == nil || s.backupDir == "" {
+		return "", ErrBackupDisabled
+	}
+
+	if err := authenticated(ctx); err != nil {
+		return "", err
+	}
+
+	filename, err := confine(s.backupDir, path)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := s.backuper.Create(filename)
+	if err != nil {
+		return "", err
+	}
+
+	point := manifest.Point
+	if point == "" {
+		point = resultNil
+	}
+
+	return fmt.Sprintf("path:%s point:%s snapshot_segments:%d compacted_segments:%d",
+		path, point, len(manifest.Snapshot), manifest.Compacted), nil
+}
+
+// confine returns path relative to directory dir. Absolute paths and paths
+// leaving directory are rejected, so clients can't reach other files of host
+func confine(dir, path string) (string, error) {
+	if filepath.IsAbs(path) || slices.Contains(strings.Split(filepath.ToSlash(path), "/"), "..") {
+		return "", fmt.Errorf("path %s must be relative and stay inside of directory", path)
+	}
+
+	return filepath.Join(dir, path), nil
+}
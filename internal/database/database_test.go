@@ -1,12 +1,25 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"concurrency_go_course/internal/backup"
 	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/slowlog"
 	"concurrency_go_course/internal/storage"
 	"concurrency_go_course/internal/storage/mock"
+	"concurrency_go_course/internal/storage/wal"
 	"concurrency_go_course/pkg/logger"
 
 	"github.com/golang/mock/gomock"
@@ -23,7 +36,7 @@ func TestServiceHandleNeg(t *testing.T) {
 
 	mockEngine := mock.NewMockEngine(ctrl)
 
-	storage, err := storage.New(mockEngine, nil, "", nil)
+	stor, err := storage.New(mockEngine, nil, "slave", nil, nil)
 	if err != nil {
 		t.Errorf("unable to create storage")
 	}
@@ -31,7 +44,7 @@ func TestServiceHandleNeg(t *testing.T) {
 	parser := compute.NewRequestParser()
 	compute := compute.NewCompute(parser)
 
-	service := NewDatabase(storage, compute)
+	service := NewDatabase(stor, compute, nil)
 
 	tests := map[string]struct {
 		in   string
@@ -49,9 +62,33 @@ func TestServiceHandleNeg(t *testing.T) {
 			in:  "GET unknown",
 			res: "",
 			exec: func() {
-				mockEngine.EXPECT().Get("unknown").Return("", false)
+				mockEngine.EXPECT().GetString("unknown").Return("", false, nil)
 			},
-			err: fmt.Errorf("value not found"),
+			err: ErrNotFound,
+		},
+		"LPUSH: on slave": {
+			in:   "LPUSH list a",
+			res:  "",
+			exec: func() {},
+			err:  &storage.SlaveWriteError{Command: "lpush"},
+		},
+		"SUBSCRIBE: without client session": {
+			in:   "SUBSCRIBE news",
+			res:  "",
+			exec: func() {},
+			err:  fmt.Errorf("pub/sub is disabled"),
+		},
+		"INCR: on slave": {
+			in:   "INCR key1",
+			res:  "",
+			exec: func() {},
+			err:  &storage.SlaveWriteError{Command: "incr"},
+		},
+		"BACKUP: without WAL": {
+			in:   "BACKUP backup.tar",
+			res:  "",
+			exec: func() {},
+			err:  ErrBackupDisabled,
 		},
 	}
 
@@ -76,7 +113,7 @@ func TestServiceHandlePos(t *testing.T) {
 
 	mockEngine := mock.NewMockEngine(ctrl)
 
-	storage, err := storage.New(mockEngine, nil, "master", nil)
+	stor, err := storage.New(mockEngine, nil, "master", nil, nil)
 	if err != nil {
 		t.Errorf("unable to create storage")
 	}
@@ -84,7 +121,7 @@ func TestServiceHandlePos(t *testing.T) {
 	parser := compute.NewRequestParser()
 	compute := compute.NewCompute(parser)
 
-	service := NewDatabase(storage, compute)
+	service := NewDatabase(stor, compute, nil)
 
 	tests := map[string]struct {
 		in   string
@@ -97,14 +134,14 @@ func TestServiceHandlePos(t *testing.T) {
 			res: "value1",
 			err: nil,
 			exec: func() {
-				mockEngine.EXPECT().Get("key1").Return("value1", true)
+				mockEngine.EXPECT().GetString("key1").Return("value1", true, nil)
 			},
 		},
 		"SET: correct result": {
 			in:  "SET key1 value1",
 			res: "OK",
 			exec: func() {
-				mockEngine.EXPECT().Set("key1", "value1").Return()
+				mockEngine.EXPECT().Set("key1", "value1", gomock.Any()).Return(nil)
 			},
 			err: nil,
 		},
@@ -112,7 +149,100 @@ func TestServiceHandlePos(t *testing.T) {
 			in:  "DEL key1",
 			res: "OK",
 			exec: func() {
-				mockEngine.EXPECT().Delete("key1").Return()
+				mockEngine.EXPECT().Delete("key1", gomock.Any()).Return(nil)
+			},
+			err: nil,
+		},
+		"INCR: existing value": {
+			in:  "INCR counter",
+			res: "42",
+			exec: func() {
+				mockEngine.EXPECT().Update("counter", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("41", true)
+					})
+			},
+			err: nil,
+		},
+		"INCRBY: missing value": {
+			in:  "INCRBY counter -3",
+			res: "-3",
+			exec: func() {
+				mockEngine.EXPECT().Update("counter", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("", false)
+					})
+			},
+			err: nil,
+		},
+		"APPEND: existing value": {
+			in:  "APPEND key1 def",
+			res: "6",
+			exec: func() {
+				mockEngine.EXPECT().Update("key1", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("abc", true)
+					})
+			},
+			err: nil,
+		},
+		"GETSET: existing value": {
+			in:  "GETSET key1 value2",
+			res: "value1",
+			exec: func() {
+				mockEngine.EXPECT().Update("key1", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("value1", true)
+					})
+			},
+			err: nil,
+		},
+		"INCR: not integer value": {
+			in:  "INCR key1",
+			res: "",
+			exec: func() {
+				mockEngine.EXPECT().Update("key1", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("abc", true)
+					})
+			},
+			err: fmt.Errorf("value is not an integer or out of range"),
+		},
+		"INCRBY: overflow": {
+			in:  "INCRBY key1 1",
+			res: "",
+			exec: func() {
+				mockEngine.EXPECT().Update("key1", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("9223372036854775807", true)
+					})
+			},
+			err: fmt.Errorf("increment or decrement would overflow"),
+		},
+		"HGETALL: sorted fields": {
+			in:  "HGETALL hash",
+			res: "a 1 b 2",
+			exec: func() {
+				mockEngine.EXPECT().HGetAll("hash").Return(map[string]string{"b": "2", "a": "1"}, nil)
+			},
+			err: nil,
+		},
+		"SMEMBERS: empty set": {
+			in:  "SMEMBERS set",
+			res: "(empty)",
+			exec: func() {
+				mockEngine.EXPECT().SMembers("set").Return([]string{}, nil)
+			},
+			err: nil,
+		},
+		"GETSET: missing value": {
+			in:  "GETSET key2 value2",
+			res: "(nil)",
+			exec: func() {
+				mockEngine.EXPECT().Update("key2", gomock.Any()).DoAndReturn(
+					func(_ string, update func(string, bool) (string, error)) (string, error) {
+						return update("", false)
+					})
 			},
 			err: nil,
 		},
@@ -128,3 +258,375 @@ func TestServiceHandlePos(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceHandleDeadline(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	walObj, err := wal.New(&config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        t.TempDir(),
+		FlushingBatchSize:    100,
+		FlushingBatchTimeout: "50ms",
+	}})
+	assert.NoError(t, err)
+
+	walCtx, walCancel := context.WithCancel(context.Background())
+	walObj.Start(walCtx)
+	defer func() {
+		walCancel()
+		<-walObj.Done()
+	}()
+
+	stor, err := storage.New(storage.NewEngine(4), walObj, "master", nil, nil)
+	assert.NoError(t, err)
+
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	_, err = service.HandleContext(expired, "SET key1 value1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// deadline is exceeded while write waits for group commit,
+	// so it is dropped and isn't applied
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = service.HandleContext(ctx, "SET key1 value1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	_, err = service.Handle("GET key1")
+	assert.Equal(t, ErrNotFound, err)
+
+	res, err := service.HandleContext(context.Background(), "SET key1 value1")
+	assert.NoError(t, err)
+	assert.Equal(t, resultOK, res)
+}
+
+func TestServiceExportImport(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, nil)
+	assert.NoError(t, err)
+
+	hash := sha256.Sum256([]byte("secret"))
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil,
+		WithUsers(map[string]string{"alice": hex.EncodeToString(hash[:])}),
+		WithExportDirectory(t.TempDir()))
+
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	ctx := network.WithSession(context.Background(), network.NewSession(conn))
+
+	for _, request := range []string{"SET app:1 one", "SADD app:set a b", "SET other:1 two", "SELECT 1", "SET app:2 db1", "SELECT 0"} {
+		_, err := service.HandleContext(ctx, request)
+		assert.NoError(t, err)
+	}
+
+	_, err = service.HandleContext(ctx, "EXPORT keys.csv csv app:")
+	assert.ErrorIs(t, err, ErrAuthRequired)
+
+	_, err = service.HandleContext(ctx, "AUTH alice secret")
+	assert.NoError(t, err)
+
+	// only keys of selected database are exported
+	res, err := service.HandleContext(ctx, "EXPORT keys.csv csv app:")
+	assert.NoError(t, err)
+	assert.Equal(t, "keys:2", res)
+
+	_, err = service.HandleContext(ctx, "EXPORT keys.csv csv")
+	assert.Error(t, err, "existing file isn't overwritten")
+
+	for _, path := range []string{"/tmp/keys.csv", "../keys.csv", "dir/../../keys.csv"} {
+		_, err = service.HandleContext(ctx, "EXPORT "+path+" csv")
+		assert.ErrorContains(t, err, "must be relative and stay inside of directory")
+		_, err = service.HandleContext(ctx, "IMPORT "+path+" csv")
+		assert.ErrorContains(t, err, "must be relative and stay inside of directory")
+	}
+
+	_, err = service.HandleContext(ctx, "DEL app:1")
+	assert.NoError(t, err)
+
+	res, err = service.HandleContext(ctx, "IMPORT keys.csv csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "keys:2", res)
+
+	res, err = service.HandleContext(ctx, "GET app:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", res)
+
+	_, err = service.HandleContext(ctx, "IMPORT keys.csv xml")
+	assert.ErrorContains(t, err, "unknown format")
+
+	// commands are disabled without export directory
+	service = NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil)
+	_, err = service.Handle("EXPORT keys.csv csv")
+	assert.ErrorIs(t, err, ErrExportDisabled)
+}
+
+func TestServiceBackup(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	walObj, err := wal.New(&config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        t.TempDir(),
+		FlushingBatchTimeout: "1ms",
+	}})
+	assert.NoError(t, err)
+
+	walCtx, walCancel := context.WithCancel(context.Background())
+	walObj.Start(walCtx)
+	defer func() {
+		walCancel()
+		<-walObj.Done()
+	}()
+
+	stor, err := storage.New(storage.NewEngine(4), walObj, "master", nil, nil)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	hash := sha256.Sum256([]byte("secret"))
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil,
+		WithUsers(map[string]string{"alice": hex.EncodeToString(hash[:])}),
+		WithBackuper(backup.New(walObj), dir))
+
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	ctx := network.WithSession(context.Background(), network.NewSession(conn))
+
+	_, err = service.HandleContext(ctx, "SET key value")
+	assert.NoError(t, err)
+
+	_, err = service.HandleContext(ctx, "BACKUP db.tar")
+	assert.ErrorIs(t, err, ErrAuthRequired)
+
+	_, err = service.HandleContext(ctx, "AUTH alice secret")
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/tmp/db.tar", "../db.tar", "dir/../../db.tar"} {
+		_, err = service.HandleContext(ctx, "BACKUP "+path)
+		assert.ErrorContains(t, err, "must be relative and stay inside of directory")
+	}
+
+	res, err := service.HandleContext(ctx, "BACKUP db.tar")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "path:db.tar")
+	assert.FileExists(t, filepath.Join(dir, "db.tar"))
+}
+
+func TestServiceSelect(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, nil)
+	assert.NoError(t, err)
+
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil, WithDatabases(4))
+
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	ctx := network.WithSession(context.Background(), network.NewSession(conn))
+
+	handle := func(request string) string {
+		res, err := service.HandleContext(ctx, request)
+		if err != nil {
+			return err.Error()
+		}
+		return res
+	}
+
+	tests := []struct {
+		request string
+		res     string
+	}{
+		{request: "SET key db0", res: "OK"},
+		{request: "SELECT 3", res: "OK"},
+		{request: "GET key", res: ErrNotFound.Error()},
+		{request: "SET key db3", res: "OK"},
+		{request: "SADD set a b", res: "2"},
+		{request: "GET key", res: "db3"},
+		{request: "SELECT 4", res: "invalid database 4, expected number from 0 to 3"},
+		{request: "SELECT -1", res: "for command SELECT expected non-negative database number, got -1"},
+		{request: "USE 0", res: "OK"},
+		{request: "GET key", res: "db0"},
+		{request: "SELECT 3", res: "OK"},
+		{request: "FLUSHDB", res: "OK"},
+		{request: "GET key", res: ErrNotFound.Error()},
+		{request: "SMEMBERS set", res: "(empty)"},
+		{request: "SELECT 0", res: "OK"},
+		{request: "GET key", res: "db0"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.res, handle(test.request), test.request)
+	}
+
+	_, err = service.Handle("SELECT 1")
+	assert.EqualError(t, err, "unable to select database: no client session")
+}
+
+func TestServiceRateLimits(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, nil)
+	assert.NoError(t, err)
+
+	hash := sha256.Sum256([]byte("secret"))
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil,
+		WithRateLimits(
+			limits.NewLimiter("client", limits.Rate{OpsPerSecond: 5}),
+			limits.NewLimiter("user", limits.Rate{OpsPerSecond: 2}),
+		),
+		WithUsers(map[string]string{"alice": hex.EncodeToString(hash[:])}))
+
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	ctx := network.WithSession(context.Background(), network.NewSession(conn))
+
+	_, err = service.HandleContext(ctx, "AUTH alice wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	res, err := service.HandleContext(ctx, "AUTH alice secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	// user limit is checked after authentication
+	_, err = service.HandleContext(ctx, "SET key value")
+	assert.NoError(t, err)
+	_, err = service.HandleContext(ctx, "GET key")
+	assert.NoError(t, err)
+	_, err = service.HandleContext(ctx, "GET key")
+	assert.ErrorIs(t, err, limits.ErrThrottled)
+	assert.ErrorContains(t, err, "THROTTLED user alice exceeded 2 ops/s")
+
+	// client limit counts requests of all sessions of address
+	other, _ := net.Pipe()
+	defer other.Close()
+	_, err = service.HandleContext(network.WithSession(context.Background(), network.NewSession(other)), "GET key")
+	assert.ErrorContains(t, err, "THROTTLED client pipe exceeded 5 ops/s")
+
+	// requests without session aren't limited
+	res, err = service.Handle("GET key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", res)
+}
+
+func TestServiceSlowLog(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	walObj, err := wal.New(&config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        t.TempDir(),
+		FlushingBatchSize:    100,
+		FlushingBatchTimeout: "20ms",
+	}})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	walObj.Start(ctx)
+	defer func() {
+		cancel()
+		<-walObj.Done()
+	}()
+
+	stor, err := storage.New(storage.NewEngine(4), walObj, "master", nil, nil)
+	assert.NoError(t, err)
+
+	log := slowlog.New(10*time.Millisecond, 10)
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil,
+		WithSlowLog(log))
+
+	// GET isn't waiting for WAL batch, so it is faster than threshold
+	_, err = service.Handle("SET key value")
+	assert.NoError(t, err)
+	_, err = service.Handle("GET key")
+	assert.NoError(t, err)
+
+	res, err := service.Handle("SLOWLOG LEN")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", res)
+
+	entry := log.Get(1)[0]
+	assert.Equal(t, "SET", entry.Command)
+	assert.GreaterOrEqual(t, entry.Phases.WAL, 10*time.Millisecond)
+	assert.Less(t, entry.Phases.Apply, entry.Phases.WAL)
+	assert.Positive(t, entry.Phases.Parse)
+
+	res, err = service.Handle("SLOWLOG GET")
+	assert.NoError(t, err)
+	assert.Contains(t, res, "id:1 ")
+	assert.Contains(t, res, `client:(nil) query:"SET key value"`)
+
+	// trace of network request is recorded after response is written
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	trace := slowlog.NewTrace()
+	traceCtx := slowlog.WithTrace(network.WithSession(context.Background(), network.NewSession(conn)), trace)
+
+	_, err = service.HandleContext(traceCtx, "DEL key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, log.Len())
+
+	trace.Add(slowlog.PhaseWrite, time.Millisecond)
+	trace.Finish()
+	entry = log.Get(1)[0]
+	assert.Equal(t, "DEL", entry.Command)
+	assert.Equal(t, "pipe", entry.Client)
+	assert.Equal(t, time.Millisecond, entry.Phases.Write)
+
+	// credentials aren't kept in slow log
+	trace = slowlog.NewTrace()
+	traceCtx = slowlog.WithTrace(network.WithSession(context.Background(), network.NewSession(conn)), trace)
+
+	_, err = service.HandleContext(traceCtx, "AUTH alice secret")
+	assert.Error(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	trace.Finish()
+	entry = log.Get(1)[0]
+	assert.Equal(t, "AUTH", entry.Command)
+	assert.Equal(t, []string{"(redacted)", "(redacted)"}, entry.Args)
+
+	// response is one line, entries are separated
+	res, err = service.Handle("SLOWLOG GET")
+	assert.NoError(t, err)
+	assert.NotContains(t, res, "\n")
+	assert.Len(t, strings.Split(res, " | "), log.Len())
+
+	res, err = service.Handle("SLOWLOG RESET")
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	res, err = service.Handle("SLOWLOG GET 5")
+	assert.NoError(t, err)
+	assert.Equal(t, "(empty)", res)
+}
+
+func TestServiceWrongType(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, nil)
+	assert.NoError(t, err)
+
+	service := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil)
+
+	_, err = service.Handle("HSET hash field value")
+	assert.NoError(t, err)
+
+	// GET of hash isn't reported as missing key
+	_, err = service.Handle("GET hash")
+	assert.ErrorIs(t, err, storage.ErrWrongType)
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/keyspace"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+// ErrExportDisabled is returned by EXPORT and IMPORT if export directory
+// isn't configured
+var ErrExportDisabled = errors.New("export and import require export directory")
+
+// exportKeys writes keys of selected database with optional prefix to new
+// file of export directory. Arguments are path, format and prefix. Keys of
+// different partitions are read at different moments, BACKUP is used
+// for consistent copy
+func (s *database) exportKeys(ctx context.Context, args []string) (string, error) {
+	path, prefix := args[0], optionalArg(args, 2)
+
+	format, err := keyspace.ParseFormat(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	filename, err := s.exportPath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("unable to create export file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	exported, err := keyspace.Export(s.selected(ctx).Dump(), file, format, prefix)
+	if err != nil {
+		return "", fmt.Errorf("unable to export keys: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return "", fmt.Errorf("unable to export keys: %w", err)
+	}
+
+	logger.Info("Keys were exported", zap.String("path", path), zap.Int("keys", exported))
+
+	return fmt.Sprintf("keys:%d", exported), nil
+}
+
+// importKeys reads keys with optional prefix from file of export directory
+// and writes them to selected database through WAL by batches. Arguments
+// are path, format and prefix. Imported keys replace existing ones
+func (s *database) importKeys(ctx context.Context, args []string) (string, error) {
+	path, prefix := args[0], optionalArg(args, 2)
+
+	format, err := keyspace.ParseFormat(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	filename, err := s.exportPath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("unable to open import file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	db := selectedDB(ctx)
+	imported, err := keyspace.Import(file, format, prefix, func(requests []wal.Request) error {
+		return s.storage.Import(keyspace.ToDatabase(requests, db))
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w, %d keys were imported", err, imported)
+	}
+
+	logger.Info("Keys were imported", zap.String("path", path), zap.Int("keys", imported))
+
+	return fmt.Sprintf("keys:%d", imported), nil
+}
+
+// exportPath returns file of export directory by path of EXPORT or IMPORT.
+// Files are read and written by authenticated users only
+func (s *database) exportPath(ctx context.Context, path string) (string, error) {
+	if s.exportDir == "" {
+		return "", ErrExportDisabled
+	}
+
+	if err := authenticated(ctx); err != nil {
+		return "", err
+	}
+
+	return confine(s.exportDir, path)
+}
+
+func optionalArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return ""
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"net"
+
+	"concurrency_go_course/internal/network"
+)
+
+type clientKey struct{}
+
+// Labels of throttled requests metric
+const (
+	limitClient = "client"
+	limitUser   = "user"
+)
+
+// WithClient returns context with address of client. Servers without
+// client sessions, like HTTP gateway and gRPC server, set it, so their
+// requests are rate limited by client address as well
+func WithClient(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, clientKey{}, address)
+}
+
+// throttle takes request of size bytes from rate limits of client
+// address and authenticated user. Requests without client session
+// or address aren't limited
+func (s *database) throttle(ctx context.Context, size int) error {
+	address, user, ok := clientOf(ctx)
+	if !ok {
+		return nil
+	}
+
+	if err := s.clientLimiter.Allow(clientIP(address), size); err != nil {
+		throttledRequests.With(limitClient).Inc()
+		return err
+	}
+
+	if user != "" {
+		if err := s.userLimiter.Allow(user, size); err != nil {
+			throttledRequests.With(limitUser).Inc()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clientOf returns address and authenticated user of client of request.
+// Client is set by session of TCP connection or by WithClient
+func clientOf(ctx context.Context) (string, string, bool) {
+	if session, ok := network.SessionFromContext(ctx); ok {
+		var address string
+		if addr := session.RemoteAddr(); addr != nil {
+			address = addr.String()
+		}
+
+		return address, session.User(), true
+	}
+
+	address, ok := ctx.Value(clientKey{}).(string)
+
+	return address, "", ok
+}
+
+// clientIP returns IP of client address without port
+func clientIP(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	return host
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"concurrency_go_course/pkg/metrics"
+)
+
+// commandUnknown is a command label of requests which were not parsed
+const commandUnknown = "unknown"
+
+// Metrics of database commands. Names are part of monitoring API
+// and must not be changed:
+//
+//	db_commands_total{command}            - number of handled commands
+//	db_command_errors_total{command}      - number of commands finished with error
+//	db_command_duration_seconds{command}  - histogram of command handling latency
+//	db_throttled_requests_total{limit}    - number of requests rejected by client or user rate limit
+var (
+	commandsTotal = metrics.NewCounterVec("db_commands_total",
+		"Number of handled commands.", "command")
+	commandErrors = metrics.NewCounterVec("db_command_errors_total",
+		"Number of commands finished with error.", "command")
+	commandDuration = metrics.NewHistogramVec("db_command_duration_seconds",
+		"Command handling latency in seconds.", "command", metrics.DefaultBuckets)
+	throttledRequests = metrics.NewCounterVec("db_throttled_requests_total",
+		"Number of requests rejected by client or user rate limit.", "limit")
+)
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/pubsub"
+	"concurrency_go_course/pkg/logger"
+)
+
+// pushTimeout limits pushing of message to subscriber
+const pushTimeout = 5 * time.Second
+
+// subscribe subscribes client session on channels. Messages are pushed
+// to the session until its connection is closed. Subscriber which doesn't
+// read message within push timeout is dropped and its connection is closed
+func (s *database) subscribe(ctx context.Context, channels []string) (string, error) {
+	if s.broker == nil {
+		return "", fmt.Errorf("pub/sub is disabled")
+	}
+
+	session, ok := network.SessionFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("unable to subscribe: no client session")
+	}
+
+	sub := s.broker.Subscribe(channels...)
+	session.SetStreaming(true)
+
+	go func() {
+		defer sub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-sub.Messages():
+				if err := session.PushTimeout(formatMessage(msg), pushTimeout); err != nil {
+					logger.ErrorWithMsg("unable to push message, subscriber is dropped:", err,
+						zap.String("channel", msg.Channel))
+					_ = session.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	logger.Debug("Client was subscribed", zap.Strings("channels", channels))
+
+	return resultOK, nil
+}
+
+func (s *database) publish(channel, payload string) (string, error) {
+	if s.broker == nil {
+		return "", fmt.Errorf("pub/sub is disabled")
+	}
+
+	return strconv.Itoa(s.broker.Publish(channel, payload)), nil
+}
+
+// formatMessage formats pushed message. Messages are separated by new lines,
+// because several of them can be read by client at once
+func formatMessage(msg pubsub.Message) []byte {
+	return []byte(fmt.Sprintf("MESSAGE %s %s\n", msg.Channel, msg.Payload))
+}
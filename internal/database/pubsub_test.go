@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/pubsub"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestKeyspaceNotifications(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := pubsub.NewBroker()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, broker)
+	if err != nil {
+		t.Errorf("unable to create storage")
+	}
+
+	db := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), broker)
+
+	sub := broker.Subscribe(pubsub.KeyspacePrefix + "key1")
+	defer sub.Close()
+
+	for _, request := range []string{"SET key1 1", "INCR key1", "DEL key1", "DEL key2"} {
+		_, err := db.Handle(request)
+		assert.Nil(t, err)
+	}
+
+	for _, event := range []string{"set", "incrby", "del"} {
+		msg := <-sub.Messages()
+		assert.Equal(t, event, msg.Payload)
+	}
+
+	res, err := db.Handle("PUBLISH " + pubsub.KeyspacePrefix + "key1 hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", res)
+}
+
+func TestSubscriberDropped(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := pubsub.NewBroker()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, "master", nil, broker)
+	assert.NoError(t, err)
+
+	db := NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), broker)
+
+	server, client := net.Pipe()
+	defer server.Close()
+	ctx := network.WithSession(context.Background(), network.NewSession(server))
+
+	res, err := db.HandleContext(ctx, "SUBSCRIBE news")
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", res)
+
+	// message can't be pushed to closed client, so subscriber is dropped
+	_ = client.Close()
+	assert.Equal(t, 1, broker.Publish("news", "hello"))
+	assert.Eventually(t, func() bool {
+		return broker.Publish("news", "hello") == 0
+	}, time.Second, 10*time.Millisecond)
+}
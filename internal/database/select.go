@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+)
+
+// selectDB selects logical database of client session. Database is
+// selected until connection is closed or another one is selected
+func (s *database) selectDB(ctx context.Context, arg string) (string, error) {
+	db, err := strconv.Atoi(arg)
+	if err != nil || db < 0 || db >= s.databases {
+		return "", fmt.Errorf("invalid database %s, expected number from 0 to %d", arg, s.databases-1)
+	}
+
+	session, ok := network.SessionFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("unable to select database: no client session")
+	}
+
+	session.SetDB(db)
+	logger.Debug("Database was selected", zap.Int("db", db))
+
+	return resultOK, nil
+}
+
+// selected returns storage of logical database selected by client
+// session. Requests without session use database 0
+func (s *database) selected(ctx context.Context) storage.Storage {
+	if session, ok := network.SessionFromContext(ctx); ok && session.DB() != 0 {
+		return s.storage.Select(session.DB())
+	}
+
+	return s.storage
+}
+
+// selectedDB returns number of logical database selected by client session
+func selectedDB(ctx context.Context) int {
+	if session, ok := network.SessionFromContext(ctx); ok {
+		return session.DB()
+	}
+
+	return 0
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/slowlog"
+	"concurrency_go_course/internal/storage"
+)
+
+// defaultSlowLogCount is a number of entries returned by SLOWLOG GET
+const defaultSlowLogCount = 10
+
+// slowLogSeparator separates entries of SLOWLOG GET response
+const slowLogSeparator = " | "
+
+// redacted replaces arguments of commands with credentials in slow log
+const redacted = "(redacted)"
+
+// credentialCommands are commands whose arguments aren't kept in slow log
+var credentialCommands = map[string]struct{}{
+	compute.CommandAuth: {},
+}
+
+// trace returns context with trace of request and func finishing it.
+// Trace of network server request is finished by server after response
+// is written, other requests get own trace. Trace is nil if slow log
+// is disabled
+func (s *database) trace(ctx context.Context) (context.Context, *slowlog.Trace, func()) {
+	if s.slowLog == nil {
+		return ctx, nil, func() {}
+	}
+
+	if trace, ok := slowlog.FromContext(ctx); ok {
+		return ctx, trace, func() {}
+	}
+
+	trace := slowlog.NewTrace()
+	return slowlog.WithTrace(ctx, trace), trace, trace.Finish
+}
+
+// record sets request of trace to be added to slow log. Blocking
+// commands are waiting for events, so they aren't recorded. Arguments
+// of commands with credentials are redacted
+func (s *database) record(ctx context.Context, trace *slowlog.Trace, query compute.Query) {
+	switch query.Command {
+	case compute.CommandWatch, compute.CommandSubscribe:
+		return
+	}
+
+	args := query.Args
+	if _, ok := credentialCommands[query.Command]; ok {
+		args = make([]string, len(query.Args))
+		for i := range args {
+			args[i] = redacted
+		}
+	}
+
+	client, _, _ := clientOf(ctx)
+
+	trace.Record(s.slowLog, client, query.Command, args)
+}
+
+// traced returns storage adding WAL waiting to trace of request
+func (s *database) traced(ctx context.Context, stor storage.Storage) storage.Storage {
+	if s.slowLog == nil {
+		return stor
+	}
+
+	if trace, ok := slowlog.FromContext(ctx); ok {
+		return stor.Trace(trace)
+	}
+
+	return stor
+}
+
+// slowLogCommand handles SLOWLOG subcommands. GET returns entries from
+// the newest one, every entry is space separated name:value pairs.
+// Entries are separated by " | ", because response is a line
+func (s *database) slowLogCommand(args []string) (string, error) {
+	switch args[0] {
+	case compute.SlowLogReset:
+		s.slowLog.Reset()
+		return resultOK, nil
+	case compute.SlowLogLen:
+		return strconv.Itoa(s.slowLog.Len()), nil
+	}
+
+	count := defaultSlowLogCount
+	if len(args) == 2 {
+		count, _ = strconv.Atoi(args[1])
+	}
+
+	entries := s.slowLog.Get(count)
+	if len(entries) == 0 {
+		return resultEmpty, nil
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		client := entry.Client
+		if client == "" {
+			client = resultNil
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"id:%d time:%s duration:%s parse:%s wal:%s apply:%s write:%s client:%s query:%q",
+			entry.ID, entry.Time.UTC().Format(time.RFC3339Nano), entry.Duration,
+			entry.Phases.Parse, entry.Phases.WAL, entry.Phases.Apply, entry.Phases.Write,
+			client, strings.Join(append([]string{entry.Command}, entry.Args...), " ")))
+	}
+
+	return strings.Join(lines, slowLogSeparator), nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+)
+
+// watch blocks client until key of storage is changed or deleted
+// and returns new value. Watch is cancelled if client disconnects
+func (s *database) watch(ctx context.Context, stor storage.Storage, args []string) (string, error) {
+	key := args[0]
+
+	if len(args) == 2 {
+		timeout, err := time.ParseDuration(args[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout %s", args[1])
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	v, ok, err := stor.Watch(ctx, key)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("watch timeout exceeded")
+		}
+
+		return "", err
+	}
+
+	logger.Debug("Watched key was changed", zap.String("key", key))
+
+	if !ok {
+		return resultNil, nil
+	}
+
+	return v, nil
+}
+
+// WatchKey sends value of key to send on every change until context is
+// done or send fails. One subscription is kept for the whole stream, so
+// changes made while value is sent aren't missed, send gets the latest one.
+// Only string values are watched, watching stops with wrong type error
+// if key holds value of another type
+func (s *database) WatchKey(ctx context.Context, key string, send func(value string, found bool) error) error {
+	if err := s.throttle(ctx, len(key)); err != nil {
+		return err
+	}
+
+	stor := s.selected(ctx)
+
+	changes, unsubscribe := stor.Subscribe(key)
+	defer unsubscribe()
+
+	if _, _, err := stor.GetString(key); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changes:
+		}
+
+		value, found, err := stor.GetString(key)
+		if err != nil {
+			return err
+		}
+		if err := send(value, found); err != nil {
+			return err
+		}
+	}
+}
@@ -12,6 +12,10 @@ import (
 type FileLib interface {
 	CreateFile(filename string) (*os.File, error)
 	WriteFile(file *os.File, data []byte) (int, error)
+	WriteFileAt(file *os.File, data []byte, offset int64) (int, error)
+	SyncFile(file *os.File) error
+	DatasyncFile(file *os.File) error
+	PreallocateFile(file *os.File, size int64) error
 	DataFromFiles(dir string, filenames []string) ([][]byte, error)
 	FilenamesFromDir(dir string) ([]string, error)
 	SegmentNext(dir, filename string) (string, error)
@@ -37,20 +41,38 @@ func (f *filelib) CreateFile(filename string) (*os.File, error) {
 	return file, err
 }
 
-// WriteFile writes data to file by file descriptor
+// WriteFile writes data to file by file descriptor.
+// Data isn't synced, it is left in OS page cache until SyncFile
 func (f *filelib) WriteFile(file *os.File, data []byte) (int, error) {
 	writtenBytes, err := file.Write(data)
 	if err != nil {
 		return 0, err
 	}
 
-	if err = file.Sync(); err != nil {
-		return 0, err
-	}
-
 	return writtenBytes, nil
 }
 
+// WriteFileAt writes data to file at offset
+func (f *filelib) WriteFileAt(file *os.File, data []byte, offset int64) (int, error) {
+	return file.WriteAt(data, offset)
+}
+
+// SyncFile commits written data of file to stable storage
+func (f *filelib) SyncFile(file *os.File) error {
+	return file.Sync()
+}
+
+// DatasyncFile commits written data of file to stable storage
+// without metadata which isn't needed to read the data
+func (f *filelib) DatasyncFile(file *os.File) error {
+	return datasync(file)
+}
+
+// PreallocateFile reserves disk space of file up to size
+func (f *filelib) PreallocateFile(file *os.File, size int64) error {
+	return allocate(file, size)
+}
+
 // DataFromFiles returns data from files
 func (f *filelib) DataFromFiles(dir string, filenames []string) ([][]byte, error) {
 	dataRes := make([][]byte, 0, len(filenames))
@@ -0,0 +1,20 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// allocate reserves disk space of file up to size without changing
+// data, so appends don't update file size metadata
+func allocate(file *os.File, size int64) error {
+	return syscall.Fallocate(int(file.Fd()), 0, 0, size)
+}
+
+// datasync commits data of file without metadata which isn't
+// needed to read it, like modification time
+func datasync(file *os.File) error {
+	return syscall.Fdatasync(int(file.Fd()))
+}
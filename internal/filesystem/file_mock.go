@@ -11,6 +11,10 @@ import (
 type MockFileLib interface {
 	CreateFile(filename string) (*os.File, error)
 	WriteFile(file *os.File, data []byte) (int, error)
+	WriteFileAt(file *os.File, data []byte, offset int64) (int, error)
+	SyncFile(file *os.File) error
+	DatasyncFile(file *os.File) error
+	PreallocateFile(file *os.File, size int64) error
 	DataFromFiles(dir string, filenames []string) ([][]byte, error)
 	FilenamesFromDir(dir string) ([]string, error)
 	SegmentNext(dir, filename string) (string, error)
@@ -43,13 +47,30 @@ func (f *mockfilelib) WriteFile(file *os.File, data []byte) (int, error) {
 		return 0, err
 	}
 
-	if err = file.Sync(); err != nil {
-		return 0, err
-	}
-
 	return writtenBytes, nil
 }
 
+// WriteFileAt writes data to file at offset
+func (f *mockfilelib) WriteFileAt(file *os.File, data []byte, offset int64) (int, error) {
+	return file.WriteAt(data, offset)
+}
+
+// SyncFile commits written data of file to stable storage
+func (f *mockfilelib) SyncFile(file *os.File) error {
+	return file.Sync()
+}
+
+// DatasyncFile commits written data of file to stable storage
+// without metadata which isn't needed to read the data
+func (f *mockfilelib) DatasyncFile(file *os.File) error {
+	return datasync(file)
+}
+
+// PreallocateFile reserves disk space of file up to size
+func (f *mockfilelib) PreallocateFile(file *os.File, size int64) error {
+	return allocate(file, size)
+}
+
 func (f *mockfilelib) DataFromFiles(dir string, filenames []string) ([][]byte, error) {
 	dataRes := make([][]byte, 0, len(filenames))
 
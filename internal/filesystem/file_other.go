@@ -0,0 +1,18 @@
+//go:build !linux
+
+package filesystem
+
+import (
+	"os"
+)
+
+// allocate extends file up to size, space is reserved
+// lazily on platforms without fallocate
+func allocate(file *os.File, size int64) error {
+	return file.Truncate(size)
+}
+
+// datasync falls back to full sync on platforms without fdatasync
+func datasync(file *os.File) error {
+	return file.Sync()
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Preallocated segment file starts with header recording end offset
+// of written data, the rest of file up to preallocated size is zeros:
+//
+//	magic      [4]byte  "WALP"
+//	version    uint32
+//	end offset uint64   offset from file start, header included
+const (
+	headerSize    = 16
+	headerVersion = 1
+)
+
+var headerMagic = []byte("WALP")
+
+func encodeHeader(end int64) []byte {
+	header := make([]byte, headerSize)
+	copy(header, headerMagic)
+	binary.LittleEndian.PutUint32(header[4:8], headerVersion)
+	binary.LittleEndian.PutUint64(header[8:16], uint64(end)) //nolint:gosec
+
+	return header
+}
+
+// SegmentPayload returns written data of segment file content.
+// Content of segment without header is returned as is
+func SegmentPayload(data []byte) []byte {
+	if len(data) < headerSize || !bytes.Equal(data[:4], headerMagic) {
+		return data
+	}
+
+	end := binary.LittleEndian.Uint64(data[8:16])
+	if end < headerSize || end > uint64(len(data)) {
+		// header is updated after data, so larger offset
+		// means data isn't complete
+		end = uint64(len(data))
+	}
+
+	return data[headerSize:end]
+}
+
+// TruncateSegment cuts written data of segment file to size bytes.
+// End offset of preallocated segment is moved back, so its
+// preallocated space is kept
+func TruncateSegment(filename string, size int) error {
+	file, err := os.OpenFile(filepath.Clean(filename), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	header := make([]byte, headerSize)
+	n, err := file.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if n == headerSize && bytes.Equal(header[:4], headerMagic) {
+		_, err = file.WriteAt(encodeHeader(int64(headerSize+size)), 0)
+	} else {
+		err = file.Truncate(int64(size))
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
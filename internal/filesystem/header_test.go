@@ -0,0 +1,56 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentPayload(t *testing.T) {
+	t.Parallel()
+
+	withHeader := func(end int64, data string, size int) []byte {
+		content := make([]byte, size)
+		copy(content, encodeHeader(end))
+		copy(content[headerSize:], data)
+		return content
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		payload []byte
+	}{
+		{
+			name:    "Segment without header",
+			data:    []byte("SET k v"),
+			payload: []byte("SET k v"),
+		},
+		{
+			name:    "Empty segment",
+			data:    []byte{},
+			payload: []byte{},
+		},
+		{
+			name:    "Preallocated segment",
+			data:    withHeader(headerSize+7, "SET k v", 64),
+			payload: []byte("SET k v"),
+		},
+		{
+			name:    "Preallocated segment without data",
+			data:    withHeader(headerSize, "", 64),
+			payload: []byte{},
+		},
+		{
+			name:    "End offset beyond file",
+			data:    withHeader(128, "SET k v", headerSize+7),
+			payload: []byte("SET k v"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.payload, SegmentPayload(tt.data))
+		})
+	}
+}
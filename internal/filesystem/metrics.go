@@ -0,0 +1,20 @@
+package filesystem
+
+import (
+	"concurrency_go_course/pkg/metrics"
+)
+
+// Metrics of WAL segment files. Segment counts are reported by segments
+// of live WAL only. Names are part of monitoring API and must not be changed:
+//
+//	wal_segments_count          - number of segment files in data directory
+//	wal_segments_bytes          - total size of segment files in bytes
+//	wal_fsync_duration_seconds  - histogram of segment file fsync latency
+var (
+	segmentsCount = metrics.NewGauge("wal_segments_count",
+		"Number of WAL segment files.")
+	segmentsBytes = metrics.NewGauge("wal_segments_bytes",
+		"Total size of WAL segment files in bytes.")
+	syncDuration = metrics.NewHistogram("wal_fsync_duration_seconds",
+		"Segment file fsync latency in seconds.", metrics.DefaultBuckets)
+)
@@ -3,35 +3,89 @@ package filesystem
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 )
 
 // Segment is interface for segment
 type Segment interface {
 	Write(data []byte) error
+	Sync() error
 	ReadAll() ([][]byte, error)
+	ReadSegments() ([]SegmentData, error)
+	Archive(name string, offset int) (string, error)
+	Rotate() (string, error)
+}
+
+// SegmentData is written data of segment file
+type SegmentData struct {
+	Name string
+	Data []byte
 }
 
 type segment struct {
 	file      *os.File
 	directory string
+	// lastName is a name of the last created segment file
+	lastName string
 
 	segmentSize    int
 	maxSegmentSize int
 
+	// dirty is set if current file has data which isn't synced yet
+	dirty bool
+
+	preallocate bool
+	datasync    bool
+	metrics     bool
+
 	fileLib FileLib
 }
 
+// SegmentOption is a func configuring segment
+type SegmentOption func(*segment)
+
+// WithPreallocation makes segment files preallocated up to max segment
+// size. Data is written at end offset recorded in file header, so file
+// size isn't changed by writes and sync doesn't update its metadata
+func WithPreallocation() SegmentOption {
+	return func(s *segment) {
+		s.preallocate = true
+	}
+}
+
+// WithDatasync makes segment synced by fdatasync instead of fsync
+func WithDatasync() SegmentOption {
+	return func(s *segment) {
+		s.datasync = true
+	}
+}
+
+// WithMetrics makes segment report its files by WAL segment metrics.
+// Only segment of live WAL sets it, so segments written by tools
+// and backups aren't counted
+func WithMetrics() SegmentOption {
+	return func(s *segment) {
+		s.metrics = true
+	}
+}
+
 // NewSegment returns new segment
-func NewSegment(directory string, maxSegmentSize int, fileLib FileLib) Segment {
-	return &segment{
+func NewSegment(directory string, maxSegmentSize int, fileLib FileLib, opts ...SegmentOption) Segment {
+	s := &segment{
 		directory:      directory,
 		maxSegmentSize: maxSegmentSize,
 		fileLib:        fileLib,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// Write writes bytes of segment
+// Write writes bytes of segment. Data isn't synced to stable storage
+// until Sync is called or segment is rotated
 func (s *segment) Write(data []byte) error {
 	if s.file == nil || s.segmentSize >= s.maxSegmentSize {
 		if err := s.createSegment(); err != nil {
@@ -39,18 +93,80 @@ func (s *segment) Write(data []byte) error {
 		}
 	}
 
-	writtenBytes, err := s.fileLib.WriteFile(s.file, data)
+	writtenBytes, err := s.write(data)
 	if err != nil {
 		return fmt.Errorf("failed to write data to segment file: %w", err)
 	}
 
 	s.segmentSize += writtenBytes
+	s.dirty = true
+	if s.metrics {
+		segmentsBytes.Add(float64(writtenBytes))
+	}
+	return nil
+}
+
+// Sync commits written data of current segment file to stable storage
+func (s *segment) Sync() error {
+	if s.file == nil || !s.dirty {
+		return nil
+	}
+
+	sync := s.fileLib.SyncFile
+	if s.datasync {
+		sync = s.fileLib.DatasyncFile
+	}
+
+	start := time.Now()
+	if err := sync(s.file); err != nil {
+		return fmt.Errorf("failed to sync segment file: %w", err)
+	}
+	syncDuration.ObserveDuration(start)
+
+	s.dirty = false
 	return nil
 }
 
+// Rotate closes current segment file, so the next write creates new one.
+// It returns name of the last segment file in directory, all data written
+// before rotation is in it or in earlier segments
+func (s *segment) Rotate() (string, error) {
+	if s.file == nil {
+		filenames, err := s.fileLib.FilenamesFromDir(s.directory)
+		if err != nil || len(filenames) == 0 {
+			return "", err
+		}
+		return filenames[len(filenames)-1], nil
+	}
+
+	if err := s.Sync(); err != nil {
+		return "", err
+	}
+	if err := s.file.Close(); err != nil {
+		return "", err
+	}
+	s.file = nil
+
+	return s.lastName, nil
+}
+
 func (s *segment) createSegment() error {
-	segmentName := fmt.Sprintf("%s/wal_%d.log", s.directory, time.Now().UnixMilli())
+	// segment created in the same millisecond as previous one
+	// would overwrite it
+	ms := time.Now().UnixMilli()
+	if last := SegmentTime(s.lastName); ms <= last {
+		ms = last + 1
+	}
+	name := SegmentName(ms)
+	segmentName := filepath.Join(s.directory, name)
+
 	if s.file != nil {
+		// closed segment is never written again, so it is synced
+		// to not lose its tail regardless of sync mode
+		if err := s.Sync(); err != nil {
+			return err
+		}
+
 		err := s.file.Close()
 		if err != nil {
 			return err
@@ -63,16 +179,168 @@ func (s *segment) createSegment() error {
 	}
 
 	s.file = file
+	s.lastName = name
 	s.segmentSize = 0
-	return nil
+	if s.metrics {
+		segmentsCount.Inc()
+	}
+
+	if !s.preallocate {
+		return nil
+	}
+
+	if err := s.fileLib.PreallocateFile(file, int64(headerSize+s.maxSegmentSize)); err != nil {
+		return fmt.Errorf("failed to preallocate segment file: %w", err)
+	}
+
+	_, err = s.fileLib.WriteFileAt(file, encodeHeader(headerSize), 0)
+	return err
 }
 
-// ReadAll reads all data from dir
+// write appends data to segment file. Data of preallocated segment
+// is written before header, so header never points to incomplete data
+// if process crashes. On machine crash data and header are persisted
+// in any order, so tail of the last segment can be zeroed if it wasn't
+// synced
+func (s *segment) write(data []byte) (int, error) {
+	if !s.preallocate {
+		return s.fileLib.WriteFile(s.file, data)
+	}
+
+	offset := int64(headerSize + s.segmentSize)
+	writtenBytes, err := s.fileLib.WriteFileAt(s.file, data, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.fileLib.WriteFileAt(s.file, encodeHeader(offset+int64(writtenBytes)), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return writtenBytes, nil
+}
+
+// ReadAll reads written data of all segments from dir
 func (s *segment) ReadAll() ([][]byte, error) {
+	segments, err := s.ReadSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([][]byte, 0, len(segments))
+	for _, segment := range segments {
+		data = append(data, segment.Data)
+	}
+
+	return data, nil
+}
+
+// ReadSegments reads written data of all segments from dir with their names
+func (s *segment) ReadSegments() ([]SegmentData, error) {
 	filenames, err := s.fileLib.FilenamesFromDir(s.directory)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.fileLib.DataFromFiles(s.directory, filenames)
+	data, err := s.fileLib.DataFromFiles(s.directory, filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]SegmentData, 0, len(data))
+	totalBytes := 0
+	for i, d := range data {
+		payload := SegmentPayload(d)
+		segments = append(segments, SegmentData{Name: filenames[i], Data: payload})
+		totalBytes += len(payload)
+	}
+	if s.metrics {
+		segmentsCount.Set(float64(len(filenames)))
+		segmentsBytes.Set(float64(totalBytes))
+	}
+
+	return segments, nil
+}
+
+// Archive moves data of segments starting from offset of named segment
+// to new archive subdirectory and returns its path. Named segment is
+// copied to archive and truncated to offset, later segments are moved.
+// Archived segments aren't read from dir anymore
+func (s *segment) Archive(name string, offset int) (string, error) {
+	archive := filepath.Join(s.directory, fmt.Sprintf("recovery_%d", time.Now().UnixMilli()))
+	if err := os.Mkdir(archive, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	filenames, err := s.fileLib.FilenamesFromDir(s.directory)
+	if err != nil {
+		return "", err
+	}
+
+	for _, filename := range filenames {
+		if filename < name {
+			continue
+		}
+
+		path := filepath.Join(s.directory, filename)
+		if filename != name {
+			if err := os.Rename(path, filepath.Join(archive, filename)); err != nil {
+				return "", fmt.Errorf("failed to archive segment: %w", err)
+			}
+			continue
+		}
+
+		if err := s.copyFile(path, filepath.Join(archive, filename)); err != nil {
+			return "", fmt.Errorf("failed to archive segment: %w", err)
+		}
+		if err := TruncateSegment(path, offset); err != nil {
+			return "", fmt.Errorf("failed to truncate segment: %w", err)
+		}
+	}
+
+	// metrics are set by segments left in dir
+	if s.metrics {
+		if _, err := s.ReadSegments(); err != nil {
+			return "", err
+		}
+	}
+
+	return archive, nil
+}
+
+// SegmentName returns name of segment created at unix milliseconds
+func SegmentName(ms int64) string {
+	return fmt.Sprintf("wal_%d.log", ms)
+}
+
+// SegmentTime returns creation time in unix milliseconds of segment
+// named wal_<unix ms>.log, it returns zero for other names
+func SegmentTime(name string) int64 {
+	var ms int64
+	if _, err := fmt.Sscanf(name, "wal_%d.log", &ms); err != nil {
+		return 0
+	}
+
+	return ms
+}
+
+// copyFile copies file and syncs the copy, so source can be changed
+func (s *segment) copyFile(src, dst string) error {
+	data, err := os.ReadFile(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+
+	file, err := s.fileLib.CreateFile(dst)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	if _, err := s.fileLib.WriteFile(file, data); err != nil {
+		return err
+	}
+
+	return s.fileLib.SyncFile(file)
 }
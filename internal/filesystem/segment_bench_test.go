@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"testing"
+)
+
+// BenchmarkSegmentWrite measures latency of synced append to segment
+// by plain file lib writes compared with preallocated segment
+func BenchmarkSegmentWrite(b *testing.B) {
+	data := make([]byte, 256)
+
+	benchmarks := []struct {
+		name string
+		opts []SegmentOption
+	}{
+		{name: "filelib"},
+		{name: "datasync", opts: []SegmentOption{WithDatasync()}},
+		{name: "preallocated", opts: []SegmentOption{WithPreallocation()}},
+		{name: "preallocated+datasync", opts: []SegmentOption{WithPreallocation(), WithDatasync()}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			segment := NewSegment(b.TempDir(), 64<<20, NewFileLib(), bm.opts...)
+
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+
+			for range b.N {
+				if err := segment.Write(data); err != nil {
+					b.Fatalf("unable to write: %s", err)
+				}
+				if err := segment.Sync(); err != nil {
+					b.Fatalf("unable to sync: %s", err)
+				}
+			}
+		})
+	}
+}
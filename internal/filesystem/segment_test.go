@@ -4,6 +4,9 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 
 	"concurrency_go_course/pkg/logger"
 )
@@ -76,3 +79,119 @@ func TestSegmentReadAll(t *testing.T) {
 		t.Errorf("wrong segment data: expected %s, got %s", "'SET k1 v1'", string(data[1]))
 	}
 }
+
+func TestSegmentWritePreallocated(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	segment := NewSegment(dir, 1024, NewFileLib(), WithPreallocation(), WithDatasync())
+
+	for _, data := range []string{"aaaaa", "bbbbb"} {
+		if err := segment.Write([]byte(data)); err != nil {
+			t.Fatalf("unable to write test data: %s", err)
+		}
+		if err := segment.Sync(); err != nil {
+			t.Fatalf("unable to sync test data: %s", err)
+		}
+	}
+
+	filenames, err := NewFileLib().FilenamesFromDir(dir)
+	if err != nil || len(filenames) != 1 {
+		t.Fatalf("wrong segment files: %v, %v", filenames, err)
+	}
+
+	stat, err := os.Stat(dir + "/" + filenames[0])
+	if err != nil {
+		t.Fatalf("unable to get file info: %s", err)
+	}
+
+	if stat.Size() != headerSize+1024 {
+		t.Errorf("wrong file size: expected %d, got %d", headerSize+1024, stat.Size())
+	}
+
+	data, err := segment.ReadAll()
+	if err != nil {
+		t.Fatalf("unable to read segments data: %s", err)
+	}
+
+	if len(data) != 1 || string(data[0]) != "aaaaabbbbb" {
+		t.Errorf("wrong segment data: expected %q, got %q", "aaaaabbbbb", data)
+	}
+}
+
+func TestSegmentRotate(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	segment := NewSegment(dir, 1024, NewFileLib())
+
+	last, err := segment.Rotate()
+	if err != nil || last != "" {
+		t.Fatalf("wrong rotation of empty directory: %q, %v", last, err)
+	}
+
+	// segments are rotated within the same millisecond
+	// without overwriting each other
+	writes := []string{"aaaaa", "bbbbb", "ccccc"}
+	names := make([]string, 0, len(writes))
+	for _, data := range writes {
+		if err := segment.Write([]byte(data)); err != nil {
+			t.Fatalf("unable to write test data: %s", err)
+		}
+
+		last, err := segment.Rotate()
+		if err != nil {
+			t.Fatalf("unable to rotate segment: %s", err)
+		}
+		names = append(names, last)
+	}
+
+	segments, err := segment.ReadSegments()
+	if err != nil || len(segments) != len(writes) {
+		t.Fatalf("wrong segments: %v, %v", segments, err)
+	}
+
+	for i, data := range writes {
+		if segments[i].Name != names[i] || string(segments[i].Data) != data {
+			t.Errorf("wrong segment: expected %s with %q, got %s with %q",
+				names[i], data, segments[i].Name, segments[i].Data)
+		}
+	}
+
+	last, err = segment.Rotate()
+	if err != nil || last != names[len(names)-1] {
+		t.Errorf("wrong rotation without writes: expected %s, got %q, %v", names[len(names)-1], last, err)
+	}
+}
+
+func TestSegmentMetrics(t *testing.T) {
+	t.Parallel()
+
+	segmentsCount.Set(0)
+	segmentsBytes.Set(0)
+
+	// segment without metrics isn't counted
+	other := NewSegment(t.TempDir(), 5, NewFileLib())
+	assert.NoError(t, other.Write([]byte("aaaaa")))
+	assert.Equal(t, float64(0), segmentsCount.Value())
+	assert.Equal(t, float64(0), segmentsBytes.Value())
+
+	segment := NewSegment(t.TempDir(), 5, NewFileLib(), WithMetrics())
+	assert.NoError(t, segment.Write([]byte("aaaaa")))
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(t, segment.Write([]byte("bbbbb")))
+	assert.Equal(t, float64(2), segmentsCount.Value())
+	assert.Equal(t, float64(10), segmentsBytes.Value())
+
+	segments, err := segment.ReadSegments()
+	assert.NoError(t, err)
+	assert.Len(t, segments, 2)
+
+	// archived data isn't counted anymore
+	_, err = segment.Archive(segments[1].Name, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), segmentsCount.Value())
+	assert.Equal(t, float64(5), segmentsBytes.Value())
+}
@@ -0,0 +1,302 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+	"concurrency_go_course/pkg/parser"
+)
+
+const (
+	maxBatchOperations = 100
+	readHeaderTimeout  = 5 * time.Second
+)
+
+// Batch operation names
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+)
+
+var errTooBig = errors.New("message is too big")
+
+// Operation is a single operation of batch request
+type Operation struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// BatchRequest is a body of batch request
+type BatchRequest struct {
+	Operations []Operation `json:"operations"`
+}
+
+// Result is a result of single operation
+type Result struct {
+	Status int    `json:"status"`
+	Key    string `json:"key,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResponse is a body of batch response
+type BatchResponse struct {
+	Results []Result `json:"results"`
+}
+
+type valueRequest struct {
+	Value string `json:"value"`
+}
+
+// Server is a HTTP/JSON gateway to database.
+// Requests are translated into text protocol queries,
+// so keys and values follow the same rules as TCP clients
+type Server struct {
+	db              database.Database
+	maxMessageSize  int
+	shutdownTimeout time.Duration
+	mux             *http.ServeMux
+}
+
+// NewServer returns new HTTP gateway
+func NewServer(cfg *config.Config, db database.Database) (*Server, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is empty")
+	}
+
+	if db == nil {
+		return nil, fmt.Errorf("database is empty")
+	}
+
+	maxMessageSize, err := parser.ParseSize(cfg.Network.MaxMessageSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set max message size: %w", err)
+	}
+
+	s := &Server{
+		db:              db,
+		maxMessageSize:  maxMessageSize,
+		shutdownTimeout: cfg.Network.GetShutdownTimeout(),
+		mux:             http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /v1/keys/{key}", s.handleGet)
+	s.mux.HandleFunc("PUT /v1/keys/{key}", s.handleSet)
+	s.mux.HandleFunc("DELETE /v1/keys/{key}", s.handleDelete)
+	s.mux.HandleFunc("POST /v1/batch", s.handleBatch)
+
+	return s, nil
+}
+
+// ServeHTTP handles HTTP request. Requests are rate limited
+// by address of client
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r.WithContext(database.WithClient(r.Context(), r.RemoteAddr)))
+}
+
+// ListenAndServe serves gateway on address until context is done.
+// Then in-flight requests are drained up to shutdown timeout
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           s,
+		ReadHeaderTimeout: readHeaderTimeout,
+		// requests aren't cancelled on shutdown to be drained
+		BaseContext: func(_ net.Listener) context.Context {
+			return context.WithoutCancel(ctx)
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Debug("Start HTTP gateway on", zap.String("address", address))
+
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	result := s.execute(r.Context(), Operation{Op: OpGet, Key: r.PathValue("key")})
+	writeJSON(w, result.Status, result)
+}
+
+func (s *Server) handleSet(w http.ResponseWriter, r *http.Request) {
+	var body valueRequest
+	if err := decodeBody(w, r, s.maxMessageSize, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := s.execute(r.Context(), Operation{Op: OpSet, Key: r.PathValue("key"), Value: body.Value})
+	writeJSON(w, result.Status, result)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	result := s.execute(r.Context(), Operation{Op: OpDelete, Key: r.PathValue("key")})
+	writeJSON(w, result.Status, result)
+}
+
+// handleBatch executes operations one by one in order of request.
+// Batch is not atomic: every operation has its own result
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var body BatchRequest
+	if err := decodeBody(w, r, s.maxMessageSize*maxBatchOperations, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if len(body.Operations) > maxBatchOperations {
+		writeJSON(w, http.StatusRequestEntityTooLarge, Result{
+			Status: http.StatusRequestEntityTooLarge,
+			Error:  fmt.Sprintf("batch is limited to %d operations", maxBatchOperations),
+		})
+		return
+	}
+
+	response := BatchResponse{Results: make([]Result, 0, len(body.Operations))}
+	for _, op := range body.Operations {
+		response.Results = append(response.Results, s.execute(r.Context(), op))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) execute(ctx context.Context, op Operation) Result {
+	query, err := s.query(op)
+	if err != nil {
+		return Result{Status: statusCode(err), Key: op.Key, Error: err.Error()}
+	}
+
+	response, err := s.db.HandleContext(ctx, query)
+	if err != nil {
+		return Result{Status: statusCode(err), Key: op.Key, Error: err.Error()}
+	}
+
+	result := Result{Status: http.StatusOK, Key: op.Key}
+	if op.Op == OpGet {
+		result.Value = response
+	}
+
+	return result
+}
+
+// query builds text protocol query of operation
+func (s *Server) query(op Operation) (string, error) {
+	var query compute.Query
+	switch op.Op {
+	case OpGet:
+		query = compute.NewQuery(compute.CommandGet, []string{op.Key})
+	case OpSet:
+		query = compute.NewQuery(compute.CommandSet, []string{op.Key, op.Value})
+	case OpDelete:
+		query = compute.NewQuery(compute.CommandDelete, []string{op.Key})
+	default:
+		return "", &badRequestError{err: fmt.Errorf("unknown operation %q", op.Op)}
+	}
+
+	request, err := query.Format()
+	if err != nil {
+		return "", &badRequestError{err: err}
+	}
+
+	if len(request) >= s.maxMessageSize {
+		return "", errTooBig
+	}
+
+	return request, nil
+}
+
+type badRequestError struct {
+	err error
+}
+
+func (e *badRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *badRequestError) Unwrap() error {
+	return e.err
+}
+
+// statusCode maps database errors to HTTP status codes
+func statusCode(err error) int {
+	var badRequest *badRequestError
+	var slaveWrite *storage.SlaveWriteError
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &slaveWrite):
+		// writes are accepted by master only
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrWrongType):
+		// key holds value of another type
+		return http.StatusConflict
+	case errors.Is(err, errTooBig):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, limits.ErrThrottled):
+		// rate limits and quotas
+		return http.StatusTooManyRequests
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, limit int, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return errTooBig
+		}
+
+		return &badRequestError{err: fmt.Errorf("invalid request body: %w", err)}
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := statusCode(err)
+	writeJSON(w, status, Result{Status: status, Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.ErrorWithMsg("unable to write response:", err)
+	}
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+)
+
+type fakeDatabase struct {
+	data  map[string]string
+	slave bool
+}
+
+func (d *fakeDatabase) Handle(request string) (string, error) {
+	return d.HandleContext(context.Background(), request)
+}
+
+func (d *fakeDatabase) HandleContext(_ context.Context, request string) (string, error) {
+	fields := strings.Fields(request)
+
+	switch fields[0] {
+	case "GET":
+		v, ok := d.data[fields[1]]
+		if !ok {
+			return "", database.ErrNotFound
+		}
+		return v, nil
+	case "SET":
+		if d.slave {
+			return "", &storage.SlaveWriteError{Command: "set"}
+		}
+		d.data[fields[1]] = fields[2]
+	case "DEL":
+		if d.slave {
+			return "", &storage.SlaveWriteError{Command: "delete"}
+		}
+		delete(d.data, fields[1])
+	}
+
+	return "OK", nil
+}
+
+func (d *fakeDatabase) WatchKey(ctx context.Context, _ string, _ func(string, bool) error) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newTestServer(t *testing.T, slave bool) *Server {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.Network.MaxMessageSize = "32B"
+
+	server, err := NewServer(cfg, &fakeDatabase{data: map[string]string{"key": "value"}, slave: slave})
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	tests := map[string]struct {
+		slave  bool
+		method string
+		path   string
+		body   string
+		status int
+		result Result
+	}{
+		"get": {
+			method: http.MethodGet, path: "/v1/keys/key",
+			status: http.StatusOK, result: Result{Status: http.StatusOK, Key: "key", Value: "value"},
+		},
+		"get not found": {
+			method: http.MethodGet, path: "/v1/keys/unknown",
+			status: http.StatusNotFound,
+			result: Result{Status: http.StatusNotFound, Key: "unknown", Error: "value not found"},
+		},
+		"set": {
+			method: http.MethodPut, path: "/v1/keys/key", body: `{"value":"new"}`,
+			status: http.StatusOK, result: Result{Status: http.StatusOK, Key: "key"},
+		},
+		"set on slave": {
+			slave:  true,
+			method: http.MethodPut, path: "/v1/keys/key", body: `{"value":"new"}`,
+			status: http.StatusConflict,
+			result: Result{Status: http.StatusConflict, Key: "key",
+				Error: "unable to execute set command on slave"},
+		},
+		"set invalid value": {
+			method: http.MethodPut, path: "/v1/keys/key", body: `{"value":"a b"}`,
+			status: http.StatusBadRequest,
+			result: Result{Status: http.StatusBadRequest, Key: "key", Error: compute.ErrInvalidArgument.Error()},
+		},
+		"set invalid body": {
+			method: http.MethodPut, path: "/v1/keys/key", body: `value`,
+			status: http.StatusBadRequest,
+			result: Result{Status: http.StatusBadRequest,
+				Error: "invalid request body: invalid character 'v' looking for beginning of value"},
+		},
+		"get too big key": {
+			method: http.MethodGet, path: "/v1/keys/" + strings.Repeat("k", 30),
+			status: http.StatusRequestEntityTooLarge,
+			result: Result{Status: http.StatusRequestEntityTooLarge, Key: strings.Repeat("k", 30),
+				Error: errTooBig.Error()},
+		},
+		"set too big body": {
+			method: http.MethodPut, path: "/v1/keys/key", body: `{"value":"` + strings.Repeat("a", 40) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+			result: Result{Status: http.StatusRequestEntityTooLarge, Error: errTooBig.Error()},
+		},
+		"delete": {
+			method: http.MethodDelete, path: "/v1/keys/key",
+			status: http.StatusOK, result: Result{Status: http.StatusOK, Key: "key"},
+		},
+		"delete on slave": {
+			slave:  true,
+			method: http.MethodDelete, path: "/v1/keys/key",
+			status: http.StatusConflict,
+			result: Result{Status: http.StatusConflict, Key: "key",
+				Error: "unable to execute delete command on slave"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newTestServer(t, test.slave)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			server.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.status, recorder.Code)
+
+			var result Result
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	server := newTestServer(t, false)
+
+	body, err := json.Marshal(BatchRequest{Operations: []Operation{
+		{Op: OpSet, Key: "a", Value: "1"},
+		{Op: OpGet, Key: "a"},
+		{Op: OpDelete, Key: "a"},
+		{Op: OpGet, Key: "a"},
+		{Op: "incr", Key: "a"},
+	}})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/batch", bytes.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response BatchResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+	assert.Equal(t, []Result{
+		{Status: http.StatusOK, Key: "a"},
+		{Status: http.StatusOK, Key: "a", Value: "1"},
+		{Status: http.StatusOK, Key: "a"},
+		{Status: http.StatusNotFound, Key: "a", Error: "value not found"},
+		{Status: http.StatusBadRequest, Key: "a", Error: `unknown operation "incr"`},
+	}, response.Results)
+}
+
+func TestServerThrottled(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(1), nil, "master", nil, nil)
+	require.NoError(t, err)
+
+	db := database.NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil,
+		database.WithRateLimits(limits.NewLimiter("client", limits.Rate{OpsPerSecond: 1}), nil))
+
+	server, err := NewServer(config.DefaultConfig(), db)
+	require.NoError(t, err)
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/v1/keys/key", nil)
+		request.RemoteAddr = remoteAddr
+		server.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("10.0.0.1:1000").Code)
+
+	// requests are limited by client IP, port isn't a part of it
+	recorder := get("10.0.0.1:2000")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	var result Result
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+	assert.Equal(t, Result{Status: http.StatusTooManyRequests, Key: "key",
+		Error: "THROTTLED client 10.0.0.1 exceeded 1 ops/s"}, result)
+
+	assert.Equal(t, http.StatusNotFound, get("10.0.0.2:1000").Code)
+}
+
+func TestServerWrongType(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := storage.New(storage.NewEngine(1), nil, "master", nil, nil)
+	require.NoError(t, err)
+
+	db := database.NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil)
+	_, err = db.Handle("HSET hash field value")
+	require.NoError(t, err)
+
+	server, err := NewServer(config.DefaultConfig(), db)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/keys/hash", nil))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	var result Result
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+	assert.Equal(t, Result{Status: http.StatusConflict, Key: "hash", Error: storage.ErrWrongType.Error()}, result)
+}
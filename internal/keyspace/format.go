@@ -0,0 +1,200 @@
+package keyspace
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Format is a format of exported keys
+type Format string
+
+// Formats of exported keys. JSON Lines has a record per line. CSV has
+// header key,type,field,value and a row per string, hash field, list
+// value or set member, rows of the same key follow each other
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+var csvHeader = []string{"key", "type", "field", "value"}
+
+// ParseFormat returns format by name, JSON Lines is used if name is empty
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatJSONL:
+		return FormatJSONL, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	}
+
+	return "", fmt.Errorf("unknown format: %s", name)
+}
+
+type recordWriter interface {
+	Write(record Record) error
+	Flush() error
+}
+
+type recordReader interface {
+	// Read returns io.EOF when all records are read
+	Read() (Record, error)
+}
+
+func newWriter(w io.Writer, format Format) (recordWriter, error) {
+	switch format {
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+func newReader(r io.Reader, format Format) (recordReader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{decoder: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = len(csvHeader)
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return &csvReader{reader: reader, done: true}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CSV header: %w", err)
+		}
+		if !slices.Equal(header, csvHeader) {
+			return nil, fmt.Errorf("unexpected CSV header %v, expected %v", header, csvHeader)
+		}
+		return &csvReader{reader: reader}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+type jsonlWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.buffered.Flush()
+}
+
+type jsonlReader struct {
+	decoder *json.Decoder
+	line    int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	var record Record
+	if err := r.decoder.Decode(&record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("unable to read record %d: %w", r.line+1, err)
+	}
+	r.line++
+
+	return record, nil
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(record Record) error {
+	switch record.Type {
+	case TypeString:
+		return w.writer.Write([]string{record.Key, record.Type, "", record.Value})
+	case TypeHash:
+		for _, field := range slices.Sorted(maps.Keys(record.Fields)) {
+			if err := w.writer.Write([]string{record.Key, record.Type, field, record.Fields[field]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, value := range record.Values {
+		if err := w.writer.Write([]string{record.Key, record.Type, "", value}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// csvReader joins consecutive rows of the same key into record
+type csvReader struct {
+	reader *csv.Reader
+	// next is a row read ahead, it starts the next record
+	next []string
+	done bool
+}
+
+func (r *csvReader) Read() (Record, error) {
+	var record *Record
+	for {
+		row := r.next
+		r.next = nil
+		if row == nil && !r.done {
+			var err error
+			row, err = r.reader.Read()
+			if errors.Is(err, io.EOF) {
+				r.done = true
+			} else if err != nil {
+				return Record{}, fmt.Errorf("unable to read CSV: %w", err)
+			}
+		}
+
+		if row == nil {
+			if record == nil {
+				return Record{}, io.EOF
+			}
+			return *record, nil
+		}
+
+		key, typ, field, value := row[0], row[1], row[2], row[3]
+		if record == nil {
+			record = &Record{Key: key, Type: typ}
+		} else if key != record.Key || typ != record.Type || typ == TypeString {
+			r.next = row
+			return *record, nil
+		}
+
+		switch typ {
+		case TypeString:
+			record.Value = value
+		case TypeHash:
+			if record.Fields == nil {
+				record.Fields = make(map[string]string)
+			}
+			record.Fields[field] = value
+		default:
+			record.Values = append(record.Values, value)
+		}
+	}
+}
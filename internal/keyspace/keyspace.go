@@ -0,0 +1,257 @@
+// Package keyspace exports keys of database to JSON Lines or CSV
+// and imports them back
+package keyspace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+)
+
+// Types of keys
+const (
+	TypeString = "string"
+	TypeHash   = "hash"
+	TypeList   = "list"
+	TypeSet    = "set"
+)
+
+const (
+	defaultPartitionsNumber = 256
+
+	// importBatchSize is a number of records written to WAL at once
+	importBatchSize = 1000
+)
+
+// Record is a key with its value. Value is set for strings, fields
+// for hashes, values for lists in order and for sets as sorted members
+type Record struct {
+	Key    string            `json:"key"`
+	Type   string            `json:"type"`
+	Value  string            `json:"value,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	Values []string          `json:"values,omitempty"`
+}
+
+// Export writes keys of snapshot requests with key prefix to w sorted by
+// key. It returns number of exported keys
+func Export(snapshot []wal.Request, w io.Writer, format Format, prefix string) (int, error) {
+	records := make([]Record, 0, len(snapshot))
+	for _, request := range snapshot {
+		if len(request.Args) == 0 || !strings.HasPrefix(request.Args[0], prefix) {
+			continue
+		}
+
+		record, err := recordOf(request)
+		if err != nil {
+			return 0, err
+		}
+		records = append(records, record)
+	}
+
+	slices.SortFunc(records, func(a, b Record) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	writer, err := newWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(records), writer.Flush()
+}
+
+// Import reads keys with key prefix from r and calls apply with requests
+// replacing them, requests of up to a thousand keys are passed at once.
+// It returns number of keys applied before error
+func Import(r io.Reader, format Format, prefix string, apply func([]wal.Request) error) (int, error) {
+	reader, err := newReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+
+	applied, pending := 0, 0
+	batch := make([]wal.Request, 0, 2*importBatchSize)
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if err := apply(batch); err != nil {
+			return fmt.Errorf("unable to import keys: %w", err)
+		}
+
+		applied += pending
+		pending = 0
+		batch = make([]wal.Request, 0, 2*importBatchSize)
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return applied, err
+		}
+
+		if !strings.HasPrefix(record.Key, prefix) {
+			continue
+		}
+
+		requests, err := requestsOf(record)
+		if err != nil {
+			return applied, err
+		}
+		batch = append(batch, requests...)
+		pending++
+
+		if pending == importBatchSize {
+			if err := flush(); err != nil {
+				return applied, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return applied, err
+	}
+
+	return applied, nil
+}
+
+// Snapshot reads segments of data directory and returns requests
+// recreating state of all logical databases. Keyring is needed for
+// encrypted segments
+func Snapshot(dir string, keyring *wal.Keyring) ([]wal.Request, error) {
+	fileLib := filesystem.NewFileLib()
+	filenames, err := fileLib.FilenamesFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	stor, err := storage.New(storage.NewEngine(defaultPartitionsNumber), nil, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range filenames {
+		data, err := fileLib.DataFromFiles(dir, []string{name})
+		if err != nil {
+			return nil, err
+		}
+
+		requests, err := wal.DecodeRequests(filesystem.SegmentPayload(data[0]), keyring)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read segment %s: %w", name, err)
+		}
+		stor.Restore(requests)
+	}
+
+	return stor.Snapshot(), nil
+}
+
+// InDatabase returns requests of logical database db
+func InDatabase(requests []wal.Request, db int) []wal.Request {
+	var filtered []wal.Request
+	for _, request := range requests {
+		if request.DB == db {
+			filtered = append(filtered, request)
+		}
+	}
+
+	return filtered
+}
+
+// ToDatabase sets logical database db of requests and returns them
+func ToDatabase(requests []wal.Request, db int) []wal.Request {
+	for i := range requests {
+		requests[i].DB = db
+	}
+
+	return requests
+}
+
+// recordOf returns record of snapshot request
+func recordOf(request wal.Request) (Record, error) {
+	record := Record{Key: request.Args[0]}
+	args := request.Args[1:]
+
+	switch request.Command {
+	case compute.CommandSet:
+		record.Type = TypeString
+		record.Value = args[0]
+	case compute.CommandHSet:
+		record.Type = TypeHash
+		record.Fields = make(map[string]string, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			record.Fields[args[i]] = args[i+1]
+		}
+	case compute.CommandRPush:
+		record.Type = TypeList
+		record.Values = args
+	case compute.CommandSAdd:
+		record.Type = TypeSet
+		record.Values = slices.Sorted(slices.Values(args))
+	default:
+		return Record{}, fmt.Errorf("unexpected snapshot command %s", request.Command)
+	}
+
+	return record, nil
+}
+
+// requestsOf returns requests deleting key and setting value of record
+func requestsOf(record Record) ([]wal.Request, error) {
+	if record.Key == "" {
+		return nil, fmt.Errorf("record has empty key")
+	}
+
+	var request wal.Request
+	switch record.Type {
+	case TypeString:
+		request = wal.Request{Command: compute.CommandSet, Args: []string{record.Key, record.Value}}
+	case TypeHash:
+		if len(record.Fields) == 0 {
+			return nil, fmt.Errorf("hash %s has no fields", record.Key)
+		}
+
+		fields := slices.Sorted(maps.Keys(record.Fields))
+		args := make([]string, 0, 1+2*len(fields))
+		args = append(args, record.Key)
+		for _, field := range fields {
+			args = append(args, field, record.Fields[field])
+		}
+		request = wal.Request{Command: compute.CommandHSet, Args: args}
+	case TypeList, TypeSet:
+		if len(record.Values) == 0 {
+			return nil, fmt.Errorf("%s %s has no values", record.Type, record.Key)
+		}
+
+		command := compute.CommandRPush
+		if record.Type == TypeSet {
+			command = compute.CommandSAdd
+		}
+		request = wal.Request{Command: command, Args: append([]string{record.Key}, record.Values...)}
+	default:
+		return nil, fmt.Errorf("key %s has unknown type %q", record.Key, record.Type)
+	}
+
+	return []wal.Request{
+		{Command: compute.CommandDelete, Args: []string{record.Key}},
+		request,
+	}, nil
+}
@@ -0,0 +1,206 @@
+package keyspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func testSnapshot() []wal.Request {
+	return []wal.Request{
+		{Command: compute.CommandSet, Args: []string{"user:2", "bob"}},
+		{Command: compute.CommandSet, Args: []string{"user:1", "alice,\"quoted\""}},
+		{Command: compute.CommandHSet, Args: []string{"user:hash", "b", "2", "a", "1"}},
+		{Command: compute.CommandRPush, Args: []string{"user:list", "z", "a", "z"}},
+		{Command: compute.CommandSAdd, Args: []string{"user:set", "y", "x"}},
+		{Command: compute.CommandSet, Args: []string{"order:1", "42"}},
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	exported, err := Export(testSnapshot(), &out, FormatCSV, "user:")
+	require.NoError(t, err)
+	assert.Equal(t, 5, exported)
+
+	assert.Equal(t, `key,type,field,value
+user:1,string,,"alice,""quoted"""
+user:2,string,,bob
+user:hash,hash,a,1
+user:hash,hash,b,2
+user:list,list,,z
+user:list,list,,a
+user:list,list,,z
+user:set,set,,x
+user:set,set,,y
+`, out.String())
+
+	out.Reset()
+	exported, err = Export(testSnapshot(), &out, FormatJSONL, "order:")
+	require.NoError(t, err)
+	assert.Equal(t, 1, exported)
+	assert.Equal(t, `{"key":"order:1","type":"string","value":"42"}`+"\n", out.String())
+}
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			_, err := Export(testSnapshot(), &out, format, "")
+			require.NoError(t, err)
+
+			stor, err := storage.New(storage.NewEngine(4), nil, "", nil, nil)
+			require.NoError(t, err)
+			// imported keys replace existing ones
+			_, err = stor.RPush(context.Background(), "user:list", []string{"old"})
+			require.NoError(t, err)
+
+			imported, err := Import(&out, format, "user:", stor.Import)
+			require.NoError(t, err)
+			assert.Equal(t, 5, imported)
+
+			value, _ := stor.Get("user:1")
+			assert.Equal(t, `alice,"quoted"`, value)
+			_, ok := stor.Get("order:1")
+			assert.False(t, ok)
+
+			hash, err := stor.HGetAll("user:hash")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"a": "1", "b": "2"}, hash)
+
+			list, err := stor.LRange("user:list", 0, -1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"z", "a", "z"}, list)
+
+			members, err := stor.SMembers("user:set")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"x", "y"}, members)
+		})
+	}
+}
+
+func TestImportBatches(t *testing.T) {
+	t.Parallel()
+
+	var in strings.Builder
+	for i := 0; i < 2500; i++ {
+		fmt.Fprintf(&in, `{"key":"key%d","type":"string","value":"v"}`+"\n", i)
+	}
+
+	var batches []int
+	imported, err := Import(strings.NewReader(in.String()), FormatJSONL, "", func(requests []wal.Request) error {
+		batches = append(batches, len(requests))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2500, imported)
+	// every key is deleted and set
+	assert.Equal(t, []int{2000, 2000, 1000}, batches)
+
+	imported, err = Import(strings.NewReader(in.String()), FormatJSONL, "", func([]wal.Request) error {
+		return fmt.Errorf("disk is full")
+	})
+	assert.ErrorContains(t, err, "disk is full")
+	assert.Zero(t, imported)
+}
+
+func TestImportInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		format Format
+		in     string
+		err    string
+	}{
+		"unknown type": {
+			format: FormatJSONL,
+			in:     `{"key":"k","type":"zset"}`,
+			err:    "unknown type",
+		},
+		"empty key": {
+			format: FormatJSONL,
+			in:     `{"key":"","type":"string","value":"v"}`,
+			err:    "empty key",
+		},
+		"empty list": {
+			format: FormatJSONL,
+			in:     `{"key":"k","type":"list"}`,
+			err:    "no values",
+		},
+		"broken JSON": {
+			format: FormatJSONL,
+			in:     `{"key":`,
+			err:    "unable to read record 1",
+		},
+		"wrong CSV header": {
+			format: FormatCSV,
+			in:     "key,value\n",
+			err:    "CSV header",
+		},
+		"wrong CSV row": {
+			format: FormatCSV,
+			in:     "key,type,field,value\nk,string\n",
+			err:    "unable to read CSV",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Import(strings.NewReader(test.in), test.format, "", func([]wal.Request) error {
+				return nil
+			})
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSONL, format)
+
+	format, err = ParseFormat("csv")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	require.NoError(t, wal.WriteRequests(dir, &wal.Settings{MaxSegmentSize: 1 << 20, Compression: wal.CompressionNone},
+		[]wal.Request{
+			{Command: compute.CommandSet, Args: []string{"key1", "value1"}},
+			{Command: compute.CommandSet, Args: []string{"key2", "value2"}},
+			{Command: compute.CommandDelete, Args: []string{"key1"}},
+		}))
+
+	snapshot, err := Snapshot(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []wal.Request{{Command: compute.CommandSet, Args: []string{"key2", "value2"}}}, snapshot)
+}
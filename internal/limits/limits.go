@@ -0,0 +1,154 @@
+// Package limits implements token bucket rate limits of requests
+// of clients and users
+package limits
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sweepInterval is an interval of removing limits of idle clients.
+// Buckets are refilled in a second, so idle client has full buckets
+const sweepInterval = time.Minute
+
+// ThrottledError is returned when request exceeds rate limit or quota
+type ThrottledError struct {
+	Reason string
+}
+
+func (e *ThrottledError) Error() string {
+	return "THROTTLED " + e.Reason
+}
+
+// Is reports whether target is ThrottledError, so any throttling
+// error matches ErrThrottled
+func (e *ThrottledError) Is(target error) bool {
+	_, ok := target.(*ThrottledError)
+	return ok
+}
+
+// ErrThrottled matches errors of throttled requests with errors.Is
+var ErrThrottled = &ThrottledError{}
+
+// Rate is a limit of requests per second. Zero limit isn't checked
+type Rate struct {
+	OpsPerSecond   float64
+	BytesPerSecond float64
+}
+
+// Limiter limits rate of requests by key, like client address or
+// user name. Every key has token buckets of a second of its rate,
+// so short bursts up to rate are allowed
+type Limiter struct {
+	kind string
+	rate Rate
+	now  func() time.Time
+
+	mutex     sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// entry is a state of limits of key
+type entry struct {
+	ops   bucket
+	bytes bucket
+	last  time.Time
+}
+
+// NewLimiter returns limiter of rate. Kind names keys in errors,
+// like client or user. It returns nil if rate has no limits,
+// nil limiter allows every request
+func NewLimiter(kind string, rate Rate) *Limiter {
+	if rate.OpsPerSecond <= 0 && rate.BytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		kind:    kind,
+		rate:    rate,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Allow takes a request of size bytes from buckets of key. It returns
+// ThrottledError without waiting if any bucket is empty
+func (l *Limiter) Allow(key string, size int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry{
+			ops:   bucket{tokens: l.rate.OpsPerSecond},
+			bytes: bucket{tokens: l.rate.BytesPerSecond},
+			last:  now,
+		}
+		l.entries[key] = e
+	}
+
+	elapsed := now.Sub(e.last).Seconds()
+	e.last = now
+	e.ops.refill(l.rate.OpsPerSecond, elapsed)
+	e.bytes.refill(l.rate.BytesPerSecond, elapsed)
+
+	if l.rate.OpsPerSecond > 0 && !e.ops.available(1) {
+		return &ThrottledError{Reason: fmt.Sprintf("%s %s exceeded %g ops/s", l.kind, key, l.rate.OpsPerSecond)}
+	}
+	if l.rate.BytesPerSecond > 0 && !e.bytes.available(0) {
+		return &ThrottledError{Reason: fmt.Sprintf("%s %s exceeded %g bytes/s", l.kind, key, l.rate.BytesPerSecond)}
+	}
+
+	e.ops.take(1)
+	e.bytes.take(float64(size))
+
+	return nil
+}
+
+// sweep removes entries of keys idle for sweep interval
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, e := range l.entries {
+		if now.Sub(e.last) >= sweepInterval {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// bucket is a token bucket holding up to a second of rate tokens,
+// but at least one token
+type bucket struct {
+	tokens float64
+}
+
+func (b *bucket) refill(rate, elapsed float64) {
+	b.tokens = min(max(rate, 1), b.tokens+elapsed*rate)
+}
+
+// available reports whether bucket has n tokens, or any tokens if n is zero
+func (b *bucket) available(n float64) bool {
+	if n == 0 {
+		return b.tokens > 0
+	}
+
+	return b.tokens >= n
+}
+
+// take takes n tokens. Request bigger than the rest of bucket is allowed,
+// its debt is repaid before the next request is allowed
+func (b *bucket) take(n float64) {
+	b.tokens -= n
+}
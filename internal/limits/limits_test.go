@@ -0,0 +1,93 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLimiter(rate Rate) (*Limiter, *time.Time) {
+	now := time.Unix(1000, 0)
+	limiter := NewLimiter("client", rate)
+	limiter.now = func() time.Time { return now }
+
+	return limiter, &now
+}
+
+func TestLimiterOps(t *testing.T) {
+	t.Parallel()
+
+	limiter, now := testLimiter(Rate{OpsPerSecond: 2})
+
+	require.NoError(t, limiter.Allow("a", 10))
+	require.NoError(t, limiter.Allow("a", 10))
+
+	err := limiter.Allow("a", 10)
+	require.ErrorIs(t, err, ErrThrottled)
+	assert.EqualError(t, err, "THROTTLED client a exceeded 2 ops/s")
+
+	// other keys have own buckets
+	require.NoError(t, limiter.Allow("b", 10))
+
+	*now = now.Add(500 * time.Millisecond)
+	require.NoError(t, limiter.Allow("a", 10))
+	require.Error(t, limiter.Allow("a", 10))
+
+	// bucket isn't refilled over a second of rate
+	*now = now.Add(time.Hour)
+	require.NoError(t, limiter.Allow("a", 10))
+	require.NoError(t, limiter.Allow("a", 10))
+	require.Error(t, limiter.Allow("a", 10))
+}
+
+func TestLimiterBytes(t *testing.T) {
+	t.Parallel()
+
+	limiter, now := testLimiter(Rate{BytesPerSecond: 100})
+
+	// request bigger than bucket is allowed when bucket isn't empty
+	require.NoError(t, limiter.Allow("a", 250))
+
+	err := limiter.Allow("a", 1)
+	assert.EqualError(t, err, "THROTTLED client a exceeded 100 bytes/s")
+
+	// debt is repaid first
+	*now = now.Add(time.Second)
+	require.Error(t, limiter.Allow("a", 1))
+	*now = now.Add(time.Second + time.Millisecond)
+	require.NoError(t, limiter.Allow("a", 1))
+}
+
+func TestLimiterSweep(t *testing.T) {
+	t.Parallel()
+
+	limiter, now := testLimiter(Rate{OpsPerSecond: 1})
+
+	require.NoError(t, limiter.Allow("a", 0))
+	require.NoError(t, limiter.Allow("b", 0))
+	assert.Len(t, limiter.entries, 2)
+
+	*now = now.Add(sweepInterval)
+	require.NoError(t, limiter.Allow("b", 0))
+	assert.Len(t, limiter.entries, 1)
+}
+
+func TestNilLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter("user", Rate{})
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.Allow("a", 100))
+}
+
+func TestThrottledError(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("unable to set: %w", &ThrottledError{Reason: "quota"})
+	assert.ErrorIs(t, err, ErrThrottled)
+	assert.False(t, errors.Is(errors.New("THROTTLED"), ErrThrottled))
+}
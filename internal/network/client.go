@@ -1,6 +1,8 @@
 package network
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"net"
 )
@@ -10,7 +12,8 @@ const ClientDefaultBufSize = 4096
 
 // TCPClient is a struct for TCP client
 type TCPClient struct {
-	conn net.Conn
+	conn   net.Conn
+	reader *bufio.Reader
 }
 
 // NewClient returns new TCP client
@@ -21,7 +24,8 @@ func NewClient(serverAddress string) (*TCPClient, error) {
 	}
 
 	return &TCPClient{
-		conn: conn,
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, ClientDefaultBufSize),
 	}, nil
 }
 
@@ -32,13 +36,34 @@ func (c *TCPClient) Send(request []byte) ([]byte, error) {
 		return nil, fmt.Errorf("unable to send request: %v", err)
 	}
 
-	response := make([]byte, ClientDefaultBufSize)
-	cnt, err := c.conn.Read(response)
+	response, err := ReadMessage(c.reader)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read response: %v", err)
 	}
 
-	return response[:cnt], nil
+	return response, nil
+}
+
+// Receive reads message pushed by server
+func (c *TCPClient) Receive() ([]byte, error) {
+	message, err := ReadMessage(c.reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read message: %v", err)
+	}
+
+	return message, nil
+}
+
+// ReadMessage reads response or pushed message of server up to delimiter
+// and returns it without delimiter. Message longer than buffer of reader
+// is an error, because the rest of it can't be told from the next one
+func ReadMessage(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadSlice(Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(line[:len(line)-1]), nil
 }
 
 // Close closes TCP client connection
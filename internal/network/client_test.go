@@ -34,7 +34,7 @@ func TestClient(t *testing.T) {
 				t.Errorf("want nil error; got %+v", err)
 			}
 
-			_, err = conn.Write([]byte(serverResponse))
+			_, err = conn.Write([]byte(serverResponse + "\n"))
 			if err != nil {
 				t.Errorf("want nil error; got %+v", err)
 			}
@@ -0,0 +1,27 @@
+package network
+
+import (
+	"concurrency_go_course/pkg/metrics"
+)
+
+// Metrics of TCP servers. Names are part of monitoring API
+// and must not be changed:
+//
+//	network_active_connections{address}      - number of connections being handled
+//	network_semaphore_wait_seconds{address}  - histogram of time spent waiting for
+//	                                           free connection slot
+//	network_queued_connections{address}      - number of accepted connections waiting
+//	                                           for free connection slot
+//	network_rejected_connections_total{address} - number of connections rejected as
+//	                                           server is busy
+var (
+	activeConnections = metrics.NewGaugeVec("network_active_connections",
+		"Number of client connections being handled.", "address")
+	semaphoreWait = metrics.NewHistogramVec("network_semaphore_wait_seconds",
+		"Time spent waiting for free connection slot in seconds.", "address",
+		metrics.DefaultBuckets)
+	queuedConnections = metrics.NewGaugeVec("network_queued_connections",
+		"Number of accepted connections waiting for free connection slot.", "address")
+	rejectedConnections = metrics.NewCounterVec("network_rejected_connections_total",
+		"Number of connections rejected as server is busy.", "address")
+)
@@ -5,17 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 
 	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/slowlog"
 	"concurrency_go_course/pkg/logger"
 	"concurrency_go_course/pkg/parser"
 	"concurrency_go_course/pkg/sema"
 )
 
+// ResponseBusy is sent to connection rejected as all connection
+// slots are taken and queue of waiting connections is full
+// or connection waited longer than queue timeout
+const ResponseBusy = "server busy"
+
+// Delimiter ends every response and pushed message, so clients
+// read them as lines whatever number of reads they take
+const Delimiter = '\n'
+
+// rejectTimeout limits writing of busy response
+const rejectTimeout = time.Second
+
 // TCPHandler is a func for data handling
 type TCPHandler = func(context.Context, []byte) []byte
 
@@ -26,6 +41,11 @@ type TCPServer struct {
 	cfg      *config.Config
 
 	semaphore *sema.Semaphore
+	queued    atomic.Int64
+
+	mutex    sync.Mutex
+	conns    map[net.Conn]struct{}
+	draining atomic.Bool
 }
 
 // NewServer returns new TCP server
@@ -38,6 +58,10 @@ func NewServer(cfg *config.Config, address string) (*TCPServer, error) {
 		return nil, fmt.Errorf("address is empty")
 	}
 
+	if cfg.Network.MaxConnections <= 0 {
+		return nil, fmt.Errorf("max connections must be positive")
+	}
+
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen: %w", err)
@@ -49,18 +73,30 @@ func NewServer(cfg *config.Config, address string) (*TCPServer, error) {
 		address:  address,
 
 		semaphore: sema.NewSemaphore(cfg.Network.MaxConnections),
+		conns:     make(map[net.Conn]struct{}),
 	}, nil
 }
 
-// Run starts TCP server
+// Run starts TCP server. When all connection slots are taken, accepted
+// connections wait in queue up to queue timeout, connections over queue
+// size or timeout are rejected with busy response. When context is done,
+// server stops accepting connections and waits for in-flight requests
+// up to shutdown timeout. Connections still open after timeout are closed
 func (s *TCPServer) Run(ctx context.Context, handler TCPHandler) {
 	fmt.Println("Server is running on", s.address)
 	logger.Debug("Start server on", zap.String("address", s.address),
 		zap.String("idle_timeout", s.cfg.Network.IdleTimeout),
 		zap.String("max_message_size", s.cfg.Network.MaxMessageSize),
-		zap.Int("max_connections", s.cfg.Network.MaxConnections))
+		zap.Int("max_connections", s.cfg.Network.MaxConnections),
+		zap.Int("max_queue_size", s.cfg.Network.MaxQueueSize),
+		zap.Duration("queue_timeout", s.cfg.Network.GetQueueTimeout()))
+
+	// handlers aren't cancelled on shutdown to finish in-flight requests
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
 
 	var wg sync.WaitGroup
+	var connWG sync.WaitGroup
 	wg.Add(1)
 
 	go func() {
@@ -80,17 +116,34 @@ func (s *TCPServer) Run(ctx context.Context, handler TCPHandler) {
 				continue
 			}
 
-			s.semaphore.Acquire()
+			waitStart := time.Now()
+			if s.semaphore.TryAcquire() {
+				semaphoreWait.With(s.address).ObserveDuration(waitStart)
+				connWG.Add(1)
+				go func(conn net.Conn) {
+					defer connWG.Done()
+					s.serve(handlerCtx, conn, handler)
+				}(conn)
+				continue
+			}
+
+			if !s.enqueue() {
+				s.reject(conn, "queue is full")
+				continue
+			}
+
+			connWG.Add(1)
 			go func(conn net.Conn) {
-				defer s.semaphore.Release()
+				defer connWG.Done()
 
-				defer func() {
-					if r := recover(); r != nil {
-						logger.Error("Recovered. Error:", zap.Any("error", r))
-					}
-				}()
+				err := s.wait(ctx)
+				semaphoreWait.With(s.address).ObserveDuration(waitStart)
+				if err != nil {
+					s.reject(conn, err.Error())
+					return
+				}
 
-				s.handle(ctx, conn, handler)
+				s.serve(handlerCtx, conn, handler)
 			}(conn)
 		}
 	}()
@@ -99,6 +152,126 @@ func (s *TCPServer) Run(ctx context.Context, handler TCPHandler) {
 	_ = s.listener.Close()
 
 	wg.Wait()
+
+	s.drain(&connWG, cancelHandlers)
+}
+
+// serve handles connection holding connection slot
+func (s *TCPServer) serve(ctx context.Context, conn net.Conn, handler TCPHandler) {
+	defer s.semaphore.Release()
+
+	activeConnections.With(s.address).Inc()
+	defer activeConnections.With(s.address).Dec()
+
+	s.track(conn, true)
+	defer s.track(conn, false)
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Recovered. Error:", zap.Any("error", r))
+		}
+	}()
+
+	s.handle(ctx, conn, handler)
+}
+
+// enqueue takes place in queue of connections waiting for connection
+// slot. It returns false if queue is full
+func (s *TCPServer) enqueue() bool {
+	if s.queued.Add(1) > int64(s.cfg.Network.MaxQueueSize) {
+		s.queued.Add(-1)
+		return false
+	}
+
+	queuedConnections.With(s.address).Inc()
+	return true
+}
+
+// wait waits for free connection slot up to queue timeout and leaves queue
+func (s *TCPServer) wait(ctx context.Context) error {
+	defer func() {
+		s.queued.Add(-1)
+		queuedConnections.With(s.address).Dec()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Network.GetQueueTimeout())
+	defer cancel()
+
+	if err := s.semaphore.AcquireContext(ctx); err != nil {
+		return fmt.Errorf("no free connection slot: %w", err)
+	}
+
+	return nil
+}
+
+// reject sends busy response and closes connection
+func (s *TCPServer) reject(conn net.Conn, reason string) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	rejectedConnections.With(s.address).Inc()
+	logger.Warn("Connection rejected, server is busy",
+		zap.String("address", s.address), zap.String("reason", reason))
+
+	if err := conn.SetWriteDeadline(time.Now().Add(rejectTimeout)); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte(ResponseBusy + string(Delimiter))); err != nil {
+		logger.ErrorWithMsg("unable to write busy response:", err)
+	}
+}
+
+// track adds connection to set of open connections or removes it
+func (s *TCPServer) track(conn net.Conn, open bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if open {
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+	}
+}
+
+// drain interrupts reading of new requests and waits for in-flight ones
+// up to shutdown timeout. Then handlers are cancelled and connections closed
+func (s *TCPServer) drain(connWG *sync.WaitGroup, cancelHandlers context.CancelFunc) {
+	s.draining.Store(true)
+
+	s.mutex.Lock()
+	for conn := range s.conns {
+		_ = conn.SetReadDeadline(time.Now())
+	}
+	s.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		connWG.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(s.cfg.Network.GetShutdownTimeout())
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		logger.Debug("Connections were drained", zap.String("address", s.address))
+		return
+	case <-timer.C:
+	}
+
+	logger.Warn("Shutdown timeout exceeded, closing connections", zap.String("address", s.address))
+
+	cancelHandlers()
+
+	s.mutex.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+
+	<-drained
 }
 
 func (s *TCPServer) handle(ctx context.Context, conn net.Conn, handler TCPHandler) {
@@ -123,33 +296,85 @@ func (s *TCPServer) handle(ctx context.Context, conn net.Conn, handler TCPHandle
 		return
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := NewSession(conn)
+	session.SetWriteTimeout(idleTimeout)
+	ctx = WithSession(ctx, session)
+
+	// next request is read while current one is handled, so disconnect
+	// of client cancels context of request blocked in handler
 	buf := make([]byte, maxMessageSize)
+	next := s.read(conn, buf, cancel)
 	for {
-		if idleTimeout != 0 {
-			if err := conn.SetDeadline(time.Now().Add(idleTimeout)); err != nil {
-				logger.ErrorWithMsg("unable to set deadline:", err)
-				return
-			}
+		deadline := time.Time{}
+		if idleTimeout != 0 && !session.Streaming() {
+			deadline = time.Now().Add(idleTimeout)
 		}
-		cnt, err := conn.Read(buf)
-		if err != nil {
-			logger.ErrorWithMsg("unable to read request:", err)
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			logger.ErrorWithMsg("unable to set deadline:", err)
+			return
+		}
+		// deadline set by drain mustn't be overwritten
+		if s.draining.Load() {
+			return
+		}
+		result := <-next
+		if result.err != nil {
+			logger.ErrorWithMsg("unable to read request:", result.err)
 			break
 		}
-		if cnt >= maxMessageSize {
+		if result.size >= maxMessageSize {
 			logger.Error("unable to handle query: too small buffer size")
 			break
 		}
-		query := string(buf[:cnt])
+		query := string(buf[:result.size])
+
+		// idle timeout isn't applied while request is handled
+		if err := conn.SetReadDeadline(time.Time{}); err != nil {
+			logger.ErrorWithMsg("unable to set deadline:", err)
+			return
+		}
+		next = s.read(conn, buf, cancel)
+
+		trace := slowlog.NewTrace()
+		response := handler(slowlog.WithTrace(ctx, trace), []byte(query))
 
 		logger.Info("Sending response to client")
-		_, err = conn.Write(handler(ctx, []byte(query)))
+		writeStart := time.Now()
+		err := session.Push(append(response, Delimiter))
+		trace.Observe(slowlog.PhaseWrite, writeStart)
 		if err != nil {
 			logger.ErrorWithMsg("unable to write response:", err)
 		}
+		trace.Finish()
 	}
 }
 
+// readResult is a result of reading of request from connection
+type readResult struct {
+	size int
+	err  error
+}
+
+// read reads request from connection to buf in background. Context of
+// connection is cancelled if client is disconnected, read timeouts of
+// idle connection and drain don't cancel it
+func (s *TCPServer) read(conn net.Conn, buf []byte, cancel context.CancelFunc) <-chan readResult {
+	result := make(chan readResult, 1)
+
+	go func() {
+		size, err := conn.Read(buf)
+		if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+			cancel()
+		}
+		result <- readResult{size: size, err: err}
+	}()
+
+	return result
+}
+
 // Close stops TCP server
 func (s *TCPServer) Close() error {
 	logger.Info("Stopping server")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -63,6 +64,19 @@ func TestNewServerNil(t *testing.T) {
 			resultServer:  nil,
 			expectedError: fmt.Errorf("address is empty"),
 		},
+		{
+			name: "New server without connection slots",
+			cfg: &config.Config{
+				Network: &config.NetworkConfig{
+					Address:        serverAddr,
+					MaxMessageSize: "4KB",
+					IdleTimeout:    "5m",
+				},
+			},
+			address:       serverAddr,
+			resultServer:  nil,
+			expectedError: fmt.Errorf("max connections must be positive"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -186,7 +200,7 @@ func TestRun(t *testing.T) {
 			t.Errorf("want nil error; got %+v", err)
 		}
 
-		assert.Equal(t, "hello first", string(buffer[:size]))
+		assert.Equal(t, "hello first\n", string(buffer[:size]))
 	}()
 
 	go func() {
@@ -208,7 +222,7 @@ func TestRun(t *testing.T) {
 			t.Errorf("want nil error; got %+v", err)
 		}
 
-		assert.Equal(t, "hello second", string(buffer[:size]))
+		assert.Equal(t, "hello second\n", string(buffer[:size]))
 	}()
 
 	wg.Wait()
@@ -217,3 +231,334 @@ func TestRun(t *testing.T) {
 		t.Errorf("unable to close listener %s", err.Error())
 	}
 }
+
+func TestRunPush(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	addr := "127.0.0.1:5556"
+
+	cfg := config.Config{
+		Network: &config.NetworkConfig{
+			Address:        addr,
+			MaxConnections: 10,
+			MaxMessageSize: "4KB",
+			IdleTimeout:    "100ms",
+		},
+	}
+
+	server, err := NewServer(&cfg, addr)
+	if err != nil {
+		t.Errorf("want nil error; got %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go server.Run(ctx, func(ctx context.Context, s []byte) []byte {
+		session, ok := SessionFromContext(ctx)
+		if !ok {
+			return []byte("no session")
+		}
+
+		session.SetStreaming(true)
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			_ = session.Push([]byte("pushed " + string(s)))
+		}()
+
+		return []byte("subscribed")
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("news"))
+	if err != nil {
+		t.Errorf("want nil error; got %+v", err)
+	}
+
+	buffer := make([]byte, 1024)
+	size, err := conn.Read(buffer)
+	if err != nil {
+		t.Errorf("want nil error; got %+v", err)
+	}
+	assert.Equal(t, "subscribed\n", string(buffer[:size]))
+
+	// message is pushed after idle timeout, streaming session must be kept
+	size, err = conn.Read(buffer)
+	if err != nil {
+		t.Errorf("want nil error; got %+v", err)
+	}
+	assert.Equal(t, "pushed news", string(buffer[:size]))
+}
+
+func TestRunDrain(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	tests := map[string]struct {
+		addr            string
+		shutdownTimeout string
+		handleTime      time.Duration
+		response        string
+	}{
+		"in-flight request is finished": {
+			addr:            "127.0.0.1:5557",
+			shutdownTimeout: "1s",
+			handleTime:      200 * time.Millisecond,
+			response:        "done\n",
+		},
+		"connection is closed after shutdown timeout": {
+			addr:            "127.0.0.1:5558",
+			shutdownTimeout: "100ms",
+			handleTime:      time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.Config{
+				Network: &config.NetworkConfig{
+					Address:         test.addr,
+					MaxConnections:  10,
+					MaxMessageSize:  "4KB",
+					IdleTimeout:     "5m",
+					ShutdownTimeout: test.shutdownTimeout,
+				},
+			}
+
+			server, err := NewServer(&cfg, test.addr)
+			if err != nil {
+				t.Fatalf("want nil error; got %+v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			started := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+
+				server.Run(ctx, func(ctx context.Context, _ []byte) []byte {
+					close(started)
+
+					select {
+					case <-time.After(test.handleTime):
+						return []byte("done")
+					case <-ctx.Done():
+						return nil
+					}
+				})
+			}()
+
+			conn, err := net.Dial("tcp", test.addr)
+			if err != nil {
+				t.Fatalf("want nil error; got %+v", err)
+			}
+			defer conn.Close()
+
+			// idle connection must be closed on shutdown
+			idle, err := net.Dial("tcp", test.addr)
+			if err != nil {
+				t.Fatalf("want nil error; got %+v", err)
+			}
+			defer idle.Close()
+
+			_, err = conn.Write([]byte("request"))
+			if err != nil {
+				t.Errorf("want nil error; got %+v", err)
+			}
+
+			<-started
+			cancel()
+
+			buffer := make([]byte, 1024)
+			size, _ := conn.Read(buffer)
+			assert.Equal(t, test.response, string(buffer[:size]))
+
+			_, err = idle.Read(buffer)
+			assert.Error(t, err)
+
+			<-stopped
+
+			_, err = net.Dial("tcp", test.addr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRunQueue(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	addr := "127.0.0.1:5559"
+
+	cfg := config.Config{
+		Network: &config.NetworkConfig{
+			Address:        addr,
+			MaxConnections: 1,
+			MaxMessageSize: "4KB",
+			IdleTimeout:    "5m",
+			MaxQueueSize:   1,
+			QueueTimeout:   "300ms",
+		},
+	}
+
+	server, err := NewServer(&cfg, addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go server.Run(ctx, func(_ context.Context, s []byte) []byte {
+		return []byte("hello " + string(s))
+	})
+
+	request := func(conn net.Conn, query string) string {
+		_, err := conn.Write([]byte(query))
+		assert.NoError(t, err)
+
+		buffer := make([]byte, 1024)
+		size, err := conn.Read(buffer)
+		assert.NoError(t, err)
+
+		return string(buffer[:size])
+	}
+
+	// the only connection slot is taken
+	first, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	assert.Equal(t, "hello first\n", request(first, "first"))
+
+	// connection waits in queue until slot is released
+	queued, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	defer queued.Close()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, float64(1), queuedConnections.With(addr).Value())
+
+	// queue is full, connection is rejected at once
+	rejected, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	defer rejected.Close()
+	buffer := make([]byte, 1024)
+	size, err := rejected.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, ResponseBusy+"\n", string(buffer[:size]))
+
+	_ = first.Close()
+	assert.Equal(t, "hello queued\n", request(queued, "queued"))
+	assert.Equal(t, float64(0), queuedConnections.With(addr).Value())
+
+	// connection waiting longer than queue timeout is rejected
+	late, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	defer late.Close()
+	size, err = late.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, ResponseBusy+"\n", string(buffer[:size]))
+	assert.Equal(t, float64(2), rejectedConnections.With(addr).Value())
+}
+
+func TestRunDisconnect(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	cfg := config.Config{
+		Network: &config.NetworkConfig{
+			MaxConnections: 1,
+			MaxMessageSize: "4KB",
+			IdleTimeout:    "5m",
+		},
+	}
+
+	server, err := NewServer(&cfg, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	addr := server.listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{}, 1)
+	go server.Run(ctx, func(ctx context.Context, _ []byte) []byte {
+		started <- struct{}{}
+		// request blocks like WATCH without timeout
+		<-ctx.Done()
+		cancelled <- struct{}{}
+		return nil
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	_, err = conn.Write([]byte("WATCH key"))
+	assert.NoError(t, err)
+	<-started
+
+	// disconnect of client cancels blocked request and frees its slot
+	_ = conn.Close()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("request wasn't cancelled after disconnect")
+	}
+
+	conn, err = net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("want nil error; got %+v", err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("WATCH key"))
+	assert.NoError(t, err)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("slot of disconnected client wasn't released")
+	}
+}
+
+func TestSessionPushTimeout(t *testing.T) {
+	t.Parallel()
+
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	session := NewSession(server)
+	session.SetWriteTimeout(50 * time.Millisecond)
+
+	// client doesn't read, so push fails by timeout
+	err := session.Push([]byte("message\n"))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	go func() {
+		buffer := make([]byte, 1024)
+		_, _ = client.Read(buffer)
+	}()
+	assert.NoError(t, session.Push([]byte("message\n")))
+}
@@ -0,0 +1,114 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type sessionKey struct{}
+
+// Session is a struct for client connection. It allows to push messages
+// to client not only in reply to request
+type Session struct {
+	conn      net.Conn
+	mutex     sync.Mutex
+	timeout   atomic.Int64
+	streaming atomic.Bool
+	db        atomic.Int64
+	user      atomic.Pointer[string]
+}
+
+// NewSession returns session of client connection
+func NewSession(conn net.Conn) *Session {
+	return &Session{conn: conn}
+}
+
+// WithSession returns context with session
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns session of connection from context
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(*Session)
+	return session, ok
+}
+
+// Push writes message to client connection. Write is limited by write
+// timeout, so client which doesn't read messages can't block it
+func (s *Session) Push(data []byte) error {
+	return s.PushTimeout(data, time.Duration(s.timeout.Load()))
+}
+
+// PushTimeout writes message to client connection within timeout,
+// zero timeout means no limit
+func (s *Session) PushTimeout(data []byte, timeout time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deadline := time.Time{}
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := s.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	_, err := s.conn.Write(data)
+	return err
+}
+
+// SetWriteTimeout limits writing of every message, zero timeout
+// means no limit
+func (s *Session) SetWriteTimeout(timeout time.Duration) {
+	s.timeout.Store(int64(timeout))
+}
+
+// SetStreaming marks session as receiving pushed messages.
+// Idle timeout is not applied to streaming session
+func (s *Session) SetStreaming(streaming bool) {
+	s.streaming.Store(streaming)
+}
+
+// Streaming returns true if session receives pushed messages
+func (s *Session) Streaming() bool {
+	return s.streaming.Load()
+}
+
+// SetDB selects logical database of session
+func (s *Session) SetDB(db int) {
+	s.db.Store(int64(db))
+}
+
+// DB returns selected logical database, it is 0 by default
+func (s *Session) DB() int {
+	return int(s.db.Load())
+}
+
+// SetUser sets user authenticated by session
+func (s *Session) SetUser(user string) {
+	s.user.Store(&user)
+}
+
+// User returns authenticated user, it is empty if session
+// isn't authenticated
+func (s *Session) User() string {
+	if user := s.user.Load(); user != nil {
+		return *user
+	}
+
+	return ""
+}
+
+// Close closes client connection
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
+// RemoteAddr returns client address
+func (s *Session) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
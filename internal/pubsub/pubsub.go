@@ -0,0 +1,133 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/pkg/logger"
+)
+
+// KeyspacePrefix is a prefix of channels with keyspace notifications
+const KeyspacePrefix = "__keyspace__:"
+
+const defaultSubscriptionBuffer = 128
+
+// Message is a struct for published message
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// Subscription is a struct for subscription on channels
+type Subscription struct {
+	broker   *Broker
+	channels []string
+	messages chan Message
+	once     sync.Once
+}
+
+// Broker is a struct for publish/subscribe broker
+type Broker struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// NewBroker returns new broker
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe subscribes on channels
+func (b *Broker) Subscribe(channels ...string) *Subscription {
+	sub := &Subscription{
+		broker:   b,
+		channels: channels,
+		messages: make(chan Message, defaultSubscriptionBuffer),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, channel := range channels {
+		subs, ok := b.subscribers[channel]
+		if !ok {
+			subs = make(map[*Subscription]struct{})
+			b.subscribers[channel] = subs
+		}
+		subs[sub] = struct{}{}
+	}
+
+	return sub
+}
+
+// Publish sends message to channel subscribers and returns number of receivers.
+// Publisher is never blocked: message is dropped for subscriber with full buffer
+func (b *Broker) Publish(channel, payload string) int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	received := 0
+	for sub := range b.subscribers[channel] {
+		select {
+		case sub.messages <- Message{Channel: channel, Payload: payload}:
+			received++
+		default:
+			logger.Warn("subscriber is too slow, message was dropped",
+				zap.String("channel", channel))
+		}
+	}
+
+	return received
+}
+
+// Notify publishes keyspace notification about key change
+// in logical database db
+func (b *Broker) Notify(db int, key, event string) {
+	b.Publish(KeyspaceChannel(db, key), strings.ToLower(event))
+}
+
+// KeyspaceChannel returns channel of keyspace notifications about key
+// of logical database db. Channels of database 0 have no database number
+func KeyspaceChannel(db int, key string) string {
+	if db == 0 {
+		return KeyspacePrefix + key
+	}
+
+	return fmt.Sprintf("__keyspace@%d__:%s", db, key)
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, channel := range sub.channels {
+		subs := b.subscribers[channel]
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(b.subscribers, channel)
+		}
+	}
+}
+
+// Channels returns subscription channels
+func (s *Subscription) Channels() []string {
+	return s.channels
+}
+
+// Messages returns channel with received messages
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Close unsubscribes from all channels
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.broker.unsubscribe(s)
+		close(s.messages)
+	})
+}
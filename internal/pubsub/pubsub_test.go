@@ -0,0 +1,84 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := NewBroker()
+
+	sub1 := broker.Subscribe("news", "weather")
+	defer sub1.Close()
+	sub2 := broker.Subscribe("news")
+	defer sub2.Close()
+
+	assert.Equal(t, 2, broker.Publish("news", "hello"))
+	assert.Equal(t, 1, broker.Publish("weather", "sunny"))
+	assert.Equal(t, 0, broker.Publish("sport", "goal"))
+
+	assert.Equal(t, Message{Channel: "news", Payload: "hello"}, <-sub1.Messages())
+	assert.Equal(t, Message{Channel: "weather", Payload: "sunny"}, <-sub1.Messages())
+	assert.Equal(t, Message{Channel: "news", Payload: "hello"}, <-sub2.Messages())
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := NewBroker()
+
+	sub := broker.Subscribe("news")
+	sub.Close()
+	sub.Close()
+
+	assert.Equal(t, 0, broker.Publish("news", "hello"))
+
+	_, ok := <-sub.Messages()
+	assert.False(t, ok)
+}
+
+func TestPublishSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := NewBroker()
+
+	sub := broker.Subscribe("news")
+	defer sub.Close()
+
+	for range defaultSubscriptionBuffer {
+		broker.Publish("news", "hello")
+	}
+
+	assert.Equal(t, 0, broker.Publish("news", "dropped"))
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	broker := NewBroker()
+
+	sub := broker.Subscribe(KeyspacePrefix+"key1", KeyspaceChannel(2, "key1"))
+	defer sub.Close()
+
+	broker.Notify(0, "key1", "SET")
+	broker.Notify(0, "key2", "DEL")
+	broker.Notify(1, "key1", "SET")
+	broker.Notify(2, "key1", "FLUSHDB")
+
+	assert.Equal(t, Message{Channel: "__keyspace__:key1", Payload: "set"}, <-sub.Messages())
+	assert.Equal(t, Message{Channel: "__keyspace@2__:key1", Payload: "flushdb"}, <-sub.Messages())
+	assert.Empty(t, sub.Messages())
+}
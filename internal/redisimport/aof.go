@@ -0,0 +1,116 @@
+package redisimport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ReadAOF applies commands of Redis append only file. AOF with RDB
+// preamble is supported, its RDB part is loaded before commands.
+// Incomplete last command is ignored like Redis does on load
+func (i *Importer) ReadAOF(r io.Reader) error {
+	reader := bufio.NewReader(r)
+
+	if prefix, err := reader.Peek(len(rdbMagic)); err == nil && string(prefix) == rdbMagic {
+		if err := i.readRDB(reader); err != nil {
+			return fmt.Errorf("unable to read RDB preamble: %w", err)
+		}
+	}
+
+	for n := 1; ; n++ {
+		args, err := readCommand(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			i.stats.Truncated = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read AOF command %d: %w", n, err)
+		}
+
+		if err := i.apply(args); err != nil {
+			return fmt.Errorf("unable to apply AOF command %d: %w", n, err)
+		}
+	}
+}
+
+// readCommand reads RESP array of bulk strings. It returns io.EOF
+// if there are no more commands and io.ErrUnexpectedEOF if command
+// is incomplete
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid array length %q", line)
+	}
+
+	args := make([]string, 0, min(count, 1024))
+	for range count {
+		arg, err := readBulkString(reader)
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func readBulkString(reader *bufio.Reader) (string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return "", fmt.Errorf("expected bulk string, got %q", line)
+	}
+
+	length, err := strconv.Atoi(line[1:])
+	if err != nil || length < 0 || length > maxStringLength {
+		return "", fmt.Errorf("invalid bulk string length %q", line)
+	}
+
+	data := make([]byte, length+2)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	if data[length] != '\r' || data[length+1] != '\n' {
+		return "", fmt.Errorf("bulk string isn't terminated by CRLF")
+	}
+
+	return string(data[:length]), nil
+}
+
+// readLine reads line terminated by CRLF without terminator
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if errors.Is(err, io.EOF) {
+		if line == "" {
+			return "", io.EOF
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("line isn't terminated by CRLF")
+	}
+
+	return line[:len(line)-2], nil
+}
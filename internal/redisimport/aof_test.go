@@ -0,0 +1,187 @@
+package redisimport
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+// resp returns commands encoded as RESP arrays of bulk strings
+func resp(commands ...[]string) string {
+	var b strings.Builder
+	for _, command := range commands {
+		fmt.Fprintf(&b, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+
+	return b.String()
+}
+
+// requestsByKey returns snapshot requests of importer by key
+func requestsByKey(t *testing.T, importer *Importer) map[string]wal.Request {
+	t.Helper()
+
+	requests, keys := importer.Requests()
+	require.Equal(t, 0, len(requests)%2)
+	require.Equal(t, len(requests)/2, keys)
+
+	byKey := make(map[string]wal.Request, len(requests)/2)
+	for i := 0; i < len(requests); i += 2 {
+		require.Equal(t, compute.CommandDelete, requests[i].Command)
+		require.Equal(t, requests[i].Args[0], requests[i+1].Args[0])
+		byKey[requests[i+1].Args[0]] = requests[i+1]
+	}
+
+	return byKey
+}
+
+func TestReadAOF(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	tests := []struct {
+		name      string
+		aof       string
+		expected  map[string]wal.Request
+		stats     Stats
+		expectErr bool
+	}{
+		{
+			name: "strings and collections",
+			aof: resp(
+				[]string{"SELECT", "0"},
+				[]string{"set", "a", "1"},
+				[]string{"INCRBY", "a", "10"},
+				[]string{"SET", "b", "x", "EX", "100"},
+				[]string{"APPEND", "b", "y"},
+				[]string{"SET", "b", "z", "NX"},
+				[]string{"MULTI"},
+				[]string{"HSET", "h", "f", "v"},
+				[]string{"RPUSH", "l", "1", "2", "3"},
+				[]string{"LPOP", "l"},
+				[]string{"EXEC"},
+				[]string{"SADD", "s", "m"},
+				[]string{"MSET", "c", "1", "d", "2"},
+				[]string{"DEL", "c"},
+				[]string{"PEXPIREAT", "d", "100"},
+			),
+			expected: map[string]wal.Request{
+				"a": {Command: compute.CommandSet, Args: []string{"a", "11"}},
+				"b": {Command: compute.CommandSet, Args: []string{"b", "xy"}},
+				"d": {Command: compute.CommandSet, Args: []string{"d", "2"}},
+				"h": {Command: compute.CommandHSet, Args: []string{"h", "f", "v"}},
+				"l": {Command: compute.CommandRPush, Args: []string{"l", "2", "3"}},
+				"s": {Command: compute.CommandSAdd, Args: []string{"s", "m"}},
+			},
+			stats: Stats{Commands: 11, Skipped: map[string]int{"SET EX": 1, "PEXPIREAT": 1}},
+		},
+		{
+			name: "other databases",
+			aof: resp(
+				[]string{"SET", "a", "1"},
+				[]string{"SELECT", "1"},
+				[]string{"SET", "a", "2"},
+				[]string{"FLUSHDB"},
+				[]string{"SELECT", "0"},
+				[]string{"SET", "b", "3"},
+			),
+			expected: map[string]wal.Request{
+				"a": {Command: compute.CommandSet, Args: []string{"a", "1"}},
+				"b": {Command: compute.CommandSet, Args: []string{"b", "3"}},
+			},
+			stats: Stats{Commands: 2, OtherDB: 2, Skipped: map[string]int{}},
+		},
+		{
+			name: "flushall",
+			aof:  resp([]string{"SET", "a", "1"}, []string{"FLUSHALL"}, []string{"SET", "b", "2"}),
+			expected: map[string]wal.Request{
+				"b": {Command: compute.CommandSet, Args: []string{"b", "2"}},
+			},
+			stats: Stats{Commands: 3, Skipped: map[string]int{}},
+		},
+		{
+			name: "truncated command",
+			aof:  resp([]string{"SET", "a", "1"}) + "*3\r\n$3\r\nSET\r\n$1\r\nb\r\n$5\r\nva",
+			expected: map[string]wal.Request{
+				"a": {Command: compute.CommandSet, Args: []string{"a", "1"}},
+			},
+			stats: Stats{Commands: 1, Truncated: true, Skipped: map[string]int{}},
+		},
+		{
+			name: "whitespaces",
+			aof: resp([]string{"SET", "a b", "1"}, []string{"RPUSH", "list", "x\ny", "z"},
+				[]string{"SET", "c", "1"}),
+			expected: map[string]wal.Request{
+				"c": {Command: compute.CommandSet, Args: []string{"c", "1"}},
+			},
+			stats: Stats{Commands: 1, Invalid: 2, Skipped: map[string]int{}},
+		},
+		{
+			name:      "inline command",
+			aof:       "SET a 1\r\n",
+			expectErr: true,
+		},
+		{
+			name:      "wrong number of arguments",
+			aof:       resp([]string{"HSET", "h", "f"}),
+			expectErr: true,
+		},
+		{
+			name:      "wrong type",
+			aof:       resp([]string{"SET", "a", "1"}, []string{"RPUSH", "a", "1"}),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			importer, err := New()
+			require.NoError(t, err)
+
+			err = importer.ReadAOF(strings.NewReader(test.aof))
+			if test.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, requestsByKey(t, importer))
+			assert.Equal(t, test.stats, importer.Stats())
+		})
+	}
+}
+
+func TestReadAOFWithRDBPreamble(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	preamble := newRDB().
+		entry(rdbTypeString, "a", str("1")).
+		entry(rdbTypeString, "b", str("2")).
+		end()
+
+	importer, err := New()
+	require.NoError(t, err)
+	require.NoError(t, importer.ReadAOF(strings.NewReader(preamble+resp([]string{"INCR", "a"}, []string{"DEL", "b"}))))
+
+	assert.Equal(t, map[string]wal.Request{
+		"a": {Command: compute.CommandSet, Args: []string{"a", "2"}},
+	}, requestsByKey(t, importer))
+
+	stats := importer.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2, stats.Commands)
+}
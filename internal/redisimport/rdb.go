@@ -0,0 +1,380 @@
+package redisimport
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const rdbMagic = "REDIS"
+
+// RDB opcodes and value types. Values of other types, like ziplist or
+// listpack encoded collections, aren't supported
+const (
+	rdbOpSlotInfo     = 0xF4
+	rdbOpFunction2    = 0xF5
+	rdbOpFunctionOld  = 0xF6
+	rdbOpModuleAux    = 0xF7
+	rdbOpIdle         = 0xF8
+	rdbOpFreq         = 0xF9
+	rdbOpAux          = 0xFA
+	rdbOpResizeDB     = 0xFB
+	rdbOpExpireTimeMS = 0xFC
+	rdbOpExpireTime   = 0xFD
+	rdbOpSelectDB     = 0xFE
+	rdbOpEOF          = 0xFF
+
+	rdbTypeString = 0
+	rdbTypeList   = 1
+	rdbTypeSet    = 2
+	rdbTypeHash   = 4
+
+	rdbEncodingInt8  = 0
+	rdbEncodingInt16 = 1
+	rdbEncodingInt32 = 2
+	rdbEncodingLZF   = 3
+
+	rdbChecksumVersion = 5
+)
+
+// ReadRDB loads keys of Redis RDB file. Strings and plain encoded
+// lists, sets and hashes are supported. Keys expired before import
+// are skipped, TTLs of other keys are dropped. Checksum isn't verified
+func (i *Importer) ReadRDB(r io.Reader) error {
+	return i.readRDB(bufio.NewReader(r))
+}
+
+func (i *Importer) readRDB(reader *bufio.Reader) error {
+	header := make([]byte, len(rdbMagic)+4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return fmt.Errorf("unable to read RDB header: %w", err)
+	}
+	if string(header[:len(rdbMagic)]) != rdbMagic {
+		return fmt.Errorf("not an RDB file")
+	}
+	version, err := strconv.Atoi(string(header[len(rdbMagic):]))
+	if err != nil {
+		return fmt.Errorf("invalid RDB version %q", header[len(rdbMagic):])
+	}
+
+	rdb := &rdbReader{reader: reader}
+	db := 0
+	var expireAt time.Time
+
+	for {
+		opcode, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("unable to read RDB: %w", err)
+		}
+
+		switch opcode {
+		case rdbOpEOF:
+			if version >= rdbChecksumVersion {
+				if _, err := io.ReadFull(reader, make([]byte, 8)); err != nil {
+					return fmt.Errorf("unable to read RDB checksum: %w", err)
+				}
+			}
+			return nil
+		case rdbOpAux:
+			if _, err := rdb.readString(); err != nil {
+				return err
+			}
+			if _, err := rdb.readString(); err != nil {
+				return err
+			}
+		case rdbOpSelectDB:
+			n, _, err := rdb.readLength()
+			if err != nil {
+				return err
+			}
+			db = int(n) //nolint:gosec
+		case rdbOpResizeDB:
+			if err := rdb.skipLengths(2); err != nil {
+				return err
+			}
+		case rdbOpSlotInfo:
+			if err := rdb.skipLengths(3); err != nil {
+				return err
+			}
+		case rdbOpIdle:
+			if err := rdb.skipLengths(1); err != nil {
+				return err
+			}
+		case rdbOpFreq:
+			if _, err := reader.ReadByte(); err != nil {
+				return err
+			}
+		case rdbOpExpireTime:
+			var seconds uint32
+			if err := binary.Read(reader, binary.LittleEndian, &seconds); err != nil {
+				return err
+			}
+			expireAt = time.Unix(int64(seconds), 0)
+		case rdbOpExpireTimeMS:
+			var ms uint64
+			if err := binary.Read(reader, binary.LittleEndian, &ms); err != nil {
+				return err
+			}
+			expireAt = time.UnixMilli(int64(ms)) //nolint:gosec
+		case rdbOpModuleAux, rdbOpFunction2, rdbOpFunctionOld:
+			return fmt.Errorf("RDB modules and functions aren't supported")
+		default:
+			if err := i.readEntry(rdb, opcode, db, expireAt); err != nil {
+				return err
+			}
+			expireAt = time.Time{}
+		}
+	}
+}
+
+// readEntry reads key with value of type and loads it
+// if it belongs to database 0 and isn't expired
+func (i *Importer) readEntry(rdb *rdbReader, valueType byte, db int, expireAt time.Time) error {
+	key, err := rdb.readString()
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	switch valueType {
+	case rdbTypeString:
+		values, err = rdb.readStrings(1)
+	case rdbTypeList, rdbTypeSet:
+		values, err = rdb.readCollection(1)
+	case rdbTypeHash:
+		values, err = rdb.readCollection(2)
+	default:
+		return fmt.Errorf("RDB value type %d of key %s isn't supported, only plain encoded values are", valueType, key)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read value of key %s: %w", key, err)
+	}
+
+	switch {
+	case db != 0:
+		i.stats.OtherDB++
+		return nil
+	case !expireAt.IsZero() && expireAt.Before(time.Now()):
+		i.stats.Expired++
+		return nil
+	case !transferable(append([]string{key}, values...)):
+		i.stats.Invalid++
+		return nil
+	case !expireAt.IsZero():
+		i.stats.Skipped["TTL"]++
+	}
+
+	i.stats.Entries++
+
+	switch valueType {
+	case rdbTypeString:
+		err = i.stor.Set(context.Background(), key, values[0])
+	case rdbTypeList:
+		_, err = i.stor.RPush(context.Background(), key, values)
+	case rdbTypeSet:
+		_, err = i.stor.SAdd(context.Background(), key, values)
+	case rdbTypeHash:
+		_, err = i.stor.HSet(context.Background(), key, values)
+	}
+
+	return err
+}
+
+// rdbReader reads length and string encodings of RDB
+type rdbReader struct {
+	reader *bufio.Reader
+}
+
+// readLength reads length encoding. It returns special encoding
+// of string instead of length if encoded is set
+func (r *rdbReader) readLength() (length uint64, encoded bool, err error) {
+	first, err := r.reader.ReadByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch first >> 6 {
+	case 0:
+		return uint64(first & 0x3F), false, nil
+	case 1:
+		next, err := r.reader.ReadByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(first&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		switch first {
+		case 0x80:
+			var n uint32
+			err := binary.Read(r.reader, binary.BigEndian, &n)
+			return uint64(n), false, err
+		case 0x81:
+			var n uint64
+			err := binary.Read(r.reader, binary.BigEndian, &n)
+			return n, false, err
+		}
+		return 0, false, fmt.Errorf("invalid length encoding 0x%X", first)
+	}
+
+	return uint64(first & 0x3F), true, nil
+}
+
+func (r *rdbReader) skipLengths(n int) error {
+	for range n {
+		if _, _, err := r.readLength(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *rdbReader) readString() (string, error) {
+	length, encoded, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		return r.readBytes(length)
+	}
+
+	switch length {
+	case rdbEncodingInt8:
+		b, err := r.reader.ReadByte()
+		return strconv.Itoa(int(int8(b))), err //nolint:gosec
+	case rdbEncodingInt16:
+		var n int16
+		err := binary.Read(r.reader, binary.LittleEndian, &n)
+		return strconv.Itoa(int(n)), err
+	case rdbEncodingInt32:
+		var n int32
+		err := binary.Read(r.reader, binary.LittleEndian, &n)
+		return strconv.Itoa(int(n)), err
+	case rdbEncodingLZF:
+		return r.readLZF()
+	}
+
+	return "", fmt.Errorf("invalid string encoding %d", length)
+}
+
+func (r *rdbReader) readBytes(length uint64) (string, error) {
+	if length > maxStringLength {
+		return "", fmt.Errorf("string length %d exceeds limit", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (r *rdbReader) readStrings(n uint64) ([]string, error) {
+	values := make([]string, 0, min(n, 1024))
+	for range n {
+		value, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// readCollection reads length of collection and its elements,
+// every element has size strings
+func (r *rdbReader) readCollection(size uint64) ([]string, error) {
+	length, _, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readStrings(length * size)
+}
+
+func (r *rdbReader) readLZF() (string, error) {
+	compressedLength, _, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+	length, _, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	if compressedLength > maxStringLength || length > maxStringLength {
+		return "", fmt.Errorf("string length %d exceeds limit", length)
+	}
+
+	compressed := make([]byte, compressedLength)
+	if _, err := io.ReadFull(r.reader, compressed); err != nil {
+		return "", err
+	}
+
+	data, err := lzfDecompress(compressed, int(length)) //nolint:gosec
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+var errLZF = errors.New("invalid LZF data")
+
+// lzfDecompress decompresses LZF data of known length
+func lzfDecompress(in []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		// literal run of ctrl+1 bytes
+		if ctrl < 1<<5 {
+			end := i + ctrl + 1
+			if end > len(in) {
+				return nil, errLZF
+			}
+			out = append(out, in[i:end]...)
+			i = end
+			continue
+		}
+
+		// back reference
+		refLength := ctrl >> 5
+		if refLength == 7 {
+			if i >= len(in) {
+				return nil, errLZF
+			}
+			refLength += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errLZF
+		}
+		ref := len(out) - ((ctrl&0x1F)<<8 | int(in[i])) - 1
+		i++
+		if ref < 0 {
+			return nil, errLZF
+		}
+
+		// reference can overlap copied bytes, so they are copied one by one
+		for j := 0; j < refLength+2; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != length {
+		return nil, errLZF
+	}
+
+	return out, nil
+}
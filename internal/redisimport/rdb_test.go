@@ -0,0 +1,191 @@
+package redisimport
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+// rdbBuilder builds RDB files for tests
+type rdbBuilder struct {
+	data []byte
+}
+
+func newRDB() *rdbBuilder {
+	return &rdbBuilder{data: []byte("REDIS0011")}
+}
+
+func (b *rdbBuilder) raw(data ...[]byte) *rdbBuilder {
+	for _, d := range data {
+		b.data = append(b.data, d...)
+	}
+	return b
+}
+
+func (b *rdbBuilder) aux(key, value string) *rdbBuilder {
+	return b.raw([]byte{rdbOpAux}, str(key), str(value))
+}
+
+func (b *rdbBuilder) selectDB(db uint64) *rdbBuilder {
+	return b.raw([]byte{rdbOpSelectDB}, length(db), []byte{rdbOpResizeDB}, length(10), length(0))
+}
+
+func (b *rdbBuilder) expireAt(t time.Time) *rdbBuilder {
+	return b.raw([]byte{rdbOpExpireTimeMS}, binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli()))) //nolint:gosec
+}
+
+func (b *rdbBuilder) entry(valueType byte, key string, value ...[]byte) *rdbBuilder {
+	return b.raw(append([][]byte{{valueType}, str(key)}, value...)...)
+}
+
+func (b *rdbBuilder) end() string {
+	return string(b.raw([]byte{rdbOpEOF}, make([]byte, 8)).data)
+}
+
+func length(n uint64) []byte {
+	switch {
+	case n < 1<<6:
+		return []byte{byte(n)}
+	case n < 1<<14:
+		return []byte{0x40 | byte(n>>8), byte(n)}
+	}
+	return binary.BigEndian.AppendUint32([]byte{0x80}, uint32(n)) //nolint:gosec
+}
+
+func str(s string) []byte {
+	return append(length(uint64(len(s))), s...)
+}
+
+func lzfStr(compressed []byte, size int) []byte {
+	data := append([]byte{0xC0 | rdbEncodingLZF}, length(uint64(len(compressed)))...)
+	data = append(data, length(uint64(size))...) //nolint:gosec
+	return append(data, compressed...)
+}
+
+func TestReadRDB(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	long := strings.Repeat("x", 100)
+	rdb := newRDB().
+		aux("redis-ver", "7.2.0").
+		selectDB(0).
+		entry(rdbTypeString, "plain", str("value")).
+		entry(rdbTypeString, "long", str(long)).
+		entry(rdbTypeString, "int8", []byte{0xC0 | rdbEncodingInt8, 0xFE}).
+		entry(rdbTypeString, "int16", []byte{0xC0 | rdbEncodingInt16, 0x39, 0x30}).
+		entry(rdbTypeString, "int32", []byte{0xC0 | rdbEncodingInt32, 0x15, 0xCD, 0x5B, 0x07}).
+		entry(rdbTypeString, "lzf", lzfStr([]byte{0x00, 'a', 0xE0, 0x00, 0x00}, 10)).
+		expireAt(time.Now().Add(-time.Hour)).
+		entry(rdbTypeString, "expired", str("old")).
+		expireAt(time.Now().Add(time.Hour)).
+		raw([]byte{rdbOpFreq, 5}).
+		entry(rdbTypeString, "ttl", str("new")).
+		entry(rdbTypeList, "list", length(3), str("b"), str("a"), str("b")).
+		entry(rdbTypeSet, "set", length(2), str("m"), str("n")).
+		entry(rdbTypeHash, "hash", length(2), str("f1"), str("v1"), str("f2"), str("v2")).
+		entry(rdbTypeString, "with space", str("value")).
+		entry(rdbTypeList, "lines", length(1), str("a\nb")).
+		selectDB(1).
+		entry(rdbTypeString, "other", str("db")).
+		end()
+
+	importer, err := New()
+	require.NoError(t, err)
+	require.NoError(t, importer.ReadRDB(strings.NewReader(rdb)))
+
+	requests := requestsByKey(t, importer)
+	assert.ElementsMatch(t, []string{"n", "m"}, requests["set"].Args[1:])
+	delete(requests, "set")
+
+	assert.Equal(t, map[string]wal.Request{
+		"plain": {Command: compute.CommandSet, Args: []string{"plain", "value"}},
+		"long":  {Command: compute.CommandSet, Args: []string{"long", long}},
+		"int8":  {Command: compute.CommandSet, Args: []string{"int8", "-2"}},
+		"int16": {Command: compute.CommandSet, Args: []string{"int16", "12345"}},
+		"int32": {Command: compute.CommandSet, Args: []string{"int32", "123456789"}},
+		"lzf":   {Command: compute.CommandSet, Args: []string{"lzf", "aaaaaaaaaa"}},
+		"ttl":   {Command: compute.CommandSet, Args: []string{"ttl", "new"}},
+		"list":  {Command: compute.CommandRPush, Args: []string{"list", "b", "a", "b"}},
+		"hash":  requests["hash"],
+	}, requests)
+	assert.ElementsMatch(t, []string{"f1", "v1", "f2", "v2"}, requests["hash"].Args[1:])
+
+	assert.Equal(t, Stats{
+		Entries: 10,
+		OtherDB: 1,
+		Expired: 1,
+		Invalid: 2,
+		Skipped: map[string]int{"TTL": 1},
+	}, importer.Stats())
+}
+
+func TestReadRDBErrors(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	tests := []struct {
+		name string
+		rdb  string
+	}{
+		{name: "not RDB", rdb: "*1\r\n$4\r\nPING\r\n"},
+		{name: "invalid version", rdb: "REDISabcd"},
+		{name: "no EOF", rdb: string(newRDB().entry(rdbTypeString, "a", str("1")).data)},
+		{name: "ziplist", rdb: newRDB().entry(10, "a", str("zl")).end()},
+		{name: "module", rdb: newRDB().raw([]byte{rdbOpModuleAux}).end()},
+		{name: "invalid LZF", rdb: newRDB().entry(rdbTypeString, "a", lzfStr([]byte{0x05, 'a'}, 6)).end()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			importer, err := New()
+			require.NoError(t, err)
+			assert.Error(t, importer.ReadRDB(strings.NewReader(test.rdb)))
+		})
+	}
+}
+
+func TestLZFDecompress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		in        []byte
+		length    int
+		expected  string
+		expectErr bool
+	}{
+		{name: "literal", in: []byte{0x02, 'a', 'b', 'c'}, length: 3, expected: "abc"},
+		{name: "back reference", in: []byte{0x02, 'a', 'b', 'c', 0x20, 0x02}, length: 6, expected: "abcabc"},
+		{name: "overlapping reference", in: []byte{0x00, 'a', 0xE0, 0x00, 0x00}, length: 10, expected: "aaaaaaaaaa"},
+		{name: "truncated literal", in: []byte{0x05, 'a'}, length: 6, expectErr: true},
+		{name: "reference before start", in: []byte{0x00, 'a', 0x20, 0x05}, length: 4, expectErr: true},
+		{name: "wrong length", in: []byte{0x00, 'a'}, length: 2, expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := lzfDecompress(test.in, test.length)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(out))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Package redisimport loads keys of Redis AOF and RDB files
+// into database data directory
+package redisimport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+)
+
+const (
+	defaultPartitionsNumber = 256
+
+	// maxStringLength is a max length of Redis string
+	maxStringLength = 512 << 20
+)
+
+// commandArgs is a minimal number of arguments of supported commands
+var commandArgs = map[string]int{
+	"SET": 2, "SETNX": 2, "GETSET": 2, "APPEND": 2, "MSET": 2,
+	"DEL": 1, "UNLINK": 1, "INCR": 1, "DECR": 1, "INCRBY": 2, "DECRBY": 2,
+	"HSET": 3, "HMSET": 3, "HDEL": 2, "LPUSH": 2, "RPUSH": 2, "LPOP": 1,
+	"SADD": 2, "SREM": 2, "FLUSHDB": 0, "FLUSHALL": 0,
+}
+
+// Stats is a summary of imported files
+type Stats struct {
+	// Commands is a number of applied AOF commands
+	Commands int
+	// Entries is a number of loaded RDB keys
+	Entries int
+	// Skipped is a number of unsupported commands by name,
+	// TTLs are skipped because keys don't expire
+	Skipped map[string]int
+	// OtherDB is a number of commands and keys of databases other than 0
+	OtherDB int
+	// Expired is a number of RDB keys expired before import
+	Expired int
+	// Truncated is set if the last AOF command is incomplete, it is ignored
+	Truncated bool
+	// Invalid is a number of commands and RDB keys with whitespaces in keys
+	// or values. They are skipped, because text protocol can't carry them
+	Invalid int
+}
+
+// Importer applies Redis commands and keys to in-memory storage.
+// Only database 0 is imported
+type Importer struct {
+	stor  storage.Storage
+	db    int
+	stats Stats
+}
+
+// New returns new importer
+func New() (*Importer, error) {
+	stor, err := newStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Importer{
+		stor:  stor,
+		stats: Stats{Skipped: make(map[string]int)},
+	}, nil
+}
+
+// Stats returns summary of imported files
+func (i *Importer) Stats() Stats {
+	return i.stats
+}
+
+// Requests returns requests replacing imported keys in data directory
+// and number of imported keys. Every key is deleted before it is set, so
+// imported keys replace existing ones when segments are recovered after
+// existing segments
+func (i *Importer) Requests() ([]wal.Request, int) {
+	snapshot := i.stor.Snapshot()
+
+	keys := make(map[string]struct{}, len(snapshot))
+	requests := make([]wal.Request, 0, 2*len(snapshot))
+	for _, request := range snapshot {
+		keys[request.Args[0]] = struct{}{}
+		requests = append(requests,
+			wal.Request{Command: compute.CommandDelete, Args: []string{request.Args[0]}},
+			request)
+	}
+
+	return requests, len(keys)
+}
+
+func newStorage() (storage.Storage, error) {
+	return storage.New(storage.NewEngine(defaultPartitionsNumber), nil, "", nil, nil)
+}
+
+// apply applies Redis command. Commands rejected by Redis aren't written
+// to AOF, so error means that command isn't compatible with storage
+func (i *Importer) apply(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch name {
+	case "SELECT":
+		if len(args) != 1 {
+			return fmt.Errorf("wrong number of arguments")
+		}
+		db, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid database %s", args[0])
+		}
+		i.db = db
+		return nil
+	case "MULTI", "EXEC":
+		return nil
+	}
+
+	if i.db != 0 {
+		i.stats.OtherDB++
+		return nil
+	}
+
+	return i.applyCommand(name, args)
+}
+
+func (i *Importer) applyCommand(name string, args []string) error {
+	required, ok := commandArgs[name]
+	if !ok {
+		i.stats.Skipped[name]++
+		return nil
+	}
+	if len(args) < required {
+		return fmt.Errorf("wrong number of arguments for %s", name)
+	}
+	if !transferable(args) {
+		i.stats.Invalid++
+		return nil
+	}
+
+	i.stats.Commands++
+
+	var err error
+	switch name {
+	case "SET":
+		err = i.set(args)
+	case "SETNX":
+		if _, found := i.stor.Get(args[0]); !found {
+			err = i.stor.Set(context.Background(), args[0], args[1])
+		}
+	case "GETSET":
+		_, _, err = i.stor.GetSet(context.Background(), args[0], args[1])
+	case "APPEND":
+		_, err = i.stor.Append(context.Background(), args[0], args[1])
+	case "MSET":
+		if len(args)%2 != 0 {
+			return fmt.Errorf("wrong number of arguments for %s", name)
+		}
+		for j := 0; j < len(args) && err == nil; j += 2 {
+			err = i.stor.Set(context.Background(), args[j], args[j+1])
+		}
+	case "DEL", "UNLINK":
+		for _, key := range args {
+			if err = i.stor.Del(context.Background(), key); err != nil {
+				break
+			}
+		}
+	case "INCR", "DECR", "INCRBY", "DECRBY":
+		err = i.incr(name, args)
+	case "HSET", "HMSET":
+		if len(args)%2 == 0 {
+			return fmt.Errorf("wrong number of arguments for %s", name)
+		}
+		_, err = i.stor.HSet(context.Background(), args[0], args[1:])
+	case "HDEL":
+		_, err = i.stor.HDel(context.Background(), args[0], args[1:])
+	case "LPUSH":
+		_, err = i.stor.LPush(context.Background(), args[0], args[1:])
+	case "RPUSH":
+		_, err = i.stor.RPush(context.Background(), args[0], args[1:])
+	case "LPOP":
+		err = i.lpop(args)
+	case "SADD":
+		_, err = i.stor.SAdd(context.Background(), args[0], args[1:])
+	case "SREM":
+		_, err = i.stor.SRem(context.Background(), args[0], args[1:])
+	case "FLUSHDB", "FLUSHALL":
+		i.stor, err = newStorage()
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// set applies SET with NX and XX conditions. Expiration options are
+// skipped, keys are imported without TTL
+func (i *Importer) set(args []string) error {
+	key, value := args[0], args[1]
+
+	for _, option := range args[2:] {
+		switch strings.ToUpper(option) {
+		case "NX":
+			if _, found := i.stor.Get(key); found {
+				return nil
+			}
+		case "XX":
+			if _, found := i.stor.Get(key); !found {
+				return nil
+			}
+		case "EX", "PX", "EXAT", "PXAT", "KEEPTTL":
+			i.stats.Skipped["SET "+strings.ToUpper(option)]++
+		}
+	}
+
+	return i.stor.Set(context.Background(), key, value)
+}
+
+func (i *Importer) incr(name string, args []string) error {
+	var delta int64 = 1
+	if name == "INCRBY" || name == "DECRBY" {
+		var err error
+		delta, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid increment %s", args[1])
+		}
+	}
+	if name == "DECR" || name == "DECRBY" {
+		delta = -delta
+	}
+
+	_, err := i.stor.Incr(context.Background(), args[0], delta)
+	return err
+}
+
+func (i *Importer) lpop(args []string) error {
+	count := 1
+	if len(args) > 1 {
+		var err error
+		count, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid count %s", args[1])
+		}
+	}
+
+	for range count {
+		if _, found, err := i.stor.LPop(context.Background(), args[0]); err != nil || !found {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// transferable returns false if any of keys and values contains
+// whitespaces, text protocol splits requests and responses by them
+func transferable(values []string) bool {
+	for _, value := range values {
+		if strings.ContainsFunc(value, unicode.IsSpace) {
+			return false
+		}
+	}
+
+	return true
+}
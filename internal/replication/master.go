@@ -9,6 +9,7 @@ import (
 	"concurrency_go_course/internal/config"
 	"concurrency_go_course/internal/filesystem"
 	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/storage/wal"
 	"concurrency_go_course/pkg/logger"
 
 	"go.uber.org/zap"
@@ -77,6 +78,17 @@ func (m *Master) Start(ctx context.Context) {
 	})
 }
 
+// negotiateCompression returns compression requested by slave
+// if it is supported, otherwise data is sent uncompressed
+func negotiateCompression(requested string) wal.Compression {
+	compression, err := wal.ParseCompression(requested)
+	if err != nil {
+		return wal.CompressionNone
+	}
+
+	return compression
+}
+
 func (m *Master) lastSegment(request SlaveRequest) MasterResponse {
 	var response MasterResponse
 
@@ -98,9 +110,17 @@ func (m *Master) lastSegment(request SlaveRequest) MasterResponse {
 		return response
 	}
 
+	compression := negotiateCompression(request.Compression)
+	segmentData, err := wal.Compress(filesystem.SegmentPayload(data), compression)
+	if err != nil {
+		logger.Error("failed to compress WAL segment", zap.Error(err))
+		return response
+	}
+
 	response.Succeed = true
-	response.SegmentData = data
+	response.SegmentData = segmentData
 	response.SegmentName = segmentName
+	response.Compression = string(compression)
 
 	logger.Debug("sending response to client ",
 		zap.String("name", response.SegmentName))
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/internal/storage/wal"
 	"concurrency_go_course/pkg/logger"
 )
 
@@ -228,3 +231,34 @@ func TestNewServerStart(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMasterLastSegmentCompression(t *testing.T) {
+	logger.MockLogger()
+
+	segmentData, err := os.ReadFile("test_data/wal_1.log")
+	assert.NoError(t, err)
+
+	master := &Master{walDirectory: "test_data", fileLib: filesystem.NewFileLib()}
+
+	tests := []struct {
+		name        string
+		requested   string
+		compression wal.Compression
+	}{
+		{name: "Slave without compression support", requested: "", compression: wal.CompressionNone},
+		{name: "Gzip is requested", requested: "gzip", compression: wal.CompressionGzip},
+		{name: "Unsupported compression is requested", requested: "zstd", compression: wal.CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := master.lastSegment(SlaveRequest{LastSegmentName: "wal_0.log", Compression: tt.requested})
+			assert.True(t, response.Succeed)
+			assert.Equal(t, string(tt.compression), response.Compression)
+
+			data, err := wal.Decompress(response.SegmentData, tt.compression)
+			assert.NoError(t, err)
+			assert.Equal(t, segmentData, data)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package replication
+
+import (
+	"sync/atomic"
+	"time"
+
+	"concurrency_go_course/pkg/metrics"
+)
+
+// lastSync is a unix time in nanoseconds of last successful sync with master
+var lastSync atomic.Int64
+
+// Metrics of replication. Names are part of monitoring API
+// and must not be changed:
+//
+//	replication_last_sync_timestamp_seconds  - unix time of last successful sync
+//	                                           of slave with master
+//	replication_lag_seconds                  - time passed since last successful
+//	                                           sync of slave with master
+//	replication_sync_errors_total            - number of failed syncs with master
+var (
+	syncErrors = metrics.NewCounter("replication_sync_errors_total",
+		"Number of failed syncs of slave with master.")
+)
+
+func init() {
+	metrics.NewGaugeFunc("replication_last_sync_timestamp_seconds",
+		"Unix time of last successful sync of slave with master.", func() float64 {
+			return float64(lastSync.Load()) / float64(time.Second)
+		})
+	metrics.NewGaugeFunc("replication_lag_seconds",
+		"Time passed since last successful sync of slave with master in seconds.", func() float64 {
+			last := lastSync.Load()
+			if last == 0 {
+				return 0
+			}
+
+			return time.Since(time.Unix(0, last)).Seconds()
+		})
+}
@@ -9,6 +9,9 @@ import (
 // SlaveRequest is a struct for request from slave node
 type SlaveRequest struct {
 	LastSegmentName string
+	// Compression is requested compression of segment data.
+	// Master which doesn't support it sends data uncompressed
+	Compression string
 }
 
 // NewRequest returns new slave request
@@ -23,6 +26,8 @@ type MasterResponse struct {
 	Succeed     bool
 	SegmentName string
 	SegmentData []byte
+	// Compression is compression of segment data chosen by master
+	Compression string
 }
 
 // NewMasterResponse returns new master response
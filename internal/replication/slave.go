@@ -1,7 +1,6 @@
 package replication
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"path"
@@ -24,6 +23,8 @@ type Slave struct {
 	walDirectory  string
 	stream        chan []wal.Request
 	fileLib       filesystem.FileLib
+	compression   wal.Compression
+	keyring       *wal.Keyring
 }
 
 // NewReplicationClient returns new replication client
@@ -38,6 +39,20 @@ func NewReplicationClient(
 		return nil, fmt.Errorf("WAL config is empty")
 	}
 
+	compression, err := wal.ParseCompression(cfg.Replication.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	// segments are saved as is, keyring decrypts them to apply
+	var keyring *wal.Keyring
+	if walCfg.WalConfig.Encryption != nil {
+		keyring, err = wal.NewKeyring(walCfg.WalConfig.Encryption)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	connection, err := network.NewClient(cfg.Replication.MasterAddress)
 	if err != nil {
 		return nil, fmt.Errorf("connection create error: %w", err)
@@ -50,6 +65,8 @@ func NewReplicationClient(
 		walDirectory:  walCfg.WalConfig.DataDirectory,
 		stream:        make(chan []wal.Request),
 		fileLib:       filesystem.NewFileLib(),
+		compression:   compression,
+		keyring:       keyring,
 	}, nil
 }
 
@@ -99,17 +116,19 @@ func (s *Slave) syncWithMaster() {
 	if err != nil {
 		logger.ErrorWithMsg("unable to sync on slave:", err)
 	}
-	req := SlaveRequest{LastSegmentName: lastSegmentName}
+	req := SlaveRequest{LastSegmentName: lastSegmentName, Compression: string(s.compression)}
 
 	data, err := EncodeSlaveRequest(&req)
 	if err != nil {
 		logger.ErrorWithMsg("unable to encode request", err)
+		syncErrors.Inc()
 		return
 	}
 
 	resp, err := s.connection.Send(data)
 	if err != nil {
 		logger.ErrorWithMsg("unable to connect with master", err)
+		syncErrors.Inc()
 		return
 	}
 
@@ -117,20 +136,40 @@ func (s *Slave) syncWithMaster() {
 	err = DecodeResponse(response, resp)
 	if err != nil {
 		logger.ErrorWithMsg("unable to decode response", err)
+		syncErrors.Inc()
+		return
+	}
+
+	// master without compression support doesn't set it
+	compression, err := wal.ParseCompression(response.Compression)
+	if err != nil {
+		logger.ErrorWithMsg("unable to decompress segment", err)
+		syncErrors.Inc()
+		return
+	}
+
+	response.SegmentData, err = wal.Decompress(response.SegmentData, compression)
+	if err != nil {
+		logger.ErrorWithMsg("unable to decompress segment", err)
+		syncErrors.Inc()
 		return
 	}
 
 	err = s.saveSegment(response.SegmentName, response.SegmentData)
 	if err != nil {
 		logger.ErrorWithMsg("unable to save segment", err)
+		syncErrors.Inc()
 		return
 	}
 
 	err = s.applyDataToEngine(response.SegmentData)
 	if err != nil {
 		logger.ErrorWithMsg("unable to apply data to engine", err)
+		syncErrors.Inc()
 		return
 	}
+
+	lastSync.Store(time.Now().UnixNano())
 }
 
 func (s *Slave) saveSegment(name string, data []byte) error {
@@ -147,7 +186,11 @@ func (s *Slave) saveSegment(name string, data []byte) error {
 		return err
 	}
 
-	return nil
+	if err = s.fileLib.SyncFile(segmentFile); err != nil {
+		return err
+	}
+
+	return segmentFile.Close()
 }
 
 func (s *Slave) applyDataToEngine(segmentData []byte) error {
@@ -155,15 +198,9 @@ func (s *Slave) applyDataToEngine(segmentData []byte) error {
 		return nil
 	}
 
-	var queries []wal.Request
-	buffer := bytes.NewBuffer(segmentData)
-	for buffer.Len() > 0 {
-		var request wal.Request
-		if err := request.Decode(buffer); err != nil {
-			return fmt.Errorf("unable to parse request data: %w", err)
-		}
-
-		queries = append(queries, request)
+	queries, err := wal.DecodeRequests(segmentData, s.keyring)
+	if err != nil {
+		return fmt.Errorf("unable to parse request data: %w", err)
 	}
 
 	s.stream <- queries
@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/api"
+	"concurrency_go_course/pkg/logger"
+	"concurrency_go_course/pkg/parser"
+)
+
+const maxBatchOperations = 100
+
+// Server is a gRPC server of database.
+// Requests are translated into text protocol queries,
+// so keys and values follow the same rules as TCP clients
+type Server struct {
+	api.UnimplementedDatabaseServer
+
+	db              database.Database
+	maxMessageSize  int
+	shutdownTimeout time.Duration
+	server          *grpc.Server
+}
+
+// NewServer returns new gRPC server
+func NewServer(cfg *config.Config, db database.Database) (*Server, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is empty")
+	}
+
+	if db == nil {
+		return nil, fmt.Errorf("database is empty")
+	}
+
+	maxMessageSize, err := parser.ParseSize(cfg.Network.MaxMessageSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set max message size: %w", err)
+	}
+
+	s := &Server{
+		db:              db,
+		maxMessageSize:  maxMessageSize,
+		shutdownTimeout: cfg.Network.GetShutdownTimeout(),
+		server:          grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize * maxBatchOperations)),
+	}
+	api.RegisterDatabaseServer(s.server, s)
+
+	return s, nil
+}
+
+// ListenAndServe serves gRPC requests on address until context is done.
+// Then in-flight requests are drained up to shutdown timeout
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		timer := time.AfterFunc(s.shutdownTimeout, s.server.Stop)
+		defer timer.Stop()
+
+		s.server.GracefulStop()
+	}()
+
+	logger.Debug("Start gRPC server on", zap.String("address", address))
+
+	return s.server.Serve(listener)
+}
+
+// Get returns value of key
+func (s *Server) Get(ctx context.Context, req *api.GetRequest) (*api.GetResponse, error) {
+	value, err := s.execute(ctx, compute.CommandGet, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetResponse{Value: value}, nil
+}
+
+// Set sets value of key
+func (s *Server) Set(ctx context.Context, req *api.SetRequest) (*api.SetResponse, error) {
+	if _, err := s.execute(ctx, compute.CommandSet, req.GetKey(), req.GetValue()); err != nil {
+		return nil, err
+	}
+
+	return &api.SetResponse{}, nil
+}
+
+// Delete deletes key
+func (s *Server) Delete(ctx context.Context, req *api.DeleteRequest) (*api.DeleteResponse, error) {
+	if _, err := s.execute(ctx, compute.CommandDelete, req.GetKey()); err != nil {
+		return nil, err
+	}
+
+	return &api.DeleteResponse{}, nil
+}
+
+// Batch executes operations one by one in order of request
+func (s *Server) Batch(ctx context.Context, req *api.BatchRequest) (*api.BatchResponse, error) {
+	if len(req.GetOperations()) > maxBatchOperations {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"batch is limited to %d operations", maxBatchOperations)
+	}
+
+	response := &api.BatchResponse{Results: make([]*api.OperationResult, 0, len(req.GetOperations()))}
+	for _, op := range req.GetOperations() {
+		var value string
+		var err error
+
+		switch {
+		case op.GetGet() != nil:
+			value, err = s.execute(ctx, compute.CommandGet, op.GetGet().GetKey())
+		case op.GetSet() != nil:
+			_, err = s.execute(ctx, compute.CommandSet, op.GetSet().GetKey(), op.GetSet().GetValue())
+		case op.GetDelete() != nil:
+			_, err = s.execute(ctx, compute.CommandDelete, op.GetDelete().GetKey())
+		default:
+			err = status.Error(codes.InvalidArgument, "operation is empty")
+		}
+
+		st := status.Convert(err)
+		response.Results = append(response.Results, &api.OperationResult{
+			Code:    int32(st.Code()), //nolint:gosec
+			Message: st.Message(),
+			Value:   value,
+		})
+	}
+
+	return response, nil
+}
+
+// Watch streams new values of key until client cancels stream
+func (s *Server) Watch(req *api.WatchRequest, stream grpc.ServerStreamingServer[api.WatchEvent]) error {
+	query := compute.NewQuery(compute.CommandWatch, []string{req.GetKey()})
+	if _, err := query.Format(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.db.WatchKey(withClient(stream.Context()), req.GetKey(), func(value string, found bool) error {
+		event := &api.WatchEvent{Key: req.GetKey(), Value: value}
+		if !found {
+			event = &api.WatchEvent{Key: req.GetKey(), Deleted: true}
+		}
+
+		return stream.Send(event)
+	})
+	if status.Code(err) == codes.Unknown {
+		return status.Error(statusCode(err), err.Error())
+	}
+
+	return err
+}
+
+func (s *Server) execute(ctx context.Context, command string, args ...string) (string, error) {
+	query := compute.NewQuery(command, args)
+
+	request, err := query.Format()
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if len(request) >= s.maxMessageSize {
+		return "", status.Error(codes.ResourceExhausted, "message is too big")
+	}
+
+	response, err := s.db.HandleContext(withClient(ctx), request)
+	if err != nil {
+		return "", status.Error(statusCode(err), err.Error())
+	}
+
+	return response, nil
+}
+
+// withClient returns context with address of peer, so requests
+// are rate limited by client address
+func withClient(ctx context.Context) context.Context {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return database.WithClient(ctx, p.Addr.String())
+	}
+
+	return ctx
+}
+
+// statusCode maps database errors to gRPC status codes
+func statusCode(err error) codes.Code {
+	var slaveWrite *storage.SlaveWriteError
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return codes.NotFound
+	case errors.As(err, &slaveWrite):
+		// writes are accepted by master only
+		return codes.FailedPrecondition
+	case errors.Is(err, storage.ErrWrongType):
+		// key holds value of another type
+		return codes.FailedPrecondition
+	case errors.Is(err, limits.ErrThrottled):
+		// rate limits and quotas
+		return codes.ResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+
+	return codes.Internal
+}
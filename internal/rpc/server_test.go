@@ -0,0 +1,220 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/api"
+	"concurrency_go_course/pkg/logger"
+)
+
+func newTestClient(t *testing.T, replicaType string, opts ...database.Option) api.DatabaseClient {
+	t.Helper()
+
+	stor, err := storage.New(storage.NewEngine(1), nil, replicaType, nil, nil)
+	require.NoError(t, err)
+
+	db := database.NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil, opts...)
+
+	cfg := config.DefaultConfig()
+	cfg.Network.MaxMessageSize = "32B"
+
+	server, err := NewServer(cfg, db)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.server.Serve(listener)
+	}()
+	t.Cleanup(server.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return api.NewDatabaseClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "master")
+	ctx := context.Background()
+
+	_, err := client.Get(ctx, &api.GetRequest{Key: "key"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Set(ctx, &api.SetRequest{Key: "key", Value: "value"})
+	require.NoError(t, err)
+
+	resp, err := client.Get(ctx, &api.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, "value", resp.GetValue())
+
+	_, err = client.Set(ctx, &api.SetRequest{Key: "key", Value: "a b"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Get(ctx, &api.GetRequest{Key: "very_long_key_which_does_not_fit"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.Delete(ctx, &api.DeleteRequest{Key: "key"})
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, &api.GetRequest{Key: "key"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerSlave(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "slave")
+
+	_, err := client.Set(context.Background(), &api.SetRequest{Key: "key", Value: "value"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.Delete(context.Background(), &api.DeleteRequest{Key: "key"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServerBatch(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "master")
+
+	resp, err := client.Batch(context.Background(), &api.BatchRequest{Operations: []*api.Operation{
+		{Op: &api.Operation_Set{Set: &api.SetRequest{Key: "a", Value: "1"}}},
+		{Op: &api.Operation_Get{Get: &api.GetRequest{Key: "a"}}},
+		{Op: &api.Operation_Delete{Delete: &api.DeleteRequest{Key: "a"}}},
+		{Op: &api.Operation_Get{Get: &api.GetRequest{Key: "a"}}},
+		{},
+	}})
+	require.NoError(t, err)
+
+	results := resp.GetResults()
+	require.Len(t, results, 5)
+	assert.Equal(t, int32(codes.OK), results[0].GetCode())
+	assert.Equal(t, "1", results[1].GetValue())
+	assert.Equal(t, int32(codes.OK), results[2].GetCode())
+	assert.Equal(t, int32(codes.NotFound), results[3].GetCode())
+	assert.Equal(t, "value not found", results[3].GetMessage())
+	assert.Equal(t, int32(codes.InvalidArgument), results[4].GetCode())
+}
+
+func TestServerWatch(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "master")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &api.WatchRequest{Key: "key"})
+	require.NoError(t, err)
+
+	// wait until watcher is registered
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = client.Set(context.Background(), &api.SetRequest{Key: "key", Value: "value"})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "value", event.GetValue())
+	assert.False(t, event.GetDeleted())
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = client.Delete(context.Background(), &api.DeleteRequest{Key: "key"})
+	require.NoError(t, err)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, event.GetDeleted())
+
+	// changes made while event is sent aren't missed,
+	// the latest value is streamed
+	_, err = client.Set(context.Background(), &api.SetRequest{Key: "key", Value: "value1"})
+	require.NoError(t, err)
+	_, err = client.Set(context.Background(), &api.SetRequest{Key: "key", Value: "value2"})
+	require.NoError(t, err)
+
+	for event.GetValue() != "value2" {
+		event, err = stream.Recv()
+		require.NoError(t, err)
+	}
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestServerDeadline(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "master")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &api.WatchRequest{Key: "key"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestServerThrottled(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	client := newTestClient(t, "master",
+		database.WithRateLimits(limits.NewLimiter("client", limits.Rate{OpsPerSecond: 2}), nil))
+	ctx := context.Background()
+
+	_, err := client.Set(ctx, &api.SetRequest{Key: "key", Value: "value"})
+	require.NoError(t, err)
+	_, err = client.Get(ctx, &api.GetRequest{Key: "key"})
+	require.NoError(t, err)
+
+	// requests are limited by address of peer
+	_, err = client.Get(ctx, &api.GetRequest{Key: "key"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "THROTTLED client")
+
+	stream, err := client.Watch(ctx, &api.WatchRequest{Key: "key"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,149 @@
+// Package slowlog implements ring buffer of requests slower than threshold
+// with their latency broken down by phase
+package slowlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limits of request arguments kept in entry, so big requests
+// don't make slow log big
+const (
+	maxArgs      = 32
+	maxArgLength = 128
+)
+
+// Phases is a latency of request by phase of handling
+type Phases struct {
+	// Parse is a parsing of request
+	Parse time.Duration
+	// WAL is waiting for request to be written to WAL
+	WAL time.Duration
+	// Apply is handling of request by storage except of WAL waiting
+	Apply time.Duration
+	// Write is writing of response to client connection
+	Write time.Duration
+}
+
+// Entry is a request of slow log
+type Entry struct {
+	ID       int64
+	Time     time.Time
+	Duration time.Duration
+	Phases   Phases
+	Client   string
+	Command  string
+	Args     []string
+}
+
+// Log is a slow log keeping last requests slower than threshold.
+// Nil log keeps nothing
+type Log struct {
+	threshold time.Duration
+
+	mutex   sync.Mutex
+	entries []Entry
+	next    int
+	lastID  int64
+}
+
+// New returns slow log of size last requests lasted threshold or longer.
+// It returns nil if size isn't positive
+func New(threshold time.Duration, size int) *Log {
+	if size <= 0 {
+		return nil
+	}
+
+	return &Log{
+		threshold: threshold,
+		entries:   make([]Entry, 0, size),
+	}
+}
+
+// Add adds entry if its duration reaches threshold. The oldest entry is
+// replaced if log is full. Entry ID is set by log
+func (l *Log) Add(entry Entry) {
+	if l == nil || entry.Duration < l.threshold {
+		return
+	}
+
+	entry.Args = truncate(entry.Args)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lastID++
+	entry.ID = l.lastID
+
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, entry)
+		return
+	}
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// Get returns up to count last entries, the newest first
+func (l *Log) Get(count int) []Entry {
+	if l == nil {
+		return nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count = max(min(count, len(l.entries)), 0)
+	entries := make([]Entry, 0, count)
+	for i := range count {
+		// the newest entry is before next one
+		index := (l.next - 1 - i + 2*len(l.entries)) % len(l.entries)
+		entries = append(entries, l.entries[index])
+	}
+
+	return entries
+}
+
+// Len returns number of entries
+func (l *Log) Len() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.entries)
+}
+
+// Reset removes all entries
+func (l *Log) Reset() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = l.entries[:0]
+	l.next = 0
+}
+
+// truncate returns copy of args limited by number and length
+func truncate(args []string) []string {
+	res := make([]string, 0, min(len(args), maxArgs))
+	for i, arg := range args {
+		if i == maxArgs-1 && len(args) > maxArgs {
+			res = append(res, fmt.Sprintf("...(%d more arguments)", len(args)-i))
+			break
+		}
+		if len(arg) > maxArgLength {
+			arg = fmt.Sprintf("%s...(%d more bytes)", arg[:maxArgLength], len(arg)-maxArgLength)
+		}
+		res = append(res, arg)
+	}
+
+	return res
+}
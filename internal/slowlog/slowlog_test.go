@@ -0,0 +1,109 @@
+package slowlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	log := New(10*time.Millisecond, 2)
+
+	log.Add(Entry{Duration: time.Millisecond, Command: "GET"})
+	assert.Equal(t, 0, log.Len())
+
+	for _, command := range []string{"SET", "DEL", "INCR"} {
+		log.Add(Entry{Duration: 10 * time.Millisecond, Command: command})
+	}
+	assert.Equal(t, 2, log.Len())
+
+	entries := log.Get(10)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "INCR", entries[0].Command)
+	assert.Equal(t, int64(3), entries[0].ID)
+	assert.Equal(t, "DEL", entries[1].Command)
+
+	assert.Len(t, log.Get(1), 1)
+	assert.Empty(t, log.Get(0))
+
+	log.Reset()
+	assert.Equal(t, 0, log.Len())
+	assert.Empty(t, log.Get(10))
+
+	log.Add(Entry{Duration: time.Second, Command: "SET"})
+	assert.Equal(t, int64(4), log.Get(1)[0].ID)
+}
+
+func TestLogTruncate(t *testing.T) {
+	t.Parallel()
+
+	log := New(0, 1)
+
+	args := make([]string, 40)
+	args[0] = strings.Repeat("v", maxArgLength+5)
+	log.Add(Entry{Command: "LPUSH", Args: args})
+
+	entry := log.Get(1)[0]
+	assert.Len(t, entry.Args, maxArgs)
+	assert.Equal(t, strings.Repeat("v", maxArgLength)+"...(5 more bytes)", entry.Args[0])
+	assert.Equal(t, "...(9 more arguments)", entry.Args[maxArgs-1])
+}
+
+func TestNilLog(t *testing.T) {
+	t.Parallel()
+
+	var log *Log
+	assert.Nil(t, New(time.Second, 0))
+
+	log.Add(Entry{Duration: time.Second})
+	log.Reset()
+	assert.Equal(t, 0, log.Len())
+	assert.Empty(t, log.Get(10))
+}
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	log := New(0, 10)
+
+	trace := NewTrace()
+	ctx := WithTrace(context.Background(), trace)
+	fromCtx, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Same(t, trace, fromCtx)
+
+	trace.Add(PhaseParse, time.Millisecond)
+	trace.Add(PhaseWAL, 2*time.Millisecond)
+	trace.Add(PhaseWAL, 3*time.Millisecond)
+	trace.Add(PhaseWrite, 4*time.Millisecond)
+
+	// trace without record isn't logged
+	trace.Finish()
+	assert.Equal(t, 0, log.Len())
+
+	trace.Record(log, "127.0.0.1:5000", "SET", []string{"key", "value"})
+	trace.Finish()
+
+	entry := log.Get(1)[0]
+	assert.Equal(t, "SET", entry.Command)
+	assert.Equal(t, []string{"key", "value"}, entry.Args)
+	assert.Equal(t, "127.0.0.1:5000", entry.Client)
+	assert.Equal(t, Phases{
+		Parse: time.Millisecond,
+		WAL:   5 * time.Millisecond,
+		Write: 4 * time.Millisecond,
+	}, entry.Phases)
+
+	_, ok = FromContext(context.Background())
+	assert.False(t, ok)
+
+	var nilTrace *Trace
+	nilTrace.Add(PhaseApply, time.Second)
+	nilTrace.Finish()
+	assert.Equal(t, time.Duration(0), nilTrace.Phase(PhaseApply))
+}
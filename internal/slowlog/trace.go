@@ -0,0 +1,120 @@
+package slowlog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Phase is a phase of request handling
+type Phase int
+
+// Phases of request handling
+const (
+	PhaseParse Phase = iota
+	PhaseWAL
+	PhaseApply
+	PhaseWrite
+
+	phasesNumber
+)
+
+type traceKey struct{}
+
+// Trace collects latency of request phases. Request is added to slow log
+// when trace is finished. Methods of nil trace do nothing
+type Trace struct {
+	start  time.Time
+	phases [phasesNumber]atomic.Int64
+
+	mutex   sync.Mutex
+	log     *Log
+	client  string
+	command string
+	args    []string
+}
+
+// NewTrace returns trace of request started now
+func NewTrace() *Trace {
+	return &Trace{start: time.Now()}
+}
+
+// WithTrace returns context with trace
+func WithTrace(ctx context.Context, trace *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// FromContext returns trace of request from context
+func FromContext(ctx context.Context) (*Trace, bool) {
+	trace, ok := ctx.Value(traceKey{}).(*Trace)
+	return trace, ok && trace != nil
+}
+
+// Observe adds time passed since start to phase
+func (t *Trace) Observe(phase Phase, start time.Time) {
+	t.Add(phase, time.Since(start))
+}
+
+// Add adds duration to phase
+func (t *Trace) Add(phase Phase, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.phases[phase].Add(int64(d))
+}
+
+// Phase returns duration of phase
+func (t *Trace) Phase(phase Phase) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	return time.Duration(t.phases[phase].Load())
+}
+
+// Record sets slow log and request of trace. Trace without them
+// isn't added to any log on finish
+func (t *Trace) Record(log *Log, client, command string, args []string) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.log = log
+	t.client = client
+	t.command = command
+	t.args = args
+}
+
+// Finish adds request to slow log if it is slower than threshold
+func (t *Trace) Finish() {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	log, client, command, args := t.log, t.client, t.command, t.args
+	t.mutex.Unlock()
+
+	if log == nil {
+		return
+	}
+
+	log.Add(Entry{
+		Time:     t.start,
+		Duration: time.Since(t.start),
+		Phases: Phases{
+			Parse: t.Phase(PhaseParse),
+			WAL:   t.Phase(PhaseWAL),
+			Apply: t.Phase(PhaseApply),
+			Write: t.Phase(PhaseWrite),
+		},
+		Client:  client,
+		Command: command,
+		Args:    args,
+	})
+}
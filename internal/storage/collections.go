@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"slices"
+)
+
+// HSet sets fields of hash stored at key and returns number of new fields.
+// Pairs is a flat list of field and value
+func (s *HashTable) HSet(key string, pairs []string, commitFn CommitFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+
+	if err := commit(commitFn); err != nil {
+		return 0, err
+	}
+
+	hash, ok := s.hashes[key]
+	if !ok {
+		hash = make(map[string]string, len(pairs)/2)
+		s.hashes[key] = hash
+	}
+
+	created, delta := 0, 0
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if old, ok := hash[pairs[i]]; ok {
+			delta += len(pairs[i+1]) - len(old)
+		} else {
+			created++
+			delta += len(pairs[i]) + len(pairs[i+1]) + elementOverhead
+		}
+		hash[pairs[i]] = pairs[i+1]
+	}
+	s.grow(key, delta)
+
+	return created, nil
+}
+
+// HGet returns value of hash field
+func (s *HashTable) HGet(key, field string) (string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.checkType(key, typeHash); err != nil {
+		return "", false, err
+	}
+
+	value, found := s.hashes[key][field]
+	s.touch(key)
+
+	return value, found, nil
+}
+
+// HDel deletes fields of hash and returns number of deleted fields
+func (s *HashTable) HDel(key string, fields []string, commitFn CommitFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeHash); err != nil {
+		return 0, err
+	}
+
+	fields = unique(fields)
+	hash := s.hashes[key]
+
+	deleted := 0
+	for _, field := range fields {
+		if _, ok := hash[field]; ok {
+			deleted++
+		}
+	}
+
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	if err := commit(commitFn); err != nil {
+		return 0, err
+	}
+
+	delta := 0
+	for _, field := range fields {
+		if value, ok := hash[field]; ok {
+			delta -= len(field) + len(value) + elementOverhead
+			delete(hash, field)
+		}
+	}
+	s.grow(key, delta)
+
+	if len(hash) == 0 {
+		s.delete(key)
+	}
+
+	return deleted, nil
+}
+
+// HGetAll returns copy of hash stored at key
+func (s *HashTable) HGetAll(key string) (map[string]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.checkType(key, typeHash); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(s.hashes[key]))
+	for field, value := range s.hashes[key] {
+		result[field] = value
+	}
+	s.touch(key)
+
+	return result, nil
+}
+
+// LPush inserts values at the head of list and returns list length
+func (s *HashTable) LPush(key string, values []string, commitFn CommitFunc) (int, error) {
+	return s.push(key, values, true, commitFn)
+}
+
+// RPush inserts values at the tail of list and returns list length
+func (s *HashTable) RPush(key string, values []string, commitFn CommitFunc) (int, error) {
+	return s.push(key, values, false, commitFn)
+}
+
+func (s *HashTable) push(key string, values []string, head bool, commitFn CommitFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeList); err != nil {
+		return 0, err
+	}
+
+	if err := commit(commitFn); err != nil {
+		return 0, err
+	}
+
+	list := s.lists[key]
+	if head {
+		reversed := slices.Clone(values)
+		slices.Reverse(reversed)
+		list = append(reversed, list...)
+	} else {
+		list = append(list, values...)
+	}
+	s.lists[key] = list
+
+	delta := 0
+	for _, value := range values {
+		delta += len(value) + elementOverhead
+	}
+	s.grow(key, delta)
+
+	return len(list), nil
+}
+
+// LPop removes and returns first element of list
+func (s *HashTable) LPop(key string, commitFn CommitFunc) (string, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeList); err != nil {
+		return "", false, err
+	}
+
+	list := s.lists[key]
+	if len(list) == 0 {
+		return "", false, nil
+	}
+
+	if err := commit(commitFn); err != nil {
+		return "", false, err
+	}
+
+	value := list[0]
+	if len(list) == 1 {
+		s.delete(key)
+	} else {
+		s.lists[key] = list[1:]
+		s.grow(key, -(len(value) + elementOverhead))
+	}
+
+	return value, true, nil
+}
+
+// LRange returns elements of list between start and stop inclusive.
+// Negative indexes are counted from the end of list
+func (s *HashTable) LRange(key string, start, stop int) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.checkType(key, typeList); err != nil {
+		return nil, err
+	}
+
+	list := s.lists[key]
+	length := len(list)
+	s.touch(key)
+
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	stop = min(stop, length-1)
+
+	if start > stop {
+		return []string{}, nil
+	}
+
+	return slices.Clone(list[start : stop+1]), nil
+}
+
+// SAdd adds members to set and returns number of added members
+func (s *HashTable) SAdd(key string, members []string, commitFn CommitFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+
+	members = unique(members)
+	set, ok := s.sets[key]
+
+	added := 0
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			added++
+		}
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+
+	if err := commit(commitFn); err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		set = make(map[string]struct{}, len(members))
+		s.sets[key] = set
+	}
+
+	delta := 0
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			delta += len(member) + elementOverhead
+		}
+	}
+	s.grow(key, delta)
+
+	return added, nil
+}
+
+// SRem removes members from set and returns number of removed members
+func (s *HashTable) SRem(key string, members []string, commitFn CommitFunc) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkType(key, typeSet); err != nil {
+		return 0, err
+	}
+
+	members = unique(members)
+	set := s.sets[key]
+
+	removed := 0
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err := commit(commitFn); err != nil {
+		return 0, err
+	}
+
+	delta := 0
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			delta -= len(member) + elementOverhead
+		}
+	}
+	s.grow(key, delta)
+
+	if len(set) == 0 {
+		s.delete(key)
+	}
+
+	return removed, nil
+}
+
+// SMembers returns sorted members of set
+func (s *HashTable) SMembers(key string) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.checkType(key, typeSet); err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(s.sets[key]))
+	for member := range s.sets[key] {
+		members = append(members, member)
+	}
+	slices.Sort(members)
+	s.touch(key)
+
+	return members, nil
+}
+
+func unique(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+
+	return slices.Compact(values)
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashTable_Hash(t *testing.T) {
+	t.Parallel()
+
+	t.Run("set, get and delete hash fields", func(t *testing.T) {
+		table := NewHashTable()
+
+		created, err := table.HSet("hash", []string{"f1", "v1", "f2", "v2"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, created)
+
+		created, err = table.HSet("hash", []string{"f1", "v3"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, created)
+
+		value, found, err := table.HGet("hash", "f1")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "v3", value)
+
+		deleted, err := table.HDel("hash", []string{"f1", "f3"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+
+		hash, err := table.HGetAll("hash")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"f2": "v2"}, hash)
+	})
+
+	t.Run("hash is removed with last field", func(t *testing.T) {
+		table := NewHashTable()
+
+		_, err := table.HSet("hash", []string{"f1", "v1"}, nil)
+		require.NoError(t, err)
+
+		_, err = table.HDel("hash", []string{"f1"}, nil)
+		require.NoError(t, err)
+
+		table.Set("hash", "value", nil)
+		value, found := table.Get("hash")
+		require.True(t, found)
+		require.Equal(t, "value", value)
+	})
+}
+
+func TestHashTable_List(t *testing.T) {
+	t.Parallel()
+
+	table := NewHashTable()
+
+	length, err := table.RPush("list", []string{"c", "d"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, length)
+
+	length, err = table.LPush("list", []string{"b", "a"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, length)
+
+	tests := map[string]struct {
+		start, stop int
+		expected    []string
+	}{
+		"full range":         {start: 0, stop: -1, expected: []string{"a", "b", "c", "d"}},
+		"middle of list":     {start: 1, stop: 2, expected: []string{"b", "c"}},
+		"negative start":     {start: -2, stop: -1, expected: []string{"c", "d"}},
+		"stop out of range":  {start: 2, stop: 100, expected: []string{"c", "d"}},
+		"start after stop":   {start: 3, stop: 1, expected: []string{}},
+		"start out of range": {start: 10, stop: 20, expected: []string{}},
+		"start before head":  {start: -100, stop: 0, expected: []string{"a"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := table.LRange("list", test.start, test.stop)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, values)
+		})
+	}
+
+	value, found, err := table.LPop("list", nil)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "a", value)
+}
+
+func TestHashTable_Sets(t *testing.T) {
+	t.Parallel()
+
+	table := NewHashTable()
+
+	added, err := table.SAdd("set", []string{"b", "a", "b"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+
+	removed, err := table.SRem("set", []string{"b", "c"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	members, err := table.SMembers("set")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, members)
+}
+
+func TestHashTable_WrongType(t *testing.T) {
+	t.Parallel()
+
+	table := NewHashTable()
+	table.Set("string", "value", nil)
+
+	_, err := table.HSet("string", []string{"f", "v"}, nil)
+	require.ErrorIs(t, err, ErrWrongType)
+
+	_, err = table.LPush("string", []string{"v"}, nil)
+	require.ErrorIs(t, err, ErrWrongType)
+
+	_, err = table.SAdd("string", []string{"v"}, nil)
+	require.ErrorIs(t, err, ErrWrongType)
+
+	_, err = table.SAdd("list", []string{"v"}, nil)
+	require.NoError(t, err)
+
+	_, err = table.Update("list", func(value string, _ bool) (string, error) {
+		return value, nil
+	})
+	require.ErrorIs(t, err, ErrWrongType)
+}
+
+func TestHashTable_CommitError(t *testing.T) {
+	t.Parallel()
+
+	table := NewHashTable()
+	commitErr := errors.New("commit error")
+
+	_, err := table.RPush("list", []string{"a"}, func() error { return commitErr })
+	require.ErrorIs(t, err, commitErr)
+
+	values, err := table.LRange("list", 0, -1)
+	require.NoError(t, err)
+	require.Empty(t, values)
+}
@@ -2,35 +2,100 @@ package storage
 
 import (
 	"hash/fnv"
+	"maps"
+	"slices"
 	"sync"
 )
 
 // Engine is interface for engine
 type Engine interface {
 	Get(key string) (string, bool)
-	Set(key string, value string)
-	Delete(key string)
+	GetString(key string) (string, bool, error)
+	Set(key string, value string, commit CommitFunc) error
+	Delete(key string, commit CommitFunc) error
+	Update(key string, update UpdateFunc) (string, error)
+
+	HSet(key string, pairs []string, commit CommitFunc) (int, error)
+	HGet(key, field string) (string, bool, error)
+	HDel(key string, fields []string, commit CommitFunc) (int, error)
+	HGetAll(key string) (map[string]string, error)
+
+	LPush(key string, values []string, commit CommitFunc) (int, error)
+	RPush(key string, values []string, commit CommitFunc) (int, error)
+	LPop(key string, commit CommitFunc) (string, bool, error)
+	LRange(key string, start, stop int) ([]string, error)
+
+	SAdd(key string, members []string, commit CommitFunc) (int, error)
+	SRem(key string, members []string, commit CommitFunc) (int, error)
+	SMembers(key string) ([]string, error)
+
+	Dump(fn DumpFunc)
+	Flush(commit CommitFunc) ([]string, error)
+
+	Select(db int) Engine
+	Databases() []int
 }
 
 type engine struct {
+	db    int
 	parts []*HashTable
+	limit *memoryLimit
+	dbs   *databases
+}
+
+// databases is a registry of logical databases of engine.
+// Databases are created on first use and share memory limit
+type databases struct {
+	mutex       sync.RWMutex
+	engines     map[int]*engine
+	partsNumber int
+	quotas      []*quotaCounter
 }
 
 const defaultKeyCount = 8
 
-// NewEngine returns new engine
+// NewEngine returns new engine. It is logical database 0, other
+// databases are returned by Select
 func NewEngine(partsNumber int) Engine {
 	engine := &engine{
-		parts: make([]*HashTable, partsNumber),
+		parts: newParts(partsNumber),
+		dbs: &databases{
+			engines:     make(map[int]*engine),
+			partsNumber: partsNumber,
+		},
 	}
+	engine.dbs.engines[0] = engine
+
+	return engine
+}
 
+func newParts(partsNumber int) []*HashTable {
+	parts := make([]*HashTable, partsNumber)
 	for i := 0; i < partsNumber; i++ {
-		engine.parts[i] = &HashTable{
-			mutex: sync.RWMutex{},
-			data:  make(map[string]string, defaultKeyCount),
-		}
+		parts[i] = newHashTable(defaultKeyCount)
 	}
-	return engine
+
+	return parts
+}
+
+// NewEngineWithLimit returns new engine which evicts keys with policy
+// when used memory exceeds maxMemory bytes. Zero maxMemory means no limit
+func NewEngineWithLimit(partsNumber int, maxMemory int64, policy string) (Engine, error) {
+	if policy == "" {
+		policy = PolicyNoEviction
+	}
+
+	if err := ValidateEvictionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	engine := NewEngine(partsNumber).(*engine)
+	engine.limit = &memoryLimit{
+		maxMemory: maxMemory,
+		policy:    policy,
+	}
+
+	return engine, nil
 }
 
 // Get returns value
@@ -47,20 +112,169 @@ func (e *engine) Get(key string) (string, bool) {
 	return value, ok
 }
 
+// GetString returns string value or error if key holds value of another type
+func (e *engine) GetString(key string) (string, bool, error) {
+	return e.partition(key).GetString(key)
+}
+
 // Set sets new value for key
-func (e *engine) Set(key string, value string) {
+func (e *engine) Set(key string, value string, commit CommitFunc) error {
 	hash := getHash(key, len(e.parts))
 	part := e.parts[hash]
 
-	part.Set(key, value)
+	return part.Set(key, value, commit)
 }
 
 // Delete deletes key-value pair
-func (e *engine) Delete(key string) {
+func (e *engine) Delete(key string, commit CommitFunc) error {
+	hash := getHash(key, len(e.parts))
+	part := e.parts[hash]
+
+	return part.Del(key, commit)
+}
+
+// Update atomically updates value for key
+func (e *engine) Update(key string, update UpdateFunc) (string, error) {
 	hash := getHash(key, len(e.parts))
 	part := e.parts[hash]
 
-	part.Del(key)
+	return part.Update(key, update)
+}
+
+// HSet sets fields of hash
+func (e *engine) HSet(key string, pairs []string, commit CommitFunc) (int, error) {
+	return e.partition(key).HSet(key, pairs, commit)
+}
+
+// HGet returns value of hash field
+func (e *engine) HGet(key, field string) (string, bool, error) {
+	return e.partition(key).HGet(key, field)
+}
+
+// HDel deletes fields of hash
+func (e *engine) HDel(key string, fields []string, commit CommitFunc) (int, error) {
+	return e.partition(key).HDel(key, fields, commit)
+}
+
+// HGetAll returns all fields of hash
+func (e *engine) HGetAll(key string) (map[string]string, error) {
+	return e.partition(key).HGetAll(key)
+}
+
+// LPush inserts values at the head of list
+func (e *engine) LPush(key string, values []string, commit CommitFunc) (int, error) {
+	return e.partition(key).LPush(key, values, commit)
+}
+
+// RPush inserts values at the tail of list
+func (e *engine) RPush(key string, values []string, commit CommitFunc) (int, error) {
+	return e.partition(key).RPush(key, values, commit)
+}
+
+// LPop removes and returns first element of list
+func (e *engine) LPop(key string, commit CommitFunc) (string, bool, error) {
+	return e.partition(key).LPop(key, commit)
+}
+
+// LRange returns range of list elements
+func (e *engine) LRange(key string, start, stop int) ([]string, error) {
+	return e.partition(key).LRange(key, start, stop)
+}
+
+// SAdd adds members to set
+func (e *engine) SAdd(key string, members []string, commit CommitFunc) (int, error) {
+	return e.partition(key).SAdd(key, members, commit)
+}
+
+// SRem removes members from set
+func (e *engine) SRem(key string, members []string, commit CommitFunc) (int, error) {
+	return e.partition(key).SRem(key, members, commit)
+}
+
+// SMembers returns members of set
+func (e *engine) SMembers(key string) ([]string, error) {
+	return e.partition(key).SMembers(key)
+}
+
+// Dump calls fn with commands recreating every key. Partitions are
+// dumped one by one, every partition is consistent
+func (e *engine) Dump(fn DumpFunc) {
+	for _, part := range e.parts {
+		part.Dump(fn)
+	}
+}
+
+// Flush deletes all keys of database and returns them. All partitions
+// are locked while commit is called, so flush is ordered with writes
+func (e *engine) Flush(commitFn CommitFunc) ([]string, error) {
+	for _, part := range e.parts {
+		part.mutex.Lock()
+		defer part.mutex.Unlock()
+	}
+
+	if err := commit(commitFn); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, part := range e.parts {
+		keys = append(keys, part.flush()...)
+	}
+
+	return keys, nil
+}
+
+// Select returns engine of logical database db. Databases share
+// memory limit, keys are evicted from any of them
+func (e *engine) Select(db int) Engine {
+	return e.dbs.get(db, e.limit)
+}
+
+// Databases returns sorted numbers of used logical databases
+func (e *engine) Databases() []int {
+	e.dbs.mutex.RLock()
+	defer e.dbs.mutex.RUnlock()
+
+	return slices.Sorted(maps.Keys(e.dbs.engines))
+}
+
+func (d *databases) get(db int, limit *memoryLimit) *engine {
+	d.mutex.RLock()
+	e, ok := d.engines[db]
+	d.mutex.RUnlock()
+	if ok {
+		return e
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if e, ok := d.engines[db]; ok {
+		return e
+	}
+
+	e = &engine{
+		db:    db,
+		parts: newParts(d.partsNumber),
+		limit: limit,
+		dbs:   d,
+	}
+	e.setQuotas(d.quotas)
+	d.engines[db] = e
+
+	return e
+}
+
+// all returns engines of all logical databases
+func (d *databases) all() []*engine {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return slices.Collect(maps.Values(d.engines))
+}
+
+func (e *engine) partition(key string) *HashTable {
+	return e.parts[getHash(key, len(e.parts))]
 }
 
 func getHash(key string, partsCount int) int {
@@ -1,16 +1,24 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
 )
 
 func TestGetEngine(t *testing.T) {
 	t.Parallel()
 
 	engine := NewEngine(4)
-	engine.Set("key1", "a")
+	engine.Set("key1", "a", nil)
 
 	tests := map[string]struct {
 		key           string
@@ -55,7 +63,7 @@ func TestSetEngine(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			engine.Set(test.key, test.value)
+			engine.Set(test.key, test.value, nil)
 			value, _ := engine.Get(test.key)
 			assert.Equal(t, value, test.value)
 		})
@@ -66,8 +74,8 @@ func TestDeleteEngine(t *testing.T) {
 	t.Parallel()
 
 	engine := NewEngine(4)
-	engine.Set("key1", "a")
-	engine.Set("key2", "a")
+	engine.Set("key1", "a", nil)
+	engine.Set("key2", "a", nil)
 
 	tests := map[string]struct {
 		key   string
@@ -83,9 +91,145 @@ func TestDeleteEngine(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			engine.Delete(test.key)
+			engine.Delete(test.key, nil)
 			value, _ := engine.Get(test.key)
 			assert.Equal(t, value, "")
 		})
 	}
 }
+
+func TestEngineDump(t *testing.T) {
+	logger.MockLogger()
+
+	source := NewEngine(4)
+	source.Set("string", "value", nil)
+	_, err := source.HSet("hash", []string{"f1", "v1", "f2", "v2"}, nil)
+	require.NoError(t, err)
+	_, err = source.RPush("list", []string{"a", "b", "c"}, nil)
+	require.NoError(t, err)
+	_, err = source.SAdd("set", []string{"m1", "m2"}, nil)
+	require.NoError(t, err)
+
+	stor, err := New(NewEngine(8), nil, "", nil, nil)
+	require.NoError(t, err)
+
+	// dump recreates the same keys in other engine
+	source.Dump(func(command string, args []string) {
+		stor.Restore([]wal.Request{{Command: command, Args: args}})
+	})
+
+	value, found := stor.Get("string")
+	require.True(t, found)
+	require.Equal(t, "value", value)
+
+	hash, err := stor.HGetAll("hash")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, hash)
+
+	list, err := stor.LRange("list", 0, -1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, list)
+
+	members, err := stor.SMembers("set")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"m1", "m2"}, members)
+
+	require.Len(t, stor.Snapshot(), 4)
+}
+
+func TestEngineDatabases(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	engine := NewEngine(4)
+	engine.Set("key", "0", nil)
+
+	db1 := engine.Select(1)
+	require.Same(t, db1, engine.Select(1))
+	require.Same(t, engine, db1.Select(0))
+
+	_, found := db1.Get("key")
+	require.False(t, found)
+
+	db1.Set("key", "1", nil)
+	_, err := db1.SAdd("set", []string{"a"}, nil)
+	require.NoError(t, err)
+
+	value, _ := engine.Get("key")
+	require.Equal(t, "0", value)
+	require.Equal(t, []int{0, 1}, engine.Databases())
+
+	keys, err := db1.Flush(func() error { return fmt.Errorf("commit error") })
+	require.Error(t, err)
+	require.Empty(t, keys)
+	_, found = db1.Get("key")
+	require.True(t, found, "database isn't flushed if commit failed")
+
+	keys, err = db1.Flush(nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"key", "set"}, keys)
+
+	_, found = db1.Get("key")
+	require.False(t, found)
+	members, err := db1.SMembers("set")
+	require.NoError(t, err)
+	require.Empty(t, members)
+
+	value, _ = engine.Get("key")
+	require.Equal(t, "0", value)
+}
+
+func TestStorageDatabases(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	stor, err := New(NewEngine(4), nil, "master", nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, stor.Set(context.Background(), "key", "0"))
+	require.NoError(t, stor.Select(2).Set(context.Background(), "key", "2"))
+	_, err = stor.Select(2).RPush(context.Background(), "list", []string{"a", "b"})
+	require.NoError(t, err)
+
+	snapshot := stor.Snapshot()
+	require.ElementsMatch(t, []wal.Request{
+		{Command: compute.CommandSet, Args: []string{"key", "0"}},
+		{Command: compute.CommandSet, Args: []string{"key", "2"}, DB: 2},
+		{Command: compute.CommandRPush, Args: []string{"list", "a", "b"}, DB: 2},
+	}, snapshot)
+
+	// requests are restored into their databases
+	restored, err := New(NewEngine(4), nil, "", nil, nil)
+	require.NoError(t, err)
+	restored.Restore(snapshot)
+	require.ElementsMatch(t, snapshot, restored.Snapshot())
+
+	restored.Restore([]wal.Request{{Command: compute.CommandFlushDB, DB: 2}})
+	_, found := restored.Select(2).Get("key")
+	require.False(t, found)
+	value, found := restored.Get("key")
+	require.True(t, found)
+	require.Equal(t, "0", value)
+
+	// watchers of flushed keys are woken up
+	watched := make(chan bool)
+	go func() {
+		_, found, _ := stor.Select(2).Watch(context.Background(), "key")
+		watched <- found
+	}()
+	require.Eventually(t, func() bool {
+		view := stor.Select(2).(*storage)
+		view.watchers.mutex.Lock()
+		defer view.watchers.mutex.Unlock()
+		return len(view.watchers.waiters) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, stor.Select(2).FlushDB(context.Background()))
+	require.False(t, <-watched)
+
+	value, found = stor.Get("key")
+	require.True(t, found)
+	require.Equal(t, "0", value)
+}
@@ -1,28 +1,69 @@
 package storage
 
 import (
+	"errors"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
+
+	"concurrency_go_course/internal/compute"
 )
 
+// UpdateFunc returns new value for key based on current value.
+// Value is not changed if error is returned
+type UpdateFunc func(value string, found bool) (string, error)
+
+// CommitFunc is called under the table lock after operation was validated
+// and before it is applied. Operation is not applied if error is returned
+type CommitFunc func() error
+
+// ErrWrongType is returned by operation against key holding value of another type
+var ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
 // HashTable is a struct for hash table
 type HashTable struct {
 	mutex sync.RWMutex
 	data  map[string]string
+
+	hashes map[string]map[string]string
+	lists  map[string][]string
+	sets   map[string]map[string]struct{}
+
+	meta   map[string]*keyMeta
+	memory atomic.Int64
+	quotas []*quotaCounter
 }
 
 // NewHashTable returns new hash table
 func NewHashTable() *HashTable {
+	return newHashTable(0)
+}
+
+func newHashTable(size int) *HashTable {
 	return &HashTable{
-		data: make(map[string]string),
+		data:   make(map[string]string, size),
+		hashes: make(map[string]map[string]string),
+		lists:  make(map[string][]string),
+		sets:   make(map[string]map[string]struct{}),
+		meta:   make(map[string]*keyMeta, size),
 	}
 }
 
-// Set sets new key-value
-func (s *HashTable) Set(key, value string) {
+// Set sets new key-value. Value isn't set if commit returns error
+func (s *HashTable) Set(key, value string, commitFn CommitFunc) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if err := commit(commitFn); err != nil {
+		return err
+	}
+
+	s.delete(key)
 	s.data[key] = value
+	s.grow(key, len(value))
+
+	return nil
 }
 
 // Get returns value for key
@@ -31,13 +72,171 @@ func (s *HashTable) Get(key string) (string, bool) {
 	defer s.mutex.RUnlock()
 
 	value, found := s.data[key]
+	if found {
+		s.touch(key)
+	}
+
 	return value, found
 }
 
-// Del deletes key
-func (s *HashTable) Del(key string) {
+// GetString returns string value for key. Error is returned if key
+// holds value of another type
+func (s *HashTable) GetString(key string) (string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.checkType(key, typeString); err != nil {
+		return "", false, err
+	}
+
+	value, found := s.data[key]
+	if found {
+		s.touch(key)
+	}
+
+	return value, found, nil
+}
+
+// Del deletes key. Key isn't deleted if commit returns error
+func (s *HashTable) Del(key string, commitFn CommitFunc) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := commit(commitFn); err != nil {
+		return err
+	}
+
+	s.delete(key)
+
+	return nil
+}
+
+// DumpFunc receives command recreating key
+type DumpFunc func(command string, args []string)
+
+// Dump calls fn with commands recreating every key of table.
+// Table is locked while it is dumped, so its dump is consistent
+func (s *HashTable) Dump(fn DumpFunc) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for key, value := range s.data {
+		fn(compute.CommandSet, []string{key, value})
+	}
+
+	for key, hash := range s.hashes {
+		args := make([]string, 0, 1+2*len(hash))
+		args = append(args, key)
+		for field, value := range hash {
+			args = append(args, field, value)
+		}
+		fn(compute.CommandHSet, args)
+	}
+
+	for key, list := range s.lists {
+		fn(compute.CommandRPush, append([]string{key}, list...))
+	}
+
+	for key, set := range s.sets {
+		args := make([]string, 0, 1+len(set))
+		args = append(args, key)
+		for member := range set {
+			args = append(args, member)
+		}
+		fn(compute.CommandSAdd, args)
+	}
+}
+
+// Update atomically replaces value for key with the result of update func.
+// Update func is called under the table lock
+func (s *HashTable) Update(key string, update UpdateFunc) (string, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if err := s.checkType(key, typeString); err != nil {
+		return "", err
+	}
+
+	value, found := s.data[key]
+	newValue, err := update(value, found)
+	if err != nil {
+		return "", err
+	}
+
+	s.data[key] = newValue
+	s.grow(key, len(newValue)-len(value))
+
+	return newValue, nil
+}
+
+const (
+	typeNone = iota
+	typeString
+	typeHash
+	typeList
+	typeSet
+)
+
+func (s *HashTable) keyType(key string) int {
+	if _, ok := s.data[key]; ok {
+		return typeString
+	}
+	if _, ok := s.hashes[key]; ok {
+		return typeHash
+	}
+	if _, ok := s.lists[key]; ok {
+		return typeList
+	}
+	if _, ok := s.sets[key]; ok {
+		return typeSet
+	}
+
+	return typeNone
+}
+
+// checkType returns error if key exists and holds value of another type
+func (s *HashTable) checkType(key string, expected int) error {
+	if t := s.keyType(key); t != typeNone && t != expected {
+		return ErrWrongType
+	}
+
+	return nil
+}
+
+func (s *HashTable) delete(key string) {
+	if meta, ok := s.meta[key]; ok {
+		s.memory.Add(-meta.size)
+		s.account(key, -1, -meta.size)
+		delete(s.meta, key)
+	}
+
 	delete(s.data, key)
+	delete(s.hashes, key)
+	delete(s.lists, key)
+	delete(s.sets, key)
+}
+
+// flush deletes all keys and returns them. It is called under the table lock
+func (s *HashTable) flush() []string {
+	keys := slices.Collect(maps.Keys(s.meta))
+	for key, meta := range s.meta {
+		s.account(key, -1, -meta.size)
+	}
+
+	s.data = make(map[string]string, defaultKeyCount)
+	s.hashes = make(map[string]map[string]string)
+	s.lists = make(map[string][]string)
+	s.sets = make(map[string]map[string]struct{})
+	s.meta = make(map[string]*keyMeta, defaultKeyCount)
+	s.memory.Store(0)
+
+	return keys
+}
+
+func commit(fn CommitFunc) error {
+	if fn == nil {
+		return nil
+	}
+
+	return fn()
 }
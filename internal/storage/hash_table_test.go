@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -10,7 +11,7 @@ func TestHashTable_Get(t *testing.T) {
 	t.Parallel()
 	t.Run("return existing value for key", func(t *testing.T) {
 		table := NewHashTable()
-		table.Set("key1", "value1")
+		table.Set("key1", "value1", nil)
 		value, found := table.Get("key1")
 		require.True(t, found)
 		require.Equal(t, "value1", value)
@@ -29,8 +30,8 @@ func TestHashTable_Del(t *testing.T) {
 
 	t.Run("Deletion of key-value pair", func(t *testing.T) {
 		table := NewHashTable()
-		table.Set("key1", "value1")
-		table.Del("key1")
+		table.Set("key1", "value1", nil)
+		table.Del("key1", nil)
 		value, found := table.Get("key1")
 		require.False(t, found)
 		require.Empty(t, value)
@@ -38,7 +39,7 @@ func TestHashTable_Del(t *testing.T) {
 
 	t.Run("Deletion of not existing key (no error)", func(t *testing.T) {
 		table := NewHashTable()
-		table.Del("key1")
+		table.Del("key1", nil)
 		value, found := table.Get("key1")
 		require.False(t, found)
 		require.Empty(t, value)
@@ -49,7 +50,7 @@ func TestMemoryTable_Set(t *testing.T) {
 	t.Parallel()
 	t.Run("correct setting key and value", func(t *testing.T) {
 		table := NewHashTable()
-		table.Set("key1", "value1")
+		table.Set("key1", "value1", nil)
 		value, found := table.Get("key1")
 		require.True(t, found)
 		require.Equal(t, "value1", value)
@@ -57,10 +58,60 @@ func TestMemoryTable_Set(t *testing.T) {
 
 	t.Run("correct overwriting existing key-value", func(t *testing.T) {
 		table := NewHashTable()
-		table.Set("key1", "value1")
-		table.Set("key1", "value2")
+		table.Set("key1", "value1", nil)
+		table.Set("key1", "value2", nil)
 		value, found := table.Get("key1")
 		require.True(t, found)
 		require.Equal(t, "value2", value)
 	})
+
+	t.Run("commit under lock, value is not set on error", func(t *testing.T) {
+		table := NewHashTable()
+		table.Set("key1", "value1", nil)
+
+		err := table.Set("key1", "value2", func() error {
+			require.False(t, table.mutex.TryRLock())
+			return errors.New("commit error")
+		})
+		require.Error(t, err)
+		require.Error(t, table.Del("key1", func() error { return errors.New("commit error") }))
+
+		value, found := table.Get("key1")
+		require.True(t, found)
+		require.Equal(t, "value1", value)
+	})
+}
+
+func TestHashTable_Update(t *testing.T) {
+	t.Parallel()
+
+	t.Run("update existing value", func(t *testing.T) {
+		table := NewHashTable()
+		table.Set("key1", "value1", nil)
+
+		value, err := table.Update("key1", func(value string, found bool) (string, error) {
+			require.True(t, found)
+			return value + "!", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "value1!", value)
+
+		value, found := table.Get("key1")
+		require.True(t, found)
+		require.Equal(t, "value1!", value)
+	})
+
+	t.Run("value is not changed on error", func(t *testing.T) {
+		table := NewHashTable()
+		table.Set("key1", "value1", nil)
+
+		_, err := table.Update("key1", func(_ string, _ bool) (string, error) {
+			return "value2", errors.New("update error")
+		})
+		require.Error(t, err)
+
+		value, found := table.Get("key1")
+		require.True(t, found)
+		require.Equal(t, "value1", value)
+	})
 }
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// PolicyNoEviction rejects writes when memory limit is reached
+	PolicyNoEviction = "noeviction"
+	// PolicyAllKeysLRU evicts least recently used keys
+	PolicyAllKeysLRU = "allkeys-lru"
+	// PolicyAllKeysLFU evicts least frequently used keys
+	PolicyAllKeysLFU = "allkeys-lfu"
+	// PolicyVolatileTTL evicts keys with the nearest expiration time.
+	// Keys don't expire in this engine, so there are no candidates for
+	// eviction and policy behaves like noeviction
+	PolicyVolatileTTL = "volatile-ttl"
+)
+
+// Approximate memory used by map entries besides key and value bytes
+const (
+	keyOverhead     = 64
+	elementOverhead = 16
+)
+
+// evictionSamples is a number of keys sampled from each partition
+// to find eviction candidate
+const evictionSamples = 5
+
+var errOutOfMemory = errors.New("command not allowed when used memory > max_memory")
+
+// EvictFunc is called under the table lock before key of logical
+// database db is evicted. Key is not evicted if error is returned.
+// It mustn't wait for WAL commit, otherwise every writer waits
+// for commit of every evicted key
+type EvictFunc func(db int, key string) error
+
+// EvictedKey is a key evicted from logical database
+type EvictedKey struct {
+	DB  int
+	Key string
+}
+
+// Evictor is interface for engine with memory limit. Memory is shared
+// by logical databases, so keys are evicted from any of them
+type Evictor interface {
+	Evict(commit EvictFunc) ([]EvictedKey, error)
+	MemoryStats() MemoryStats
+}
+
+// MemoryStats is a struct for engine memory statistics
+type MemoryStats struct {
+	UsedMemory     int64
+	MaxMemory      int64
+	EvictionPolicy string
+	EvictedKeys    int64
+}
+
+// keyMeta is a struct for memory and access statistics of key
+type keyMeta struct {
+	size       int64
+	lastAccess atomic.Int64
+	hits       atomic.Uint64
+}
+
+// memoryLimit is a struct for engine memory limit
+type memoryLimit struct {
+	maxMemory int64
+	policy    string
+	evicted   atomic.Int64
+	mutex     sync.Mutex
+}
+
+// ValidateEvictionPolicy returns error for unknown policy
+func ValidateEvictionPolicy(policy string) error {
+	switch policy {
+	case PolicyNoEviction, PolicyAllKeysLRU, PolicyAllKeysLFU, PolicyVolatileTTL:
+		return nil
+	}
+
+	return fmt.Errorf("unknown eviction policy %s", policy)
+}
+
+// touch updates access statistics of key. Can be called under read lock
+func (s *HashTable) touch(key string) {
+	if meta, ok := s.meta[key]; ok {
+		meta.lastAccess.Store(time.Now().UnixNano())
+		meta.hits.Add(1)
+	}
+}
+
+// grow changes memory size of key by delta
+func (s *HashTable) grow(key string, delta int) {
+	meta, ok := s.meta[key]
+	if !ok {
+		meta = &keyMeta{size: int64(len(key) + keyOverhead)}
+		s.meta[key] = meta
+		s.memory.Add(meta.size)
+		s.account(key, 1, meta.size)
+	}
+
+	meta.size += int64(delta)
+	s.memory.Add(int64(delta))
+	s.account(key, 0, int64(delta))
+	s.touch(key)
+}
+
+// Memory returns approximate memory used by table
+func (s *HashTable) Memory() int64 {
+	return s.memory.Load()
+}
+
+// candidate returns sampled key which is the best for eviction with policy
+func (s *HashTable) candidate(policy string) (string, uint64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var (
+		best      string
+		bestScore uint64
+		found     bool
+		sampled   int
+	)
+
+	for key, meta := range s.meta {
+		var score uint64
+		switch policy {
+		case PolicyAllKeysLRU:
+			score = uint64(meta.lastAccess.Load())
+		case PolicyAllKeysLFU:
+			score = meta.hits.Load()
+		default:
+			return "", 0, false
+		}
+
+		if !found || score < bestScore {
+			best, bestScore, found = key, score, true
+		}
+
+		sampled++
+		if sampled == evictionSamples {
+			break
+		}
+	}
+
+	return best, bestScore, found
+}
+
+// evict deletes key of database db after commit
+func (s *HashTable) evict(db int, key string, commitFn EvictFunc) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.meta[key]; !ok {
+		return false, nil
+	}
+
+	if commitFn != nil {
+		if err := commitFn(db, key); err != nil {
+			return false, err
+		}
+	}
+
+	s.delete(key)
+
+	return true, nil
+}
+
+// Evict evicts keys of all databases according to policy until memory
+// is under limit and returns evicted keys
+func (e *engine) Evict(commit EvictFunc) ([]EvictedKey, error) {
+	if e.limit == nil || e.limit.maxMemory == 0 {
+		return nil, nil
+	}
+
+	e.limit.mutex.Lock()
+	defer e.limit.mutex.Unlock()
+
+	var evicted []EvictedKey
+	for e.memory() > e.limit.maxMemory {
+		key, db, part, ok := e.candidate()
+		if !ok {
+			return evicted, errOutOfMemory
+		}
+
+		deleted, err := part.evict(db, key, commit)
+		if err != nil {
+			return evicted, err
+		}
+
+		if deleted {
+			evicted = append(evicted, EvictedKey{DB: db, Key: key})
+			e.limit.evicted.Add(1)
+		}
+	}
+
+	return evicted, nil
+}
+
+// MemoryStats returns memory statistics of all databases
+func (e *engine) MemoryStats() MemoryStats {
+	stats := MemoryStats{
+		UsedMemory:     e.memory(),
+		EvictionPolicy: PolicyNoEviction,
+	}
+
+	if e.limit != nil {
+		stats.MaxMemory = e.limit.maxMemory
+		stats.EvictionPolicy = e.limit.policy
+		stats.EvictedKeys = e.limit.evicted.Load()
+	}
+
+	return stats
+}
+
+func (e *engine) memory() int64 {
+	var total int64
+	for _, db := range e.dbs.all() {
+		for _, part := range db.parts {
+			total += part.Memory()
+		}
+	}
+
+	return total
+}
+
+func (e *engine) candidate() (string, int, *HashTable, bool) {
+	var (
+		best      string
+		bestDB    int
+		bestPart  *HashTable
+		bestScore uint64
+		found     bool
+	)
+
+	for _, db := range e.dbs.all() {
+		for _, part := range db.parts {
+			key, score, ok := part.candidate(e.limit.policy)
+			if ok && (!found || score < bestScore) {
+				best, bestDB, bestPart, bestScore, found = key, db.db, part, score, true
+			}
+		}
+	}
+
+	return best, bestDB, bestPart, found
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestHashTable_Memory(t *testing.T) {
+	t.Parallel()
+
+	table := NewHashTable()
+
+	table.Set("key1", "value1", nil)
+	require.Equal(t, int64(len("key1")+len("value1")+keyOverhead), table.Memory())
+
+	table.Set("key1", "v", nil)
+	require.Equal(t, int64(len("key1")+len("v")+keyOverhead), table.Memory())
+
+	_, err := table.HSet("hash", []string{"f1", "v1", "f2", "v2"}, nil)
+	require.NoError(t, err)
+	_, err = table.RPush("list", []string{"a", "b"}, nil)
+	require.NoError(t, err)
+	_, err = table.SAdd("set", []string{"a", "b"}, nil)
+	require.NoError(t, err)
+
+	_, err = table.HDel("hash", []string{"f1", "f2"}, nil)
+	require.NoError(t, err)
+	_, _, err = table.LPop("list", nil)
+	require.NoError(t, err)
+	_, _, err = table.LPop("list", nil)
+	require.NoError(t, err)
+	_, err = table.SRem("set", []string{"a", "b"}, nil)
+	require.NoError(t, err)
+
+	table.Del("key1", nil)
+	require.Equal(t, int64(0), table.Memory())
+}
+
+func TestEngine_Evict(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	entrySize := int64(len("key1") + len("value1") + keyOverhead)
+
+	t.Run("noeviction rejects writes", func(t *testing.T) {
+		engine, err := NewEngineWithLimit(1, entrySize, PolicyNoEviction)
+		require.NoError(t, err)
+
+		stor, err := New(engine, nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		require.NoError(t, stor.Set(context.Background(), "key2", "value2"))
+		require.ErrorIs(t, stor.Set(context.Background(), "key3", "value3"), errOutOfMemory)
+	})
+
+	t.Run("volatile-ttl has no candidates", func(t *testing.T) {
+		engine, err := NewEngineWithLimit(1, entrySize, PolicyVolatileTTL)
+		require.NoError(t, err)
+
+		stor, err := New(engine, nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		require.NoError(t, stor.Set(context.Background(), "key2", "value2"))
+		require.ErrorIs(t, stor.Set(context.Background(), "key3", "value3"), errOutOfMemory)
+	})
+
+	t.Run("allkeys-lru evicts least recently used key", func(t *testing.T) {
+		engine, err := NewEngineWithLimit(1, 2*entrySize, PolicyAllKeysLRU)
+		require.NoError(t, err)
+
+		stor, err := New(engine, nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, stor.Set(context.Background(), "key2", "value2"))
+		time.Sleep(time.Millisecond)
+		_, _ = stor.Get("key1")
+
+		require.NoError(t, stor.Set(context.Background(), "key3", "value3"))
+		require.NoError(t, stor.Set(context.Background(), "key4", "value4"))
+
+		_, found := stor.Get("key2")
+		require.False(t, found)
+		_, found = stor.Get("key1")
+		require.True(t, found)
+
+		stats := stor.MemoryStats()
+		require.Equal(t, int64(1), stats.EvictedKeys)
+		require.Equal(t, PolicyAllKeysLRU, stats.EvictionPolicy)
+		require.Equal(t, 3*entrySize, stats.UsedMemory)
+	})
+
+	t.Run("allkeys-lfu evicts least frequently used key", func(t *testing.T) {
+		engine, err := NewEngineWithLimit(1, 2*entrySize, PolicyAllKeysLFU)
+		require.NoError(t, err)
+
+		stor, err := New(engine, nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		require.NoError(t, stor.Set(context.Background(), "key2", "value2"))
+		for range 3 {
+			_, _ = stor.Get("key2")
+		}
+		_, _ = stor.Get("key1")
+
+		require.NoError(t, stor.Set(context.Background(), "key3", "value3"))
+		_, _ = stor.Get("key3")
+		_, _ = stor.Get("key3")
+		require.NoError(t, stor.Set(context.Background(), "key4", "value4"))
+
+		_, found := stor.Get("key1")
+		require.False(t, found)
+		_, found = stor.Get("key2")
+		require.True(t, found)
+	})
+
+	t.Run("keys of all databases are evicted", func(t *testing.T) {
+		engine, err := NewEngineWithLimit(1, 2*entrySize, PolicyAllKeysLRU)
+		require.NoError(t, err)
+
+		stor, err := New(engine, nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, stor.Select(1).Set(context.Background(), "key2", "value2"))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, stor.Select(1).Set(context.Background(), "key3", "value3"))
+		require.NoError(t, stor.Select(1).Set(context.Background(), "key4", "value4"))
+
+		_, found := stor.Get("key1")
+		require.False(t, found)
+		_, found = stor.Select(1).Get("key2")
+		require.True(t, found)
+		require.Equal(t, 3*entrySize, stor.MemoryStats().UsedMemory)
+	})
+
+	t.Run("evicted keys are written to WAL by one group", func(t *testing.T) {
+		dir := t.TempDir()
+		walCfg := &config.WALCfg{WalConfig: &config.WALSettings{
+			DataDirectory:        dir,
+			FlushingBatchTimeout: "5ms",
+		}}
+
+		walObj, err := wal.New(walCfg)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		walObj.Start(ctx)
+
+		engine, err := NewEngineWithLimit(1, 2*entrySize, PolicyAllKeysLRU)
+		require.NoError(t, err)
+		stor, err := New(engine, walObj, "master", nil, nil)
+		require.NoError(t, err)
+
+		// the big key takes memory of two keys, so both small keys
+		// are evicted by the next write
+		big := strings.Repeat("v", int(2*entrySize)-len("key3")-keyOverhead)
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, stor.Set(context.Background(), "key2", "value2"))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, stor.Set(context.Background(), "key3", big))
+		require.NoError(t, stor.Set(context.Background(), "key4", "value4"))
+		require.Equal(t, int64(2), stor.MemoryStats().EvictedKeys)
+
+		cancel()
+		<-walObj.Done()
+
+		walObj, err = wal.New(walCfg)
+		require.NoError(t, err)
+		requests, err := walObj.Recover()
+		require.NoError(t, err)
+
+		var deletes []wal.Request
+		for _, request := range requests {
+			if request.Command == compute.CommandDelete {
+				deletes = append(deletes, request)
+			}
+		}
+		require.Len(t, deletes, 2)
+		require.Equal(t, deletes[0].Timestamp, deletes[1].Timestamp)
+
+		restored, err := New(NewEngine(1), walObj, "master", nil, nil)
+		require.NoError(t, err)
+		_, found := restored.Get("key1")
+		require.False(t, found)
+		_, found = restored.Get("key2")
+		require.False(t, found)
+		value, _ := restored.Get("key4")
+		require.Equal(t, "value4", value)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		_, err := NewEngineWithLimit(1, entrySize, "random")
+		require.Error(t, err)
+	})
+}
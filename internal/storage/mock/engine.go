@@ -5,6 +5,7 @@
 package mock
 
 import (
+	storage "concurrency_go_course/internal/storage"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -33,16 +34,59 @@ func (m *MockEngine) EXPECT() *MockEngineMockRecorder {
 	return m.recorder
 }
 
+// Databases mocks base method.
+func (m *MockEngine) Databases() []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Databases")
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// Databases indicates an expected call of Databases.
+func (mr *MockEngineMockRecorder) Databases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Databases", reflect.TypeOf((*MockEngine)(nil).Databases))
+}
+
 // Delete mocks base method.
-func (m *MockEngine) Delete(key string) {
+func (m *MockEngine) Delete(key string, commit storage.CommitFunc) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Delete", key)
+	ret := m.ctrl.Call(m, "Delete", key, commit)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockEngineMockRecorder) Delete(key interface{}) *gomock.Call {
+func (mr *MockEngineMockRecorder) Delete(key, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEngine)(nil).Delete), key, commit)
+}
+
+// Dump mocks base method.
+func (m *MockEngine) Dump(fn storage.DumpFunc) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Dump", fn)
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockEngineMockRecorder) Dump(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockEngine)(nil).Dump), fn)
+}
+
+// Flush mocks base method.
+func (m *MockEngine) Flush(commit storage.CommitFunc) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush", commit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockEngineMockRecorder) Flush(commit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEngine)(nil).Delete), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockEngine)(nil).Flush), commit)
 }
 
 // Get mocks base method.
@@ -60,14 +104,228 @@ func (mr *MockEngineMockRecorder) Get(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockEngine)(nil).Get), key)
 }
 
+// GetString mocks base method.
+func (m *MockEngine) GetString(key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetString", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetString indicates an expected call of GetString.
+func (mr *MockEngineMockRecorder) GetString(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetString", reflect.TypeOf((*MockEngine)(nil).GetString), key)
+}
+
+// HDel mocks base method.
+func (m *MockEngine) HDel(key string, fields []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HDel", key, fields, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HDel indicates an expected call of HDel.
+func (mr *MockEngineMockRecorder) HDel(key, fields, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDel", reflect.TypeOf((*MockEngine)(nil).HDel), key, fields, commit)
+}
+
+// HGet mocks base method.
+func (m *MockEngine) HGet(key, field string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGet", key, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HGet indicates an expected call of HGet.
+func (mr *MockEngineMockRecorder) HGet(key, field interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGet", reflect.TypeOf((*MockEngine)(nil).HGet), key, field)
+}
+
+// HGetAll mocks base method.
+func (m *MockEngine) HGetAll(key string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", key)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll.
+func (mr *MockEngineMockRecorder) HGetAll(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockEngine)(nil).HGetAll), key)
+}
+
+// HSet mocks base method.
+func (m *MockEngine) HSet(key string, pairs []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSet", key, pairs, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HSet indicates an expected call of HSet.
+func (mr *MockEngineMockRecorder) HSet(key, pairs, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSet", reflect.TypeOf((*MockEngine)(nil).HSet), key, pairs, commit)
+}
+
+// LPop mocks base method.
+func (m *MockEngine) LPop(key string, commit storage.CommitFunc) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPop", key, commit)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LPop indicates an expected call of LPop.
+func (mr *MockEngineMockRecorder) LPop(key, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPop", reflect.TypeOf((*MockEngine)(nil).LPop), key, commit)
+}
+
+// LPush mocks base method.
+func (m *MockEngine) LPush(key string, values []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPush", key, values, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LPush indicates an expected call of LPush.
+func (mr *MockEngineMockRecorder) LPush(key, values, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPush", reflect.TypeOf((*MockEngine)(nil).LPush), key, values, commit)
+}
+
+// LRange mocks base method.
+func (m *MockEngine) LRange(key string, start, stop int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LRange", key, start, stop)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LRange indicates an expected call of LRange.
+func (mr *MockEngineMockRecorder) LRange(key, start, stop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LRange", reflect.TypeOf((*MockEngine)(nil).LRange), key, start, stop)
+}
+
+// RPush mocks base method.
+func (m *MockEngine) RPush(key string, values []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RPush", key, values, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RPush indicates an expected call of RPush.
+func (mr *MockEngineMockRecorder) RPush(key, values, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPush", reflect.TypeOf((*MockEngine)(nil).RPush), key, values, commit)
+}
+
+// SAdd mocks base method.
+func (m *MockEngine) SAdd(key string, members []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SAdd", key, members, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SAdd indicates an expected call of SAdd.
+func (mr *MockEngineMockRecorder) SAdd(key, members, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockEngine)(nil).SAdd), key, members, commit)
+}
+
+// SMembers mocks base method.
+func (m *MockEngine) SMembers(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers.
+func (mr *MockEngineMockRecorder) SMembers(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockEngine)(nil).SMembers), key)
+}
+
+// SRem mocks base method.
+func (m *MockEngine) SRem(key string, members []string, commit storage.CommitFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SRem", key, members, commit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SRem indicates an expected call of SRem.
+func (mr *MockEngineMockRecorder) SRem(key, members, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRem", reflect.TypeOf((*MockEngine)(nil).SRem), key, members, commit)
+}
+
+// Select mocks base method.
+func (m *MockEngine) Select(db int) storage.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Select", db)
+	ret0, _ := ret[0].(storage.Engine)
+	return ret0
+}
+
+// Select indicates an expected call of Select.
+func (mr *MockEngineMockRecorder) Select(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockEngine)(nil).Select), db)
+}
+
 // Set mocks base method.
-func (m *MockEngine) Set(key, value string) {
+func (m *MockEngine) Set(key, value string, commit storage.CommitFunc) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Set", key, value)
+	ret := m.ctrl.Call(m, "Set", key, value, commit)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockEngineMockRecorder) Set(key, value interface{}) *gomock.Call {
+func (mr *MockEngineMockRecorder) Set(key, value, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockEngine)(nil).Set), key, value, commit)
+}
+
+// Update mocks base method.
+func (m *MockEngine) Update(key string, update storage.UpdateFunc) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", key, update)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockEngineMockRecorder) Update(key, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockEngine)(nil).Set), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockEngine)(nil).Update), key, update)
 }
@@ -5,7 +5,10 @@
 package mock
 
 import (
+	slowlog "concurrency_go_course/internal/slowlog"
+	storage "concurrency_go_course/internal/storage"
 	wal "concurrency_go_course/internal/storage/wal"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,18 +37,61 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// Append mocks base method.
+func (m *MockStorage) Append(ctx context.Context, key, value string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, key, value)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockStorageMockRecorder) Append(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockStorage)(nil).Append), ctx, key, value)
+}
+
 // Del mocks base method.
-func (m *MockStorage) Del(key string) error {
+func (m *MockStorage) Del(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Del", key)
+	ret := m.ctrl.Call(m, "Del", ctx, key)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Del indicates an expected call of Del.
-func (mr *MockStorageMockRecorder) Del(key interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) Del(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockStorage)(nil).Del), ctx, key)
+}
+
+// Dump mocks base method.
+func (m *MockStorage) Dump() []wal.Request {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dump")
+	ret0, _ := ret[0].([]wal.Request)
+	return ret0
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockStorageMockRecorder) Dump() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockStorage)(nil).Del), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockStorage)(nil).Dump))
+}
+
+// FlushDB mocks base method.
+func (m *MockStorage) FlushDB(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDB", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDB indicates an expected call of FlushDB.
+func (mr *MockStorageMockRecorder) FlushDB(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDB", reflect.TypeOf((*MockStorage)(nil).FlushDB), ctx)
 }
 
 // Get mocks base method.
@@ -63,6 +109,203 @@ func (mr *MockStorageMockRecorder) Get(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorage)(nil).Get), key)
 }
 
+// GetSet mocks base method.
+func (m *MockStorage) GetSet(ctx context.Context, key, value string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSet", ctx, key, value)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSet indicates an expected call of GetSet.
+func (mr *MockStorageMockRecorder) GetSet(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSet", reflect.TypeOf((*MockStorage)(nil).GetSet), ctx, key, value)
+}
+
+// GetString mocks base method.
+func (m *MockStorage) GetString(key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetString", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetString indicates an expected call of GetString.
+func (mr *MockStorageMockRecorder) GetString(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetString", reflect.TypeOf((*MockStorage)(nil).GetString), key)
+}
+
+// HDel mocks base method.
+func (m *MockStorage) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HDel", ctx, key, fields)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HDel indicates an expected call of HDel.
+func (mr *MockStorageMockRecorder) HDel(ctx, key, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDel", reflect.TypeOf((*MockStorage)(nil).HDel), ctx, key, fields)
+}
+
+// HGet mocks base method.
+func (m *MockStorage) HGet(key, field string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGet", key, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HGet indicates an expected call of HGet.
+func (mr *MockStorageMockRecorder) HGet(key, field interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGet", reflect.TypeOf((*MockStorage)(nil).HGet), key, field)
+}
+
+// HGetAll mocks base method.
+func (m *MockStorage) HGetAll(key string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", key)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll.
+func (mr *MockStorageMockRecorder) HGetAll(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockStorage)(nil).HGetAll), key)
+}
+
+// HSet mocks base method.
+func (m *MockStorage) HSet(ctx context.Context, key string, pairs []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSet", ctx, key, pairs)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HSet indicates an expected call of HSet.
+func (mr *MockStorageMockRecorder) HSet(ctx, key, pairs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSet", reflect.TypeOf((*MockStorage)(nil).HSet), ctx, key, pairs)
+}
+
+// Import mocks base method.
+func (m *MockStorage) Import(requests []wal.Request) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", requests)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockStorageMockRecorder) Import(requests interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockStorage)(nil).Import), requests)
+}
+
+// Incr mocks base method.
+func (m *MockStorage) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, delta)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockStorageMockRecorder) Incr(ctx, key, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockStorage)(nil).Incr), ctx, key, delta)
+}
+
+// LPop mocks base method.
+func (m *MockStorage) LPop(ctx context.Context, key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPop", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LPop indicates an expected call of LPop.
+func (mr *MockStorageMockRecorder) LPop(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPop", reflect.TypeOf((*MockStorage)(nil).LPop), ctx, key)
+}
+
+// LPush mocks base method.
+func (m *MockStorage) LPush(ctx context.Context, key string, values []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPush", ctx, key, values)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LPush indicates an expected call of LPush.
+func (mr *MockStorageMockRecorder) LPush(ctx, key, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPush", reflect.TypeOf((*MockStorage)(nil).LPush), ctx, key, values)
+}
+
+// LRange mocks base method.
+func (m *MockStorage) LRange(key string, start, stop int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LRange", key, start, stop)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LRange indicates an expected call of LRange.
+func (mr *MockStorageMockRecorder) LRange(key, start, stop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LRange", reflect.TypeOf((*MockStorage)(nil).LRange), key, start, stop)
+}
+
+// MemoryStats mocks base method.
+func (m *MockStorage) MemoryStats() storage.MemoryStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MemoryStats")
+	ret0, _ := ret[0].(storage.MemoryStats)
+	return ret0
+}
+
+// MemoryStats indicates an expected call of MemoryStats.
+func (mr *MockStorageMockRecorder) MemoryStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemoryStats", reflect.TypeOf((*MockStorage)(nil).MemoryStats))
+}
+
+// RPush mocks base method.
+func (m *MockStorage) RPush(ctx context.Context, key string, values []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RPush", ctx, key, values)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RPush indicates an expected call of RPush.
+func (mr *MockStorageMockRecorder) RPush(ctx, key, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPush", reflect.TypeOf((*MockStorage)(nil).RPush), ctx, key, values)
+}
+
 // Restore mocks base method.
 func (m *MockStorage) Restore(requests []wal.Request) {
 	m.ctrl.T.Helper()
@@ -75,18 +318,171 @@ func (mr *MockStorageMockRecorder) Restore(requests interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStorage)(nil).Restore), requests)
 }
 
+// SAdd mocks base method.
+func (m *MockStorage) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SAdd", ctx, key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SAdd indicates an expected call of SAdd.
+func (mr *MockStorageMockRecorder) SAdd(ctx, key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockStorage)(nil).SAdd), ctx, key, members)
+}
+
+// SMembers mocks base method.
+func (m *MockStorage) SMembers(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers.
+func (mr *MockStorageMockRecorder) SMembers(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockStorage)(nil).SMembers), key)
+}
+
+// SRem mocks base method.
+func (m *MockStorage) SRem(ctx context.Context, key string, members []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SRem", ctx, key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SRem indicates an expected call of SRem.
+func (mr *MockStorageMockRecorder) SRem(ctx, key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRem", reflect.TypeOf((*MockStorage)(nil).SRem), ctx, key, members)
+}
+
+// Select mocks base method.
+func (m *MockStorage) Select(db int) storage.Storage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Select", db)
+	ret0, _ := ret[0].(storage.Storage)
+	return ret0
+}
+
+// Select indicates an expected call of Select.
+func (mr *MockStorageMockRecorder) Select(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockStorage)(nil).Select), db)
+}
+
 // Set mocks base method.
-func (m *MockStorage) Set(key, value string) error {
+func (m *MockStorage) Set(ctx context.Context, key, value string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", key, value)
+	ret := m.ctrl.Call(m, "Set", ctx, key, value)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockStorageMockRecorder) Set(key, value interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) Set(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStorage)(nil).Set), ctx, key, value)
+}
+
+// Snapshot mocks base method.
+func (m *MockStorage) Snapshot() []wal.Request {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].([]wal.Request)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockStorageMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockStorage)(nil).Snapshot))
+}
+
+// Subscribe mocks base method.
+func (m *MockStorage) Subscribe(key string) (<-chan struct{}, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", key)
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStorageMockRecorder) Subscribe(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStorage)(nil).Subscribe), key)
+}
+
+// Trace mocks base method.
+func (m *MockStorage) Trace(trace *slowlog.Trace) storage.Storage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trace", trace)
+	ret0, _ := ret[0].(storage.Storage)
+	return ret0
+}
+
+// Trace indicates an expected call of Trace.
+func (mr *MockStorageMockRecorder) Trace(trace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trace", reflect.TypeOf((*MockStorage)(nil).Trace), trace)
+}
+
+// Watch mocks base method.
+func (m *MockStorage) Watch(ctx context.Context, key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockStorageMockRecorder) Watch(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockStorage)(nil).Watch), ctx, key)
+}
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method.
+func (m *MockNotifier) Notify(db int, key, event string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Notify", db, key, event)
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockNotifierMockRecorder) Notify(db, key, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStorage)(nil).Set), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockNotifier)(nil).Notify), db, key, event)
 }
 
 // MockWAL is a mock of WAL interface.
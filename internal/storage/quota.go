@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"concurrency_go_course/internal/limits"
+)
+
+// Quota limits number and memory of keys with prefix in logical
+// database. Empty prefix limits the whole database, zero limit
+// isn't checked
+type Quota struct {
+	DB        int
+	Prefix    string
+	MaxKeys   int64
+	MaxMemory int64
+}
+
+// QuotaUsage is a quota with number and memory of its keys
+type QuotaUsage struct {
+	Quota
+	Keys   int64
+	Memory int64
+}
+
+// QuotaLimiter is interface for engine with quotas
+type QuotaLimiter interface {
+	SetQuotas(quotas []Quota)
+	CheckQuota(key string) error
+	// CheckQuotaLocked is CheckQuota called under partition lock of key,
+	// e.g. by CommitFunc
+	CheckQuotaLocked(key string) error
+	QuotaUsage() []QuotaUsage
+}
+
+// quotaCounter counts keys and memory of quota
+type quotaCounter struct {
+	quota  Quota
+	keys   atomic.Int64
+	memory atomic.Int64
+}
+
+func (c *quotaCounter) matches(key string) bool {
+	return strings.HasPrefix(key, c.quota.Prefix)
+}
+
+// account changes number and memory of keys of quotas matching key.
+// It is called under the table lock
+func (s *HashTable) account(key string, keys, memory int64) {
+	for _, counter := range s.quotas {
+		if counter.matches(key) {
+			counter.keys.Add(keys)
+			counter.memory.Add(memory)
+		}
+	}
+}
+
+// SetQuotas sets quotas of logical databases. It must be called
+// before keys are written, existing keys aren't counted
+func (e *engine) SetQuotas(quotas []Quota) {
+	counters := make([]*quotaCounter, 0, len(quotas))
+	for _, quota := range quotas {
+		counters = append(counters, &quotaCounter{quota: quota})
+	}
+
+	e.dbs.mutex.Lock()
+	defer e.dbs.mutex.Unlock()
+
+	e.dbs.quotas = counters
+	for _, db := range e.dbs.engines {
+		db.setQuotas(counters)
+	}
+}
+
+// setQuotas sets counters of database quotas to partitions
+func (e *engine) setQuotas(counters []*quotaCounter) {
+	var quotas []*quotaCounter
+	for _, counter := range counters {
+		if counter.quota.DB == e.db {
+			quotas = append(quotas, counter)
+		}
+	}
+
+	for _, part := range e.parts {
+		part.mutex.Lock()
+		part.quotas = quotas
+		part.mutex.Unlock()
+	}
+}
+
+// CheckQuota returns ThrottledError if write of key is over quota:
+// memory of quota is exhausted or key is new and quota has no free keys
+func (e *engine) CheckQuota(key string) error {
+	part := e.partition(key)
+
+	part.mutex.RLock()
+	defer part.mutex.RUnlock()
+
+	return part.checkQuota(key)
+}
+
+// CheckQuotaLocked is CheckQuota called under partition lock of key
+func (e *engine) CheckQuotaLocked(key string) error {
+	return e.partition(key).checkQuota(key)
+}
+
+// checkQuota checks quotas of key. It is called under the table lock
+func (s *HashTable) checkQuota(key string) error {
+	for _, counter := range s.quotas {
+		if !counter.matches(key) {
+			continue
+		}
+
+		quota := counter.quota
+		if quota.MaxMemory > 0 && counter.memory.Load() >= quota.MaxMemory {
+			return &limits.ThrottledError{
+				Reason: fmt.Sprintf("memory quota of prefix %q in database %d exceeded", quota.Prefix, quota.DB),
+			}
+		}
+		if quota.MaxKeys > 0 && counter.keys.Load() >= quota.MaxKeys && s.keyType(key) == typeNone {
+			return &limits.ThrottledError{
+				Reason: fmt.Sprintf("keys quota of prefix %q in database %d exceeded", quota.Prefix, quota.DB),
+			}
+		}
+	}
+
+	return nil
+}
+
+// QuotaUsage returns usage of quotas
+func (e *engine) QuotaUsage() []QuotaUsage {
+	e.dbs.mutex.RLock()
+	defer e.dbs.mutex.RUnlock()
+
+	usage := make([]QuotaUsage, 0, len(e.dbs.quotas))
+	for _, counter := range e.dbs.quotas {
+		usage = append(usage, QuotaUsage{
+			Quota:  counter.quota,
+			Keys:   counter.keys.Load(),
+			Memory: counter.memory.Load(),
+		})
+	}
+
+	return usage
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/limits"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestQuotas(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	entrySize := int64(len("a:1") + len("value") + keyOverhead)
+
+	engine := NewEngine(4)
+	engine.(QuotaLimiter).SetQuotas([]Quota{
+		{Prefix: "a:", MaxKeys: 2},
+		{Prefix: "b:", MaxMemory: entrySize + 1},
+		{DB: 1, MaxKeys: 1},
+	})
+
+	stor, err := New(engine, nil, "master", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("keys quota", func(t *testing.T) {
+		require.NoError(t, stor.Set(context.Background(), "a:1", "value"))
+		_, err := stor.SAdd(context.Background(), "a:2", []string{"value"})
+		require.NoError(t, err)
+
+		require.ErrorIs(t, stor.Set(context.Background(), "a:3", "value"), limits.ErrThrottled)
+		require.NoError(t, stor.Set(context.Background(), "a:1", "other"), "existing key can be changed")
+		require.NoError(t, stor.Set(context.Background(), "other", "value"), "keys without prefix aren't limited")
+
+		require.NoError(t, stor.Del(context.Background(), "a:1"))
+		require.NoError(t, stor.Set(context.Background(), "a:3", "value"))
+	})
+
+	t.Run("memory quota", func(t *testing.T) {
+		require.NoError(t, stor.Set(context.Background(), "b:1", "value"))
+		// quota isn't exhausted before append, so append exceeds it
+		_, err := stor.Append(context.Background(), "b:1", "value")
+		require.NoError(t, err)
+
+		_, err = stor.Append(context.Background(), "b:1", "value")
+		require.EqualError(t, err, `THROTTLED memory quota of prefix "b:" in database 0 exceeded`)
+		require.ErrorIs(t, stor.Set(context.Background(), "b:2", "value"), limits.ErrThrottled)
+
+		require.NoError(t, stor.Del(context.Background(), "b:1"))
+		require.NoError(t, stor.Set(context.Background(), "b:2", "value"))
+	})
+
+	t.Run("database quota", func(t *testing.T) {
+		db1 := stor.Select(1)
+		require.NoError(t, db1.Set(context.Background(), "key1", "value"))
+		_, err := db1.RPush(context.Background(), "key2", []string{"value"})
+		require.EqualError(t, err, `THROTTLED keys quota of prefix "" in database 1 exceeded`)
+		require.NoError(t, stor.Select(2).Set(context.Background(), "key2", "value"))
+
+		err = stor.Import([]wal.Request{{Command: compute.CommandSet, Args: []string{"key2", "value"}, DB: 1}})
+		require.ErrorIs(t, err, limits.ErrThrottled)
+
+		require.NoError(t, db1.FlushDB(context.Background()))
+		require.NoError(t, db1.Set(context.Background(), "key2", "value"))
+
+		// quota is checked for every imported request, requests before
+		// the throttled one stay applied
+		err = stor.Import([]wal.Request{
+			{Command: compute.CommandSet, Args: []string{"key2", "value"}, DB: 1},
+			{Command: compute.CommandSet, Args: []string{"key3", "value"}, DB: 1},
+		})
+		require.ErrorIs(t, err, limits.ErrThrottled)
+		_, found := db1.Get("key3")
+		require.False(t, found)
+	})
+
+	usage := engine.(QuotaLimiter).QuotaUsage()
+	require.Equal(t, []QuotaUsage{
+		{Quota: Quota{Prefix: "a:", MaxKeys: 2}, Keys: 2, Memory: 2*entrySize + elementOverhead},
+		{Quota: Quota{Prefix: "b:", MaxMemory: entrySize + 1}, Keys: 1, Memory: entrySize},
+		{Quota: Quota{DB: 1, MaxKeys: 1}, Keys: 1, Memory: entrySize + 1},
+	}, usage)
+}
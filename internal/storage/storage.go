@@ -1,10 +1,18 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"concurrency_go_course/internal/compute"
 	"concurrency_go_course/internal/replication"
+	"concurrency_go_course/internal/slowlog"
 	"concurrency_go_course/internal/storage/wal"
 	"concurrency_go_course/pkg/logger"
 
@@ -13,17 +21,84 @@ import (
 
 // Storage is interface for storage
 type Storage interface {
-	Set(key, value string) error
+	Set(ctx context.Context, key, value string) error
 	Get(key string) (string, bool)
-	Del(key string) error
+	GetString(key string) (string, bool, error)
+	Del(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, delta int64) (int64, error)
+	Append(ctx context.Context, key, value string) (int, error)
+	GetSet(ctx context.Context, key, value string) (string, bool, error)
+
+	HSet(ctx context.Context, key string, pairs []string) (int, error)
+	HGet(key, field string) (string, bool, error)
+	HDel(ctx context.Context, key string, fields []string) (int, error)
+	HGetAll(key string) (map[string]string, error)
+
+	LPush(ctx context.Context, key string, values []string) (int, error)
+	RPush(ctx context.Context, key string, values []string) (int, error)
+	LPop(ctx context.Context, key string) (string, bool, error)
+	LRange(key string, start, stop int) ([]string, error)
+
+	SAdd(ctx context.Context, key string, members []string) (int, error)
+	SRem(ctx context.Context, key string, members []string) (int, error)
+	SMembers(key string) ([]string, error)
+
+	Watch(ctx context.Context, key string) (string, bool, error)
+	Subscribe(key string) (<-chan struct{}, func())
+
+	Select(db int) Storage
+	FlushDB(ctx context.Context) error
+
+	Trace(trace *slowlog.Trace) Storage
+
+	MemoryStats() MemoryStats
+
 	Restore(requests []wal.Request)
+	Snapshot() []wal.Request
+	Dump() []wal.Request
+	Import(requests []wal.Request) error
+}
+
+const eventEvicted = "evicted"
+
+var (
+	errNotInteger = errors.New("value is not an integer or out of range")
+	errOverflow   = errors.New("increment or decrement would overflow")
+)
+
+// SlaveWriteError is returned when write command is executed on slave
+type SlaveWriteError struct {
+	Command string
+}
+
+func (e *SlaveWriteError) Error() string {
+	return fmt.Sprintf("unable to execute %s command on slave", e.Command)
 }
 
 type storage struct {
+	db                int
 	engine            Engine
 	replicationStream chan []wal.Request
 	wal               *wal.WAL
 	isMasterRepl      bool
+	notifier          Notifier
+	watchers          *watchers
+	evictor           Evictor
+	quotas            QuotaLimiter
+	views             *views
+	trace             *slowlog.Trace
+}
+
+// views is a registry of storages of logical databases. They share
+// WAL and replication, every database has own engine and watchers
+type views struct {
+	mutex sync.Mutex
+	byDB  map[int]*storage
+}
+
+// Notifier is interface for keyspace notifications
+type Notifier interface {
+	Notify(db int, key, event string)
 }
 
 // WAL is interface for write ahead log
@@ -33,9 +108,10 @@ type WAL interface {
 	Recover() ([]wal.Request, error)
 }
 
-// New creates new storage
+// New creates new storage. Writes are rejected by replication slave only:
+// master and standalone storage without replication accept them
 func New(engine Engine, wal *wal.WAL,
-	replicationType string, replStream chan []wal.Request,
+	replicationType string, replStream chan []wal.Request, notifier Notifier,
 ) (Storage, error) {
 	if engine == nil {
 		return nil, fmt.Errorf("unable to create storage: engine is empty")
@@ -45,16 +121,28 @@ func New(engine Engine, wal *wal.WAL,
 		engine:            engine,
 		wal:               wal,
 		replicationStream: replStream,
-		isMasterRepl:      replicationType == replication.ReplicaTypeMaster,
+		isMasterRepl:      replicationType != replication.ReplicaTypeSlave,
+		notifier:          notifier,
+		watchers:          newWatchers(),
+		views:             &views{byDB: make(map[int]*storage)},
+	}
+	stor.views.byDB[0] = stor
+
+	if evictor, ok := engine.(Evictor); ok {
+		stor.evictor = evictor
+	}
+	if quotas, ok := engine.(QuotaLimiter); ok {
+		stor.quotas = quotas
 	}
 
 	if wal != nil {
+		// storage isn't started without unrecovered data,
+		// new writes would be appended over it
 		requests, err := stor.wal.Recover()
 		if err != nil {
-			logger.ErrorWithMsg("unable to get requests from WAL", err)
-		} else {
-			stor.Restore(requests)
+			return nil, fmt.Errorf("unable to recover from WAL: %w", err)
 		}
+		stor.Restore(requests)
 	}
 
 	if replStream != nil {
@@ -70,19 +158,20 @@ func New(engine Engine, wal *wal.WAL,
 }
 
 // Set sets new value
-func (s *storage) Set(key, value string) error {
+func (s *storage) Set(ctx context.Context, key, value string) error {
 	if !s.isMasterRepl {
-		return fmt.Errorf("unable to execute set command on slave")
+		return &SlaveWriteError{Command: "set"}
 	}
 
-	if s.wal != nil {
-		err := s.wal.Set(key, value)
-		if err != nil {
-			return err
-		}
+	if err := s.reserve(key); err != nil {
+		return err
+	}
+
+	if err := s.engine.Set(key, value, s.commitFunc(ctx, compute.CommandSet, key, value)); err != nil {
+		return err
 	}
+	s.notify(key, compute.CommandSet)
 
-	s.engine.Set(key, value)
 	return nil
 }
 
@@ -91,33 +180,555 @@ func (s *storage) Get(key string) (string, bool) {
 	return s.engine.Get(key)
 }
 
+// GetString returns string value by key. Error is returned if key
+// holds hash, list or set
+func (s *storage) GetString(key string) (string, bool, error) {
+	return s.engine.GetString(key)
+}
+
 // Del deletes key
-func (s *storage) Del(key string) error {
+func (s *storage) Del(ctx context.Context, key string) error {
+	if !s.isMasterRepl {
+		return &SlaveWriteError{Command: "delete"}
+	}
+
+	if err := s.engine.Delete(key, s.commitFunc(ctx, compute.CommandDelete, key)); err != nil {
+		return err
+	}
+	s.notify(key, compute.CommandDelete)
+
+	return nil
+}
+
+// Incr increments integer value of key by delta and returns new value.
+// Missing key is treated as zero
+func (s *storage) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	if !s.isMasterRepl {
+		return 0, &SlaveWriteError{Command: "incr"}
+	}
+
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	var result int64
+	_, err := s.engine.Update(key, func(value string, found bool) (string, error) {
+		var current int64
+		if found {
+			var err error
+			current, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", errNotInteger
+			}
+		}
+
+		if (delta > 0 && current > math.MaxInt64-delta) ||
+			(delta < 0 && current < math.MinInt64-delta) {
+			return "", errOverflow
+		}
+
+		result = current + delta
+		newValue := strconv.FormatInt(result, 10)
+
+		return newValue, s.logSet(ctx, key, newValue)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.notify(key, compute.CommandIncrBy)
+
+	return result, nil
+}
+
+// Append appends value to the end of key value and returns new length
+func (s *storage) Append(ctx context.Context, key, value string) (int, error) {
 	if !s.isMasterRepl {
-		return fmt.Errorf("unable to execute delete command on slave")
+		return 0, &SlaveWriteError{Command: "append"}
 	}
 
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	newValue, err := s.engine.Update(key, func(current string, _ bool) (string, error) {
+		newValue := current + value
+
+		return newValue, s.logSet(ctx, key, newValue)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.notify(key, compute.CommandAppend)
+
+	return len(newValue), nil
+}
+
+// GetSet sets new value for key and returns old one
+func (s *storage) GetSet(ctx context.Context, key, value string) (string, bool, error) {
+	if !s.isMasterRepl {
+		return "", false, &SlaveWriteError{Command: "getset"}
+	}
+
+	if err := s.reserve(key); err != nil {
+		return "", false, err
+	}
+
+	var oldValue string
+	var oldFound bool
+	_, err := s.engine.Update(key, func(current string, found bool) (string, error) {
+		oldValue, oldFound = current, found
+
+		return value, s.logSet(ctx, key, value)
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	s.notify(key, compute.CommandSet)
+
+	return oldValue, oldFound, nil
+}
+
+// logSet writes resulting value of read-modify-write command to WAL,
+// so replay and replication don't depend on the previous state
+func (s *storage) logSet(ctx context.Context, key, value string) error {
+	if s.wal == nil {
+		return nil
+	}
+
+	return s.log(ctx, s.db, compute.CommandSet, []string{key, value})
+}
+
+// HSet sets fields of hash
+func (s *storage) HSet(ctx context.Context, key string, pairs []string) (int, error) {
+	if err := s.checkMaster(compute.CommandHSet); err != nil {
+		return 0, err
+	}
+
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.HSet(key, pairs, s.commitFunc(ctx, compute.CommandHSet, key, pairs...))
+
+	return s.notifyChanged(key, compute.CommandHSet, changed, err)
+}
+
+// HGet returns value of hash field
+func (s *storage) HGet(key, field string) (string, bool, error) {
+	return s.engine.HGet(key, field)
+}
+
+// HDel deletes fields of hash
+func (s *storage) HDel(ctx context.Context, key string, fields []string) (int, error) {
+	if err := s.checkMaster(compute.CommandHDel); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.HDel(key, fields, s.commitFunc(ctx, compute.CommandHDel, key, fields...))
+
+	return s.notifyChanged(key, compute.CommandHDel, changed, err)
+}
+
+// HGetAll returns all fields of hash
+func (s *storage) HGetAll(key string) (map[string]string, error) {
+	return s.engine.HGetAll(key)
+}
+
+// LPush inserts values at the head of list
+func (s *storage) LPush(ctx context.Context, key string, values []string) (int, error) {
+	if err := s.checkMaster(compute.CommandLPush); err != nil {
+		return 0, err
+	}
+
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.LPush(key, values, s.commitFunc(ctx, compute.CommandLPush, key, values...))
+
+	return s.notifyChanged(key, compute.CommandLPush, changed, err)
+}
+
+// RPush inserts values at the tail of list
+func (s *storage) RPush(ctx context.Context, key string, values []string) (int, error) {
+	if err := s.checkMaster(compute.CommandRPush); err != nil {
+		return 0, err
+	}
+
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.RPush(key, values, s.commitFunc(ctx, compute.CommandRPush, key, values...))
+
+	return s.notifyChanged(key, compute.CommandRPush, changed, err)
+}
+
+// LPop removes and returns first element of list
+func (s *storage) LPop(ctx context.Context, key string) (string, bool, error) {
+	if err := s.checkMaster(compute.CommandLPop); err != nil {
+		return "", false, err
+	}
+
+	value, ok, err := s.engine.LPop(key, s.commitFunc(ctx, compute.CommandLPop, key))
+	if ok {
+		s.notify(key, compute.CommandLPop)
+	}
+
+	return value, ok, err
+}
+
+// LRange returns range of list elements
+func (s *storage) LRange(key string, start, stop int) ([]string, error) {
+	return s.engine.LRange(key, start, stop)
+}
+
+// SAdd adds members to set
+func (s *storage) SAdd(ctx context.Context, key string, members []string) (int, error) {
+	if err := s.checkMaster(compute.CommandSAdd); err != nil {
+		return 0, err
+	}
+
+	if err := s.reserve(key); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.SAdd(key, members, s.commitFunc(ctx, compute.CommandSAdd, key, members...))
+
+	return s.notifyChanged(key, compute.CommandSAdd, changed, err)
+}
+
+// SRem removes members from set
+func (s *storage) SRem(ctx context.Context, key string, members []string) (int, error) {
+	if err := s.checkMaster(compute.CommandSRem); err != nil {
+		return 0, err
+	}
+
+	changed, err := s.engine.SRem(key, members, s.commitFunc(ctx, compute.CommandSRem, key, members...))
+
+	return s.notifyChanged(key, compute.CommandSRem, changed, err)
+}
+
+// SMembers returns members of set
+func (s *storage) SMembers(key string) ([]string, error) {
+	return s.engine.SMembers(key)
+}
+
+// Select returns storage of logical database db. Database is created
+// on first use, it shares WAL, replication and memory limit with others
+func (s *storage) Select(db int) Storage {
+	return s.view(db)
+}
+
+func (s *storage) view(db int) *storage {
+	if db == s.db {
+		return s
+	}
+
+	s.views.mutex.Lock()
+	defer s.views.mutex.Unlock()
+
+	if view, ok := s.views.byDB[db]; ok {
+		return view
+	}
+
+	view := *s
+	view.db = db
+	view.engine = s.engine.Select(db)
+	view.watchers = newWatchers()
+	view.quotas, _ = view.engine.(QuotaLimiter)
+	s.views.byDB[db] = &view
+
+	return &view
+}
+
+// Trace returns storage adding time of waiting for WAL to trace
+// of request. Storage is the same, only WAL writes are traced
+func (s *storage) Trace(trace *slowlog.Trace) Storage {
+	if trace == nil {
+		return s
+	}
+
+	traced := *s
+	traced.trace = trace
+
+	return &traced
+}
+
+// FlushDB deletes all keys of database. Flush is written to WAL as one
+// request, watchers of deleted keys are woken up
+func (s *storage) FlushDB(ctx context.Context) error {
+	if err := s.checkMaster(compute.CommandFlushDB); err != nil {
+		return err
+	}
+
+	var commit CommitFunc
 	if s.wal != nil {
-		if err := s.wal.Del(key); err != nil {
-			return err
+		commit = func() error {
+			return s.log(ctx, s.db, compute.CommandFlushDB, nil)
+		}
+	}
+
+	keys, err := s.engine.Flush(commit)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		s.notify(key, compute.CommandFlushDB)
+	}
+
+	return nil
+}
+
+// MemoryStats returns memory statistics of engine
+func (s *storage) MemoryStats() MemoryStats {
+	if s.evictor == nil {
+		return MemoryStats{}
+	}
+
+	return s.evictor.MemoryStats()
+}
+
+// reserve prepares write of key: it evicts keys if memory limit
+// is reached and checks quotas of key
+func (s *storage) reserve(key string) error {
+	if err := s.evict(); err != nil {
+		return err
+	}
+
+	return s.checkQuota(key)
+}
+
+func (s *storage) checkQuota(key string) error {
+	if s.quotas == nil {
+		return nil
+	}
+
+	return s.quotas.CheckQuota(key)
+}
+
+// checkQuotaLocked checks quotas of key under its partition lock
+func (s *storage) checkQuotaLocked(key string) error {
+	if s.quotas == nil {
+		return nil
+	}
+
+	return s.quotas.CheckQuotaLocked(key)
+}
+
+// evict evicts keys if memory limit is reached. Evicted keys are written
+// to WAL as deletes, so slaves stay consistent with master. Deletes are
+// pushed to WAL under partition locks to keep order with other writes
+// of keys, and committed by one group after locks are released
+func (s *storage) evict() error {
+	if s.evictor == nil {
+		return nil
+	}
+
+	var handles []<-chan error
+	evicted, err := s.evictor.Evict(func(db int, key string) error {
+		if s.wal != nil {
+			handles = append(handles, s.wal.Push(db, compute.CommandDelete, []string{key}))
+		}
+
+		return nil
+	})
+
+	for _, key := range evicted {
+		logger.Debug("Key was evicted", zap.Int("db", key.DB), zap.String("key", key.Key))
+		s.view(key.DB).notify(key.Key, eventEvicted)
+	}
+
+	start := time.Now()
+	for _, handle := range handles {
+		if commitErr := <-handle; commitErr != nil && err == nil {
+			err = fmt.Errorf("unable to write evicted keys to WAL: %w", commitErr)
 		}
 	}
+	if len(handles) != 0 {
+		s.trace.Observe(slowlog.PhaseWAL, start)
+	}
+
+	return err
+}
+
+// notify wakes up key watchers and sends keyspace notification
+// about key change
+func (s *storage) notify(key, cmd string) {
+	s.watchers.wake(key)
+
+	if s.notifier != nil {
+		s.notifier.Notify(s.db, key, cmd)
+	}
+}
+
+func (s *storage) notifyChanged(key, cmd string, changed int, err error) (int, error) {
+	if err == nil && changed > 0 {
+		s.notify(key, cmd)
+	}
+
+	return changed, err
+}
+
+func (s *storage) checkMaster(cmd string) error {
+	if !s.isMasterRepl {
+		return &SlaveWriteError{Command: strings.ToLower(cmd)}
+	}
 
-	s.engine.Delete(key)
 	return nil
 }
 
-// Restore restores WAL settings
+// log writes request to WAL and adds waiting for it to trace of request.
+// Request isn't written if context is done before its group commit
+func (s *storage) log(ctx context.Context, db int, cmd string, args []string) error {
+	start := time.Now()
+	defer s.trace.Observe(slowlog.PhaseWAL, start)
+
+	return s.wal.Log(ctx, db, cmd, args)
+}
+
+// commitFunc returns func writing operation to WAL before it is applied
+func (s *storage) commitFunc(ctx context.Context, cmd string, key string, args ...string) CommitFunc {
+	if s.wal == nil {
+		return nil
+	}
+
+	return func() error {
+		return s.log(ctx, s.db, cmd, append([]string{key}, args...))
+	}
+}
+
+// Restore applies requests of WAL or replication stream to their
+// logical databases
 func (s *storage) Restore(requests []wal.Request) {
 	for _, request := range requests {
-		switch request.Command {
-		case compute.CommandSet:
-			s.engine.Set(request.Args[0], request.Args[1])
-			logger.Debug("Was restored", zap.String("key", request.Args[0]),
-				zap.String("value", request.Args[1]))
-		case compute.CommandDelete:
-			s.engine.Delete(request.Args[0])
-			logger.Debug("Was deleted", zap.String("key", request.Args[0]))
+		view := s.view(request.DB)
+		if err := view.restore(request, nil); err != nil {
+			logger.ErrorWithMsg("unable to restore request", err,
+				zap.String("command", request.Command), zap.Int("db", request.DB))
+			continue
 		}
+
+		if len(request.Args) != 0 {
+			view.notify(request.Args[0], request.Command)
+		}
+	}
+}
+
+// Snapshot returns requests recreating current state of all logical
+// databases, every request has its database set
+func (s *storage) Snapshot() []wal.Request {
+	var requests []wal.Request
+	for _, db := range s.engine.Databases() {
+		s.view(db).engine.Dump(func(command string, args []string) {
+			requests = append(requests, wal.Request{Command: command, Args: args, DB: db})
+		})
 	}
+
+	return requests
+}
+
+// Dump returns requests recreating current state of logical database
+// of storage, every request has its database set
+func (s *storage) Dump() []wal.Request {
+	var requests []wal.Request
+	s.engine.Dump(func(command string, args []string) {
+		requests = append(requests, wal.Request{Command: command, Args: args, DB: s.db})
+	})
+
+	return requests
+}
+
+// Import applies requests and writes them to WAL. Every request is pushed
+// to WAL and checked against quotas under partition lock of its key, so
+// WAL keeps order of concurrent writes. Group commits are waited after
+// all requests are applied, so bulk load waits for a few of them instead
+// of one per request. Requests before the failed one stay applied
+func (s *storage) Import(requests []wal.Request) error {
+	if !s.isMasterRepl {
+		return &SlaveWriteError{Command: "import"}
+	}
+
+	if err := s.evict(); err != nil {
+		return err
+	}
+
+	var handles []<-chan error
+	var err error
+	for _, request := range requests {
+		view := s.view(request.DB)
+
+		commit := func() error {
+			if request.Command != compute.CommandDelete && len(request.Args) != 0 {
+				if err := view.checkQuotaLocked(request.Args[0]); err != nil {
+					return err
+				}
+			}
+			if s.wal != nil {
+				handles = append(handles, s.wal.Push(request.DB, request.Command, request.Args))
+			}
+
+			return nil
+		}
+
+		if err = view.restore(request, commit); err != nil {
+			break
+		}
+		if len(request.Args) != 0 {
+			view.notify(request.Args[0], request.Command)
+		}
+	}
+
+	start := time.Now()
+	for _, handle := range handles {
+		if commitErr := <-handle; commitErr != nil && err == nil {
+			err = commitErr
+		}
+	}
+	if len(handles) != 0 {
+		s.trace.Observe(slowlog.PhaseWAL, start)
+	}
+
+	return err
+}
+
+// restore applies request, commit is called before it is applied
+func (s *storage) restore(request wal.Request, commit CommitFunc) error {
+	var err error
+
+	switch request.Command {
+	case compute.CommandSet:
+		err = s.engine.Set(request.Args[0], request.Args[1], commit)
+		logger.Debug("Was restored", zap.String("key", request.Args[0]),
+			zap.String("value", request.Args[1]))
+	case compute.CommandDelete:
+		err = s.engine.Delete(request.Args[0], commit)
+		logger.Debug("Was deleted", zap.String("key", request.Args[0]))
+	case compute.CommandHSet:
+		_, err = s.engine.HSet(request.Args[0], request.Args[1:], commit)
+	case compute.CommandHDel:
+		_, err = s.engine.HDel(request.Args[0], request.Args[1:], commit)
+	case compute.CommandLPush:
+		_, err = s.engine.LPush(request.Args[0], request.Args[1:], commit)
+	case compute.CommandRPush:
+		_, err = s.engine.RPush(request.Args[0], request.Args[1:], commit)
+	case compute.CommandLPop:
+		_, _, err = s.engine.LPop(request.Args[0], commit)
+	case compute.CommandSAdd:
+		_, err = s.engine.SAdd(request.Args[0], request.Args[1:], commit)
+	case compute.CommandSRem:
+		_, err = s.engine.SRem(request.Args[0], request.Args[1:], commit)
+	case compute.CommandFlushDB:
+		var keys []string
+		keys, err = s.engine.Flush(commit)
+		for _, key := range keys {
+			s.notify(key, compute.CommandFlushDB)
+		}
+	}
+
+	return err
 }
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	walCfg := &config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        t.TempDir(),
+		FlushingBatchTimeout: "1ms",
+	}}
+
+	walObj, err := wal.New(walCfg)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	walObj.Start(ctx)
+
+	stor, err := New(NewEngine(1), walObj, "master", nil, nil)
+	require.NoError(t, err)
+
+	requests := make([]wal.Request, 0, 100)
+	for i := range 100 {
+		requests = append(requests, wal.Request{Command: compute.CommandSet, Args: []string{"key", "import" + strconv.Itoa(i)}})
+	}
+
+	// concurrent writes of imported key are written to WAL
+	// in the same order as they are applied
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			_ = stor.Set(context.Background(), "key", "set"+strconv.Itoa(i))
+		}
+	}()
+	require.NoError(t, stor.Import(requests))
+	wg.Wait()
+
+	value, found := stor.Get("key")
+	require.True(t, found)
+
+	cancel()
+	<-walObj.Done()
+
+	walObj, err = wal.New(walCfg)
+	require.NoError(t, err)
+	restored, err := New(NewEngine(1), walObj, "master", nil, nil)
+	require.NoError(t, err)
+
+	restoredValue, found := restored.Get("key")
+	require.True(t, found)
+	require.Equal(t, value, restoredValue)
+}
+
+func TestNewUnrecoveredWAL(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)), 0o600))
+
+	walCfg := &config.WALCfg{WalConfig: &config.WALSettings{
+		DataDirectory:        dir,
+		FlushingBatchTimeout: "1ms",
+		Encryption: &config.EncryptionConfig{
+			ActiveKeyID: "k1",
+			Keys:        []config.KeyConfig{{ID: "k1", File: keyFile}},
+		},
+	}}
+
+	walObj, err := wal.New(walCfg)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	walObj.Start(ctx)
+
+	stor, err := New(NewEngine(1), walObj, "master", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, stor.Set(context.Background(), "key", "value"))
+
+	cancel()
+	<-walObj.Done()
+
+	// WAL can't be decrypted without keyring, so storage isn't started
+	walCfg.WalConfig.Encryption = nil
+	walObj, err = wal.New(walCfg)
+	require.NoError(t, err)
+
+	_, err = New(NewEngine(1), walObj, "master", nil, nil)
+	require.ErrorIs(t, err, wal.ErrNoKeyring)
+}
+
+func TestNewReplicationType(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	for replicationType, writable := range map[string]bool{"master": true, "": true, "slave": false} {
+		stor, err := New(NewEngine(1), nil, replicationType, nil, nil)
+		require.NoError(t, err)
+
+		err = stor.Set(context.Background(), "key", "value")
+		if writable {
+			require.NoError(t, err, replicationType)
+		} else {
+			require.Equal(t, &SlaveWriteError{Command: "set"}, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package wal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression is an algorithm of WAL batch compression
+type Compression string
+
+const (
+	// CompressionNone leaves data uncompressed
+	CompressionNone Compression = "none"
+	// CompressionGzip compresses data by gzip
+	CompressionGzip Compression = "gzip"
+)
+
+// ParseCompression returns compression by name, empty name means none
+func ParseCompression(name string) (Compression, error) {
+	switch Compression(name) {
+	case "", CompressionNone:
+		return CompressionNone, nil
+	case CompressionGzip:
+		return CompressionGzip, nil
+	}
+
+	return "", fmt.Errorf("unknown compression: %s", name)
+}
+
+// Compress compresses data
+func Compress(data []byte, compression Compression) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to compress data: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress data: %w", err)
+		}
+		return buffer.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("unknown compression: %s", compression)
+}
+
+// Decompress decompresses data
+func Decompress(data []byte, compression Compression) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress data: %w", err)
+		}
+		defer reader.Close() //nolint:errcheck
+
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress data: %w", err)
+		}
+		return decompressed, nil
+	}
+
+	return nil, fmt.Errorf("unknown compression: %s", compression)
+}
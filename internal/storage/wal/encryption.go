@@ -0,0 +1,146 @@
+package wal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"concurrency_go_course/internal/config"
+)
+
+// ErrNoKeyring is returned if encrypted record is read without keyring
+var ErrNoKeyring = errors.New("WAL record is encrypted, but encryption isn't configured")
+
+// Keyring is a set of AES-GCM keys. Records are encrypted by active key,
+// ID of the key is written to every record, so records encrypted by other
+// keys of keyring can be decrypted after key rotation
+type Keyring struct {
+	activeKeyID string
+	aeads       map[string]cipher.AEAD
+}
+
+// NewKeyring loads keys by config
+func NewKeyring(cfg *config.EncryptionConfig) (*Keyring, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("encryption config is empty")
+	}
+
+	keyring := &Keyring{
+		activeKeyID: cfg.ActiveKeyID,
+		aeads:       make(map[string]cipher.AEAD, len(cfg.Keys)),
+	}
+
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.ID == "" || len(keyCfg.ID) > 255 {
+			return nil, fmt.Errorf("invalid encryption key ID: %q", keyCfg.ID)
+		}
+
+		key, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load encryption key %s: %w", keyCfg.ID, err)
+		}
+
+		keyring.aeads[keyCfg.ID], err = newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load encryption key %s: %w", keyCfg.ID, err)
+		}
+	}
+
+	if _, ok := keyring.aeads[keyring.activeKeyID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q isn't found", keyring.activeKeyID)
+	}
+
+	return keyring, nil
+}
+
+func loadKey(cfg config.KeyConfig) ([]byte, error) {
+	var encoded string
+
+	switch {
+	case cfg.File != "":
+		data, err := os.ReadFile(filepath.Clean(cfg.File))
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(data)
+	case cfg.Env != "":
+		value, ok := os.LookupEnv(cfg.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s isn't set", cfg.Env)
+		}
+		encoded = value
+	default:
+		return nil, fmt.Errorf("key file or environment variable should be set")
+	}
+
+	return hex.DecodeString(strings.TrimSpace(encoded))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data by active key:
+//
+//	key ID length  byte
+//	key ID         []byte
+//	nonce          [12]byte
+//	ciphertext     []byte
+//
+// additional data is authenticated with ciphertext
+func (k *Keyring) seal(data, additionalData []byte) ([]byte, error) {
+	aead := k.aeads[k.activeKeyID]
+
+	sealed := make([]byte, 0, 1+len(k.activeKeyID)+aead.NonceSize()+len(data)+aead.Overhead())
+	sealed = append(sealed, byte(len(k.activeKeyID)))
+	sealed = append(sealed, k.activeKeyID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	sealed = append(sealed, nonce...)
+
+	return aead.Seal(sealed, nonce, data, additionalData), nil
+}
+
+// open decrypts data encrypted by any key of keyring
+func (k *Keyring) open(sealed, additionalData []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrNoKeyring
+	}
+
+	if len(sealed) < 1 || len(sealed) < 1+int(sealed[0]) {
+		return nil, fmt.Errorf("failed to decrypt data: key ID is truncated")
+	}
+
+	keyID := string(sealed[1 : 1+sealed[0]])
+	sealed = sealed[1+len(keyID):]
+
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("failed to decrypt data: unknown key %q", keyID)
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt data: nonce is truncated")
+	}
+
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return data, nil
+}
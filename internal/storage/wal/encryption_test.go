@@ -0,0 +1,130 @@
+package wal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"concurrency_go_course/internal/config"
+)
+
+const (
+	testKey1 = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testKey2 = "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+)
+
+func TestNewKeyringNeg(t *testing.T) {
+	t.Setenv("WAL_TEST_KEY", "not hex")
+
+	tests := []struct {
+		name string
+		cfg  *config.EncryptionConfig
+	}{
+		{
+			name: "Empty config",
+			cfg:  nil,
+		},
+		{
+			name: "Active key isn't found",
+			cfg: &config.EncryptionConfig{
+				ActiveKeyID: "k2",
+				Keys:        []config.KeyConfig{{ID: "k1", Env: "WAL_TEST_KEY"}},
+			},
+		},
+		{
+			name: "Key isn't hex encoded",
+			cfg: &config.EncryptionConfig{
+				ActiveKeyID: "k1",
+				Keys:        []config.KeyConfig{{ID: "k1", Env: "WAL_TEST_KEY"}},
+			},
+		},
+		{
+			name: "Environment variable isn't set",
+			cfg: &config.EncryptionConfig{
+				ActiveKeyID: "k1",
+				Keys:        []config.KeyConfig{{ID: "k1", Env: "WAL_TEST_KEY_UNSET"}},
+			},
+		},
+		{
+			name: "Key source isn't set",
+			cfg: &config.EncryptionConfig{
+				ActiveKeyID: "k1",
+				Keys:        []config.KeyConfig{{ID: "k1"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.cfg)
+			assert.Nil(t, keyring)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptedBatchesKeyRotation(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "wal.key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(testKey1+"\n"), 0o600))
+	t.Setenv("WAL_TEST_KEY", testKey2)
+
+	keyring1, err := NewKeyring(&config.EncryptionConfig{
+		ActiveKeyID: "k1",
+		Keys:        []config.KeyConfig{{ID: "k1", File: keyFile}},
+	})
+	assert.NoError(t, err)
+
+	// after rotation new records are encrypted by k2, k1 is kept for reads
+	keyring2, err := NewKeyring(&config.EncryptionConfig{
+		ActiveKeyID: "k2",
+		Keys: []config.KeyConfig{
+			{ID: "k1", File: keyFile},
+			{ID: "k2", Env: "WAL_TEST_KEY"},
+		},
+	})
+	assert.NoError(t, err)
+
+	var segment bytes.Buffer
+
+	data, err := encodeBatch([]Request{NewRequest("SET", []string{"token", "secret1"})}, CompressionNone, keyring1)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("secret1")))
+	segment.Write(data)
+
+	data, err = encodeBatch([]Request{NewRequest("SET", []string{"token", "secret2"})}, CompressionGzip, keyring2)
+	assert.NoError(t, err)
+	segment.Write(data)
+
+	requests, err := DecodeRequests(segment.Bytes(), keyring2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Request{
+		{Command: "SET", Args: []string{"token", "secret1"}},
+		{Command: "SET", Args: []string{"token", "secret2"}},
+	}, requests)
+
+	_, err = DecodeRequests(segment.Bytes(), keyring1)
+	assert.ErrorContains(t, err, `unknown key "k2"`)
+
+	_, err = DecodeRequests(segment.Bytes(), nil)
+	assert.ErrorIs(t, err, ErrNoKeyring)
+}
+
+func TestEncryptedBatchTampered(t *testing.T) {
+	t.Setenv("WAL_TEST_KEY", testKey1)
+
+	keyring, err := NewKeyring(&config.EncryptionConfig{
+		ActiveKeyID: "k1",
+		Keys:        []config.KeyConfig{{ID: "k1", Env: "WAL_TEST_KEY"}},
+	})
+	assert.NoError(t, err)
+
+	data, err := encodeBatch([]Request{NewRequest("SET", []string{"key", "value"})}, CompressionNone, keyring)
+	assert.NoError(t, err)
+
+	data[len(data)-1] ^= 0xFF
+	_, err = DecodeRequests(data, keyring)
+	assert.ErrorContains(t, err, "failed to decrypt data")
+}
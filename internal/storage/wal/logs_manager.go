@@ -1,9 +1,11 @@
 package wal
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"time"
+
+	"go.uber.org/zap"
 
 	fs "concurrency_go_course/internal/filesystem"
 	"concurrency_go_course/pkg/logger"
@@ -12,42 +14,141 @@ import (
 // LogsManager is interface for manager
 type LogsManager interface {
 	Write(requests []Request)
+	Sync() error
 	ReadAll() ([]Request, error)
+	ReadUntil(target *RecoveryTarget) ([]Request, error)
+	Rotate() (string, error)
 }
 
 // LogsManager is a struct for logs manager
 type logsmanager struct {
 	segment fs.Segment
+
+	syncMode     SyncMode
+	syncInterval time.Duration
+	lastSync     time.Time
+	// unsynced is a number of written requests which aren't synced yet,
+	// all of them are committed by one sync
+	unsynced int
+
+	compression Compression
+	keyring     *Keyring
+}
+
+// LogsManagerOption is a func configuring logs manager
+type LogsManagerOption func(*logsmanager)
+
+// WithCompression makes batches compressed before they are written
+func WithCompression(compression Compression) LogsManagerOption {
+	return func(l *logsmanager) {
+		l.compression = compression
+	}
+}
+
+// WithEncryption makes batches encrypted by keyring before they are written.
+// Encrypted batches are decrypted on read by the same keyring
+func WithEncryption(keyring *Keyring) LogsManagerOption {
+	return func(l *logsmanager) {
+		l.keyring = keyring
+	}
 }
 
-// NewLogsManager returns new logs manager
-func NewLogsManager(segment fs.Segment) (LogsManager, error) {
+// NewLogsManager returns new logs manager which syncs segment
+// according to sync mode
+func NewLogsManager(
+	segment fs.Segment, syncMode SyncMode, syncInterval time.Duration, opts ...LogsManagerOption,
+) (LogsManager, error) {
 	if segment == nil {
 		return nil, errors.New("segment is invalid")
 	}
 
-	return &logsmanager{segment: segment}, nil
+	l := &logsmanager{
+		segment:      segment,
+		syncMode:     syncMode,
+		syncInterval: syncInterval,
+		lastSync:     time.Now(),
+		compression:  CompressionNone,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l, nil
 }
 
 // Write writes requests
 func (l *logsmanager) Write(requests []Request) {
-	var buffer bytes.Buffer
-	for _, req := range requests {
-		if err := req.Encode(&buffer); err != nil {
-			logger.ErrorWithMsg("failed to encode requests", err)
-			l.acknowledgeWrite(requests, err)
-			return
-		}
+	now := time.Now().UnixNano()
+	for i := range requests {
+		requests[i].Timestamp = now
+	}
+
+	data, err := encodeBatch(requests, l.compression, l.keyring)
+	if err != nil {
+		logger.ErrorWithMsg("failed to encode requests", err)
+		l.acknowledgeWrite(requests, err)
+		return
 	}
 
-	err := l.segment.Write(buffer.Bytes())
+	start := time.Now()
+	err = l.segment.Write(data)
+	if err == nil {
+		l.unsynced += len(requests)
+		err = l.syncByMode()
+	}
+	flushDuration.ObserveDuration(start)
+	batchSize.Observe(float64(len(requests)))
 	if err != nil {
 		logger.ErrorWithMsg("failed to write request data:", err)
+		flushErrors.Inc()
 	}
 
 	l.acknowledgeWrite(requests, err)
 }
 
+// Sync syncs requests written after the last sync
+func (l *logsmanager) Sync() error {
+	if l.unsynced == 0 {
+		return nil
+	}
+
+	if err := l.segment.Sync(); err != nil {
+		syncErrors.Inc()
+		return err
+	}
+
+	requestsPerSync.Observe(float64(l.unsynced))
+	syncs.Inc()
+	l.unsynced = 0
+	l.lastSync = time.Now()
+
+	return nil
+}
+
+// Rotate syncs written requests and closes current segment.
+// It returns name of the last segment containing them
+func (l *logsmanager) Rotate() (string, error) {
+	if err := l.Sync(); err != nil {
+		return "", err
+	}
+
+	return l.segment.Rotate()
+}
+
+func (l *logsmanager) syncByMode() error {
+	switch l.syncMode {
+	case SyncModeAlways:
+		return l.Sync()
+	case SyncModeInterval:
+		if time.Since(l.lastSync) >= l.syncInterval {
+			return l.Sync()
+		}
+	case SyncModeNone:
+	}
+
+	return nil
+}
+
 // ReadAll reads all requests
 func (l *logsmanager) ReadAll() ([]Request, error) {
 	segmentsData, err := l.segment.ReadAll()
@@ -68,20 +169,55 @@ func (l *logsmanager) ReadAll() ([]Request, error) {
 	return requests, nil
 }
 
-func (l *logsmanager) readSegment(requests []Request, data []byte) ([]Request, error) {
-	buffer := bytes.NewBuffer(data)
-	for buffer.Len() > 0 {
-		var request Request
-		if err := request.Decode(buffer); err != nil {
-			return nil, fmt.Errorf("failed to parse logs data: %w", err)
+// ReadUntil reads requests written before recovery target. Data of
+// segments starting from the first request after target is moved to
+// archive directory, so it isn't recovered again on the next start
+// and new requests aren't mixed with it
+func (l *logsmanager) ReadUntil(target *RecoveryTarget) ([]Request, error) {
+	segments, err := l.segment.ReadSegments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segments: %w", err)
+	}
+
+	var requests []Request
+	for _, segment := range segments {
+		cut := -1
+		_, err := ScanRequests(segment.Data, l.keyring, func(offset int, request Request) {
+			if cut >= 0 {
+				return
+			}
+			if target.reached(segment.Name, offset, request) {
+				cut = offset
+				return
+			}
+			requests = append(requests, request)
+		})
+		// data after target is archived, so its corruption doesn't matter
+		if err != nil && cut < 0 {
+			return nil, fmt.Errorf("failed to read segments: %w", err)
 		}
 
-		requests = append(requests, request)
+		if cut >= 0 {
+			archive, err := l.segment.Archive(segment.Name, cut)
+			if err != nil {
+				return nil, fmt.Errorf("failed to archive requests after recovery target: %w", err)
+			}
+
+			logger.Info("WAL is recovered to target, later requests are archived",
+				zap.String("segment", segment.Name), zap.Int("offset", cut), zap.String("archive", archive))
+			return requests, nil
+		}
 	}
 
+	logger.Info("WAL recovery target isn't reached, all requests are recovered")
+
 	return requests, nil
 }
 
+func (l *logsmanager) readSegment(requests []Request, data []byte) ([]Request, error) {
+	return decodeRequests(requests, data, l.keyring)
+}
+
 func (l *logsmanager) acknowledgeWrite(requests []Request, err error) {
 	for _, req := range requests {
 		req.doneStatus <- err
@@ -1,8 +1,10 @@
 package wal
 
 import (
+	"bytes"
 	"os"
 	"testing"
+	"time"
 
 	"concurrency_go_course/internal/filesystem"
 	"concurrency_go_course/pkg/logger"
@@ -49,7 +51,7 @@ func TestLogsManagerWrite(t *testing.T) {
 	fileLib := filesystem.NewFileLib()
 	segment := filesystem.NewSegment(testDataDir, 10, fileLib)
 
-	logsManager, err := NewLogsManager(segment)
+	logsManager, err := NewLogsManager(segment, SyncModeAlways, 0)
 	if err != nil {
 		t.Errorf("failed: %s", err)
 	}
@@ -101,7 +103,7 @@ func TestLogsManagerReadAll(t *testing.T) {
 	fileLib := filesystem.NewMockFileLib()
 	segment := filesystem.NewSegment(testDataDirRead, 10, fileLib)
 
-	logsManager, err := NewLogsManager(segment)
+	logsManager, err := NewLogsManager(segment, SyncModeAlways, 0)
 	if err != nil {
 		t.Errorf("failed: %s", err)
 	}
@@ -110,7 +112,7 @@ func TestLogsManagerReadAll(t *testing.T) {
 
 	segmentR := filesystem.NewSegment(testDataDirRead, 10, fileLib)
 
-	logsManager, err = NewLogsManager(segmentR)
+	logsManager, err = NewLogsManager(segmentR, SyncModeAlways, 0)
 	if err != nil {
 		t.Errorf("failed: %s", err)
 	}
@@ -125,3 +127,97 @@ func TestLogsManagerReadAll(t *testing.T) {
 		assert.Equal(t, r.Args, r.Args)
 	}
 }
+
+type syncCountingSegment struct {
+	syncs int
+}
+
+func (s *syncCountingSegment) Write(_ []byte) error { return nil }
+
+func (s *syncCountingSegment) Sync() error {
+	s.syncs++
+	return nil
+}
+
+func (s *syncCountingSegment) ReadAll() ([][]byte, error) { return nil, nil }
+
+func (s *syncCountingSegment) ReadSegments() ([]filesystem.SegmentData, error) { return nil, nil }
+
+func (s *syncCountingSegment) Archive(_ string, _ int) (string, error) { return "", nil }
+
+func (s *syncCountingSegment) Rotate() (string, error) { return "", nil }
+
+func TestLogsManagerSyncMode(t *testing.T) {
+	logger.MockLogger()
+
+	tests := []struct {
+		name         string
+		syncMode     SyncMode
+		syncInterval time.Duration
+		// syncs after writes and after explicit Sync
+		writeSyncs int
+		totalSyncs int
+	}{
+		{
+			name:       "Always",
+			syncMode:   SyncModeAlways,
+			writeSyncs: 3,
+			totalSyncs: 3,
+		},
+		{
+			name:         "Interval",
+			syncMode:     SyncModeInterval,
+			syncInterval: time.Hour,
+			writeSyncs:   0,
+			totalSyncs:   1,
+		},
+		{
+			name:       "Interval elapsed",
+			syncMode:   SyncModeInterval,
+			writeSyncs: 3,
+			totalSyncs: 3,
+		},
+		{
+			name:       "None",
+			syncMode:   SyncModeNone,
+			writeSyncs: 0,
+			totalSyncs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := &syncCountingSegment{}
+
+			logsManager, err := NewLogsManager(segment, tt.syncMode, tt.syncInterval)
+			assert.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				req := NewRequest("SET", []string{"key", "value"})
+				logsManager.Write([]Request{req})
+				assert.NoError(t, <-req.doneStatus)
+			}
+			assert.Equal(t, tt.writeSyncs, segment.syncs)
+
+			assert.NoError(t, logsManager.Sync())
+			assert.Equal(t, tt.totalSyncs, segment.syncs)
+		})
+	}
+}
+
+func TestLogsManagerReadZeroedTail(t *testing.T) {
+	logger.MockLogger()
+
+	var buffer bytes.Buffer
+	for _, req := range []Request{NewRequest("SET", []string{"key", "value"}), NewRequest("DEL", []string{"key"})} {
+		assert.NoError(t, req.Encode(&buffer))
+	}
+	// tail of preallocated segment which wasn't synced before crash
+	buffer.Write(make([]byte, 64))
+
+	l := &logsmanager{}
+	requests, err := l.readSegment(nil, buffer.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "DEL", requests[1].Command)
+}
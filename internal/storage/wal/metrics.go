@@ -0,0 +1,31 @@
+package wal
+
+import (
+	"concurrency_go_course/pkg/metrics"
+)
+
+// Metrics of WAL. Names are part of monitoring API and must not be changed:
+//
+//	wal_batch_size               - histogram of number of requests in flushed batch
+//	wal_flush_duration_seconds   - histogram of batch write and sync latency
+//	wal_flush_errors_total       - number of batches failed to be written
+//	wal_fsync_total              - number of segment syncs
+//	wal_fsync_errors_total       - number of failed segment syncs
+//	wal_requests_per_fsync       - histogram of number of requests committed
+//	                               by one sync (group commit size)
+var (
+	batchSize = metrics.NewHistogram("wal_batch_size",
+		"Number of requests in flushed batch.",
+		[]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000})
+	flushDuration = metrics.NewHistogram("wal_flush_duration_seconds",
+		"Batch write and sync latency in seconds.", metrics.DefaultBuckets)
+	flushErrors = metrics.NewCounter("wal_flush_errors_total",
+		"Number of batches failed to be written.")
+	syncs = metrics.NewCounter("wal_fsync_total",
+		"Number of WAL segment syncs.")
+	syncErrors = metrics.NewCounter("wal_fsync_errors_total",
+		"Number of failed WAL segment syncs.")
+	requestsPerSync = metrics.NewHistogram("wal_requests_per_fsync",
+		"Number of requests committed by one sync.",
+		[]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 5000})
+)
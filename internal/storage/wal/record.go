@@ -0,0 +1,168 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"concurrency_go_course/pkg/logger"
+)
+
+// Plain batch is written as gob encoded requests one by one.
+// Batch transformed by compression or encryption is written as record:
+//
+//	marker  byte    0xC5, gob message never starts with it
+//	flags   byte    transformations applied to payload
+//	length  uint32  length of payload
+//	payload []byte  gob encoded requests of batch
+//
+// so segments can contain both plain batches and records
+const (
+	recordMarker     byte = 0xC5
+	recordHeaderSize      = 6
+
+	flagGzip      byte = 1 << 0
+	flagEncrypted byte = 1 << 1
+)
+
+// encodeBatch encodes requests of batch. Batch is compressed
+// before encryption, encryption is disabled if keyring is nil
+func encodeBatch(requests []Request, compression Compression, keyring *Keyring) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, req := range requests {
+		if err := req.Encode(&buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	if compression == CompressionNone && keyring == nil {
+		return buffer.Bytes(), nil
+	}
+
+	payload, err := Compress(buffer.Bytes(), compression)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []byte{recordMarker, 0}
+	if compression == CompressionGzip {
+		header[1] |= flagGzip
+	}
+
+	if keyring != nil {
+		header[1] |= flagEncrypted
+		payload, err = keyring.seal(payload, header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	copy(record, header)
+	binary.LittleEndian.PutUint32(record[2:], uint32(len(payload))) //nolint:gosec
+
+	return append(record, payload...), nil
+}
+
+// DecodeRequests decodes requests of segment data containing plain
+// batches and records. Keyring is required for encrypted records only
+func DecodeRequests(data []byte, keyring *Keyring) ([]Request, error) {
+	return decodeRequests(nil, data, keyring)
+}
+
+func decodeRequests(requests []Request, data []byte, keyring *Keyring) ([]Request, error) {
+	_, err := ScanRequests(data, keyring, func(_ int, request Request) {
+		requests = append(requests, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// ScanRequests decodes requests of segment data one by one and calls fn
+// with every request and offset of plain request or record containing it.
+// It returns size of data decoded successfully, so data is corrupted
+// from this offset if error is returned
+func ScanRequests(data []byte, keyring *Keyring, fn func(offset int, request Request)) (int, error) {
+	buffer := bytes.NewBuffer(data)
+	for buffer.Len() > 0 {
+		offset := len(data) - buffer.Len()
+
+		// preallocated segment can have zeroed tail after machine crash
+		// if its header was synced before data, such data wasn't acknowledged
+		if isZeroed(buffer.Bytes()) {
+			logger.Warn("WAL segment has zeroed tail, it is skipped")
+			return offset, nil
+		}
+
+		if buffer.Bytes()[0] == recordMarker {
+			payload, err := readRecord(buffer, keyring)
+			if err != nil {
+				return offset, err
+			}
+
+			var requests []Request
+			if _, err := ScanRequests(payload, keyring, func(_ int, request Request) {
+				requests = append(requests, request)
+			}); err != nil {
+				return offset, err
+			}
+
+			for _, request := range requests {
+				fn(offset, request)
+			}
+			continue
+		}
+
+		var request Request
+		if err := request.Decode(buffer); err != nil {
+			return offset, fmt.Errorf("failed to parse logs data: %w", err)
+		}
+
+		fn(offset, request)
+	}
+
+	return len(data), nil
+}
+
+// readRecord reads record from buffer and returns its plain payload
+func readRecord(buffer *bytes.Buffer, keyring *Keyring) ([]byte, error) {
+	header := buffer.Next(recordHeaderSize)
+	if len(header) < recordHeaderSize {
+		return nil, fmt.Errorf("failed to parse logs data: record header is truncated")
+	}
+
+	length := int(binary.LittleEndian.Uint32(header[2:]))
+	payload := buffer.Next(length)
+	if len(payload) < length {
+		return nil, fmt.Errorf("failed to parse logs data: record is truncated")
+	}
+
+	var err error
+
+	flags := header[1]
+	if flags&flagEncrypted != 0 {
+		payload, err = keyring.open(payload, header[:2])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if flags&flagGzip != 0 {
+		return Decompress(payload, CompressionGzip)
+	}
+
+	return payload, nil
+}
+
+func isZeroed(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,90 @@
+package wal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRequestsMixedBatches(t *testing.T) {
+	batches := []struct {
+		requests    []Request
+		compression Compression
+	}{
+		{
+			requests:    []Request{NewRequest("SET", []string{"key1", "value1"})},
+			compression: CompressionNone,
+		},
+		{
+			requests: []Request{
+				NewRequest("SET", []string{"key2", "value2"}),
+				NewRequest("DEL", []string{"key1"}),
+			},
+			compression: CompressionGzip,
+		},
+		{
+			requests:    []Request{NewRequest("SET", []string{"key3", "value3"})},
+			compression: CompressionNone,
+		},
+	}
+
+	var segment bytes.Buffer
+	var expected []Request
+	for _, batch := range batches {
+		data, err := encodeBatch(batch.requests, batch.compression, nil)
+		assert.NoError(t, err)
+
+		segment.Write(data)
+		for _, req := range batch.requests {
+			expected = append(expected, Request{Command: req.Command, Args: req.Args})
+		}
+	}
+
+	requests, err := DecodeRequests(segment.Bytes(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, requests)
+}
+
+func TestDecodeRequestsTruncatedRecord(t *testing.T) {
+	data, err := encodeBatch([]Request{NewRequest("SET", []string{"key", "value"})}, CompressionGzip, nil)
+	assert.NoError(t, err)
+
+	_, err = DecodeRequests(data[:len(data)-1], nil)
+	assert.Error(t, err)
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("SET key value\n"), 100)
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		compressed, err := Compress(data, compression)
+		assert.NoError(t, err)
+
+		decompressed, err := Decompress(compressed, compression)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	_, err := ParseCompression("zstd")
+	assert.Error(t, err)
+}
+
+func TestScanRequestsCorruptedTail(t *testing.T) {
+	first, err := encodeBatch([]Request{NewRequest("SET", []string{"key1", "value1"})}, CompressionNone, nil)
+	assert.NoError(t, err)
+
+	second, err := encodeBatch([]Request{NewRequest("SET", []string{"key2", "value2"})}, CompressionGzip, nil)
+	assert.NoError(t, err)
+
+	// second batch is torn by crash
+	data := append(append([]byte{}, first...), second[:len(second)/2]...)
+
+	var offsets []int
+	size, err := ScanRequests(data, nil, func(offset int, _ Request) {
+		offsets = append(offsets, offset)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, len(first), size)
+	assert.Equal(t, []int{0}, offsets)
+}
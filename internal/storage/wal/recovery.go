@@ -0,0 +1,74 @@
+package wal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"concurrency_go_course/internal/filesystem"
+)
+
+// RecoveryTarget is a point of WAL history to recover state to.
+// Requests written after time or starting from position are ignored
+type RecoveryTarget struct {
+	// Time limits requests by their write time, it is ignored if zero
+	Time time.Time
+	// Segment and Offset are a position of request as printed by
+	// waltool dump, position is ignored if segment is empty
+	Segment string
+	Offset  int
+}
+
+// ParseRecoveryTarget returns recovery target of RFC3339 time and position
+// formatted as segment:offset. It returns nil if both of them are empty
+func ParseRecoveryTarget(recoverTime, position string) (*RecoveryTarget, error) {
+	if recoverTime == "" && position == "" {
+		return nil, nil
+	}
+
+	var target RecoveryTarget
+
+	if recoverTime != "" {
+		t, err := time.Parse(time.RFC3339Nano, recoverTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recovery time %q: %w", recoverTime, err)
+		}
+		target.Time = t
+	}
+
+	if position != "" {
+		segment, offset, ok := strings.Cut(position, ":")
+		if !ok || segment == "" {
+			return nil, fmt.Errorf("invalid recovery position %q: expected segment:offset", position)
+		}
+
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid recovery position %q: offset must be non-negative number", position)
+		}
+		target.Segment, target.Offset = segment, n
+	}
+
+	return &target, nil
+}
+
+// reached reports whether request of segment at offset is after target.
+// Requests written by older versions have no timestamp, time of their
+// segment creation is used instead
+func (t *RecoveryTarget) reached(segment string, offset int, request Request) bool {
+	if t.Segment != "" && (segment > t.Segment || (segment == t.Segment && offset >= t.Offset)) {
+		return true
+	}
+
+	if t.Time.IsZero() {
+		return false
+	}
+
+	timestamp := request.Timestamp
+	if timestamp == 0 {
+		timestamp = time.UnixMilli(filesystem.SegmentTime(segment)).UnixNano()
+	}
+
+	return timestamp > t.Time.UnixNano()
+}
@@ -0,0 +1,138 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestParseRecoveryTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		time     string
+		position string
+		expected *RecoveryTarget
+		wantErr  bool
+	}{
+		{name: "empty"},
+		{
+			name:     "time",
+			time:     "2024-05-01T10:00:00.5Z",
+			expected: &RecoveryTarget{Time: time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)},
+		},
+		{
+			name:     "position",
+			position: "wal_1714557600000.log:128",
+			expected: &RecoveryTarget{Segment: "wal_1714557600000.log", Offset: 128},
+		},
+		{name: "invalid time", time: "yesterday", wantErr: true},
+		{name: "position without offset", position: "wal_1.log", wantErr: true},
+		{name: "negative offset", position: "wal_1.log:-1", wantErr: true},
+		{name: "position without segment", position: ":10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			target, err := ParseRecoveryTarget(tt.time, tt.position)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestRecoveryTargetReached(t *testing.T) {
+	t.Parallel()
+
+	target := &RecoveryTarget{Time: time.UnixMilli(2000)}
+
+	assert.False(t, target.reached("wal_1000.log", 0, Request{Timestamp: time.UnixMilli(2000).UnixNano()}))
+	assert.True(t, target.reached("wal_1000.log", 0, Request{Timestamp: time.UnixMilli(2001).UnixNano()}))
+	// requests without timestamp get time of segment creation
+	assert.False(t, target.reached("wal_1000.log", 0, Request{}))
+	assert.True(t, target.reached("wal_3000.log", 0, Request{}))
+
+	target = &RecoveryTarget{Segment: "wal_2000.log", Offset: 10}
+
+	assert.False(t, target.reached("wal_1000.log", 50, Request{}))
+	assert.False(t, target.reached("wal_2000.log", 9, Request{}))
+	assert.True(t, target.reached("wal_2000.log", 10, Request{}))
+	assert.True(t, target.reached("wal_3000.log", 0, Request{}))
+}
+
+func TestLogsManagerReadUntil(t *testing.T) {
+	t.Parallel()
+	logger.MockLogger()
+
+	dir := t.TempDir()
+	fileLib := filesystem.NewFileLib()
+
+	// every batch is written to own segment
+	logsManager, err := NewLogsManager(filesystem.NewSegment(dir, 1, fileLib), SyncModeAlways, 0)
+	require.NoError(t, err)
+
+	for _, batch := range [][]Request{
+		{NewRequest("SET", []string{"key1", "value1"}), NewRequest("SET", []string{"key2", "value2"})},
+		{NewRequest("DEL", []string{"key1"}), NewRequest("DEL", []string{"key2"})},
+		{NewRequest("SET", []string{"key3", "value3"})},
+	} {
+		logsManager.Write(batch)
+		for _, request := range batch {
+			require.NoError(t, <-request.Done())
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	segments, err := filesystem.NewSegment(dir, 1, fileLib).ReadSegments()
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	// position of the second delete
+	var position RecoveryTarget
+	_, err = ScanRequests(segments[1].Data, nil, func(offset int, request Request) {
+		if request.Args[0] == "key2" {
+			position = RecoveryTarget{Segment: segments[1].Name, Offset: offset}
+		}
+	})
+	require.NoError(t, err)
+
+	logsManager, err = NewLogsManager(filesystem.NewSegment(dir, 1, fileLib), SyncModeAlways, 0)
+	require.NoError(t, err)
+
+	requests, err := logsManager.ReadUntil(&position)
+	require.NoError(t, err)
+
+	commands := make([]string, 0, len(requests))
+	for _, request := range requests {
+		commands = append(commands, request.Command+" "+request.Args[0])
+	}
+	assert.Equal(t, []string{"SET key1", "SET key2", "DEL key1"}, commands)
+
+	// archived requests aren't recovered again
+	requests, err = logsManager.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, requests, 3)
+
+	archives, err := filepath.Glob(filepath.Join(dir, "recovery_*"))
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+
+	entries, err := os.ReadDir(archives[0])
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -2,6 +2,7 @@ package wal
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 )
 
@@ -9,7 +10,15 @@ import (
 type Request struct {
 	Command string
 	Args    []string
+	// Timestamp is a time of batch write in unix nanoseconds,
+	// it is zero for requests written by older versions
+	Timestamp int64
+	// DB is a logical database of request, requests written
+	// by older versions belong to database 0
+	DB int
 
+	// ctx is a context of writer, request isn't written if it is done
+	ctx        context.Context
 	doneStatus chan error
 }
 
@@ -23,6 +32,11 @@ func NewRequest(command string, args []string) Request {
 	}
 }
 
+// Done returns channel receiving result of request write
+func (r *Request) Done() <-chan error {
+	return r.doneStatus
+}
+
 // Encode encodes bytes
 func (r *Request) Encode(buffer *bytes.Buffer) error {
 	encoder := gob.NewEncoder(buffer)
@@ -0,0 +1,41 @@
+package wal
+
+import (
+	"fmt"
+)
+
+// SyncMode defines when WAL segments are synced to stable storage.
+// Writes are acknowledged after batch is written to segment file,
+// so sync mode defines which acknowledged writes can be lost on crash
+// of machine. Crash of process alone never loses acknowledged writes,
+// because written data is kept by OS page cache
+type SyncMode string
+
+const (
+	// SyncModeAlways syncs every batch before it is acknowledged.
+	// Acknowledged writes are never lost, every batch costs an fsync
+	SyncModeAlways SyncMode = "always"
+	// SyncModeInterval syncs segment at most once per sync interval.
+	// Writes acknowledged within the last interval before crash can be lost
+	SyncModeInterval SyncMode = "interval"
+	// SyncModeNone never syncs segment except on its rotation and leaves
+	// writeback to OS. Writes acknowledged within OS dirty page expiration
+	// time (30 seconds by default on Linux) before crash can be lost
+	SyncModeNone SyncMode = "none"
+)
+
+const (
+	defaultSyncMode     = SyncModeAlways
+	defaultSyncInterval = "100ms"
+)
+
+func parseSyncMode(mode string) (SyncMode, error) {
+	switch SyncMode(mode) {
+	case "":
+		return defaultSyncMode, nil
+	case SyncModeAlways, SyncModeInterval, SyncModeNone:
+		return SyncMode(mode), nil
+	}
+
+	return "", fmt.Errorf("unknown sync mode: %s", mode)
+}
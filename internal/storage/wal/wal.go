@@ -2,8 +2,10 @@ package wal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -28,20 +30,42 @@ type Settings struct {
 	FlushingBatchSize    int
 	FlushingBatchTimeout time.Duration
 	DataDirectory        string
+	SyncMode             SyncMode
+	SyncInterval         time.Duration
+	Preallocate          bool
+	Datasync             bool
+	Compression          Compression
+	Keyring              *Keyring
+	// RecoveryTarget limits recovered requests, all of them
+	// are recovered if it is nil
+	RecoveryTarget *RecoveryTarget
 }
 
-// WAL is a write ahead log struct
+// ErrStopped is returned if request is written to stopped WAL
+var ErrStopped = errors.New("WAL is stopped")
+
+// WAL is a write ahead log struct. Writes are group committed: requests
+// of concurrent writers are collected into a group which is written and
+// synced at once. Group is committed when it reaches flushing batch size
+// or flushing timeout passes since its first request. Requests pushed
+// while group is being written form the next group
 type WAL struct {
 	settings *Settings
 
 	logsManager LogsManager
+	// flushMutex serializes access to logs manager
+	flushMutex sync.Mutex
 
 	mutexBuffer sync.Mutex
 	buffer      []Request
+	stopped     bool
 
-	bufferCh chan []Request
+	// pending is signalled when request is pushed to empty buffer
+	pending chan struct{}
+	// full is signalled when buffer reaches flushing batch size
+	full chan struct{}
 
-	writeStatus <-chan error
+	done chan struct{}
 }
 
 // New creates new WAL
@@ -50,7 +74,7 @@ func New(cfg *config.WALCfg) (*WAL, error) {
 		return nil, fmt.Errorf("unable to create WAL: cfg is empty")
 	}
 
-	settings, err := walSettings(cfg)
+	settings, err := NewSettings(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -71,13 +95,18 @@ func New(cfg *config.WALCfg) (*WAL, error) {
 		}
 	}
 
+	return newWAL(settings, logsManager), nil
+}
+
+func newWAL(settings *Settings, logsManager LogsManager) *WAL {
 	return &WAL{
 		settings:    settings,
-		mutexBuffer: sync.Mutex{},
-		buffer:      make([]Request, 0),
-		bufferCh:    make(chan []Request, 1),
+		buffer:      make([]Request, 0, settings.FlushingBatchSize),
+		pending:     make(chan struct{}, 1),
+		full:        make(chan struct{}, 1),
 		logsManager: logsManager,
-	}, nil
+		done:        make(chan struct{}),
+	}
 }
 
 // Start initializes WAL
@@ -86,85 +115,278 @@ func (w *WAL) Start(ctx context.Context) {
 		zap.String("flushing_timeout", w.settings.FlushingBatchTimeout.String()),
 		zap.Int("flushing_batch_size", w.settings.FlushingBatchSize),
 		zap.Int("max_segment_size", w.settings.MaxSegmentSize),
+		zap.String("sync_mode", string(w.settings.SyncMode)),
+		zap.String("sync_interval", w.settings.SyncInterval.String()),
+		zap.Bool("preallocate", w.settings.Preallocate),
+		zap.Bool("datasync", w.settings.Datasync),
+		zap.String("compression", string(w.settings.Compression)),
+		zap.Bool("encryption", w.settings.Keyring != nil),
 	)
 
 	go func() {
-		ticker := time.NewTicker(w.settings.FlushingBatchTimeout)
-		defer ticker.Stop()
+		defer close(w.done)
+
+		timer := time.NewTimer(w.settings.FlushingBatchTimeout)
+		defer timer.Stop()
+
+		// in interval mode batches written after the last sync
+		// are synced by timer if there are no new batches
+		var syncCh <-chan time.Time
+		if w.settings.SyncMode == SyncModeInterval {
+			syncTicker := time.NewTicker(w.settings.SyncInterval)
+			defer syncTicker.Stop()
+			syncCh = syncTicker.C
+		}
 
 		for {
+			// wait for the first request of group
 			select {
 			case <-ctx.Done():
-				w.flushBatch()
-				logger.Debug("Batch was flushed by ctx")
+				w.stop()
 				return
-			default:
+			case <-syncCh:
+				w.sync()
+				continue
+			case <-w.pending:
 			}
 
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(w.settings.FlushingBatchTimeout)
+
 			select {
 			case <-ctx.Done():
-				w.flushBatch()
-				logger.Debug("Batch was flushed by ctx")
+				w.stop()
 				return
-			case batch := <-w.bufferCh:
-				w.logsManager.Write(batch)
-				ticker.Reset(w.settings.FlushingBatchTimeout * time.Second)
+			case <-w.full:
 				logger.Debug("Batch was flushed by buffer")
-			case <-ticker.C:
-				w.flushBatch()
+			case <-timer.C:
 				logger.Debug("Batch was flushed by timeout")
 			}
+
+			w.flushBatch()
 		}
 	}()
 }
 
-// Recover recover from files
+// Done returns channel which is closed when WAL is stopped
+// by context and the last batch is flushed
+func (w *WAL) Done() <-chan struct{} {
+	return w.done
+}
+
+// Recover recover from files. If recovery target is set, requests
+// after it are ignored and archived
 func (w *WAL) Recover() ([]Request, error) {
+	if w.settings.RecoveryTarget != nil {
+		return w.logsManager.ReadUntil(w.settings.RecoveryTarget)
+	}
+
 	return w.logsManager.ReadAll()
 }
 
-// Set sets new value
+// Set sets new value of key in database 0
 func (w *WAL) Set(key, value string) error {
-	w.push(compute.CommandSet, []string{key, value})
-
-	return <-w.writeStatus
+	return w.Log(context.Background(), 0, compute.CommandSet, []string{key, value})
 }
 
-// Del deletes key
+// Del deletes key of database 0
 func (w *WAL) Del(key string) error {
-	w.push(compute.CommandDelete, []string{key})
+	return w.Log(context.Background(), 0, compute.CommandDelete, []string{key})
+}
+
+// Log writes request with any command of logical database db to WAL.
+// If context is done before request is taken into group commit, request
+// is dropped and context error is returned. Request taken into group
+// is written anyway, so returned error always tells whether it was written
+func (w *WAL) Log(ctx context.Context, db int, cmd string, args []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := w.push(ctx, db, cmd, args)
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	if w.drop(done) {
+		return ctx.Err()
+	}
+
+	return <-done
+}
 
-	return <-w.writeStatus
+// Push adds request of logical database db to current group without
+// waiting for it to be committed. Requests pushed one after another
+// are written in the same order. Returned channel receives result
+// of group commit
+func (w *WAL) Push(db int, cmd string, args []string) <-chan error {
+	return w.push(context.Background(), db, cmd, args)
 }
 
-func (w *WAL) push(cmd string, args []string) {
+// LogAll writes requests with any commands to WAL. All requests are
+// pushed before waiting, so they are committed by a few large groups
+// instead of a group commit wait per request. It returns the first error
+// of group commit, requests of other groups can be written anyway
+func (w *WAL) LogAll(requests []Request) error {
+	handles := make([]<-chan error, 0, len(requests))
+	for _, request := range requests {
+		handles = append(handles, w.push(context.Background(), request.DB, request.Command, request.Args))
+	}
+
+	var err error
+	for _, handle := range handles {
+		if pushErr := <-handle; pushErr != nil && err == nil {
+			err = pushErr
+		}
+	}
+
+	return err
+}
+
+// push adds request to current group and returns its completion handle,
+// which receives result of group commit. Request is dropped from group
+// if context is done before group is written
+func (w *WAL) push(ctx context.Context, db int, cmd string, args []string) <-chan error {
 	request := NewRequest(cmd, args)
+	request.DB = db
+	request.ctx = ctx
 
 	w.mutexBuffer.Lock()
+	defer w.mutexBuffer.Unlock()
+
+	if w.stopped {
+		request.doneStatus <- ErrStopped
+		close(request.doneStatus)
+		return request.doneStatus
+	}
+
 	w.buffer = append(w.buffer, request)
+	if len(w.buffer) == 1 {
+		notify(w.pending)
+	}
 	if len(w.buffer) == w.settings.FlushingBatchSize {
-		w.bufferCh <- w.buffer
-		w.buffer = nil
+		notify(w.full)
+	}
+
+	return request.doneStatus
+}
+
+// drop removes request with completion handle done from current group.
+// It returns false if group of request is already taken to be written
+func (w *WAL) drop(done <-chan error) bool {
+	w.mutexBuffer.Lock()
+	defer w.mutexBuffer.Unlock()
+
+	for i, request := range w.buffer {
+		if request.doneStatus == done {
+			w.buffer = slices.Delete(w.buffer, i, i+1)
+			return true
+		}
+	}
+
+	return false
+}
+
+// notify signals channel without blocking, signal isn't repeated
+// until previous one is received
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
 	}
+}
+
+// stop flushes the last batch. Unsynced batches are synced
+// unless sync is left to OS
+func (w *WAL) stop() {
+	w.mutexBuffer.Lock()
+	w.stopped = true
 	w.mutexBuffer.Unlock()
 
-	w.writeStatus = request.doneStatus
+	w.flushBatch()
+	logger.Debug("Batch was flushed by ctx")
+
+	if w.settings.SyncMode == SyncModeNone {
+		return
+	}
+
+	w.sync()
+}
+
+// Rotate commits requests pushed before it and closes current segment,
+// so later requests are written to new one. It returns name of the last
+// segment containing requests committed before rotation, it is empty
+// if nothing was written yet
+func (w *WAL) Rotate() (string, error) {
+	w.flushMutex.Lock()
+	defer w.flushMutex.Unlock()
+
+	w.flushBatchLocked()
+	return w.logsManager.Rotate()
+}
+
+// Settings returns copy of WAL settings
+func (w *WAL) Settings() Settings {
+	return *w.settings
+}
+
+func (w *WAL) sync() {
+	w.flushMutex.Lock()
+	defer w.flushMutex.Unlock()
+
+	if err := w.logsManager.Sync(); err != nil {
+		logger.ErrorWithMsg("failed to sync WAL:", err)
+	}
 }
 
 func (w *WAL) flushBatch() {
+	w.flushMutex.Lock()
+	defer w.flushMutex.Unlock()
+
+	w.flushBatchLocked()
+}
+
+func (w *WAL) flushBatchLocked() {
 	var batch []Request
 
 	w.mutexBuffer.Lock()
 	batch = w.buffer
-	w.buffer = nil
+	w.buffer = make([]Request, 0, w.settings.FlushingBatchSize)
+	// group could reach batch size while timeout was handled
+	select {
+	case <-w.full:
+	default:
+	}
 	w.mutexBuffer.Unlock()
 
+	// requests whose context is done while they waited for group
+	// aren't written, their writers get context error
+	batch = slices.DeleteFunc(batch, func(request Request) bool {
+		if request.ctx == nil || request.ctx.Err() == nil {
+			return false
+		}
+
+		request.doneStatus <- request.ctx.Err()
+		close(request.doneStatus)
+		return true
+	})
+
 	if len(batch) != 0 {
 		w.logsManager.Write(batch)
 	}
 }
 
-func walSettings(cfg *config.WALCfg) (*Settings, error) {
+// NewSettings returns WAL settings of config with defaults
+// for values which aren't set
+func NewSettings(cfg *config.WALCfg) (*Settings, error) {
 	segmentSize, err := parser.ParseSize(defaultMaxSegmentSize)
 	if err != nil {
 		return nil, err
@@ -175,11 +397,46 @@ func walSettings(cfg *config.WALCfg) (*Settings, error) {
 		return nil, err
 	}
 
+	syncInterval, err := time.ParseDuration(defaultSyncInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	syncMode, err := parseSyncMode(cfg.WalConfig.SyncMode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create WAL: %w", err)
+	}
+
+	compression, err := ParseCompression(cfg.WalConfig.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create WAL: %w", err)
+	}
+
+	var keyring *Keyring
+	if cfg.WalConfig.Encryption != nil {
+		keyring, err = NewKeyring(cfg.WalConfig.Encryption)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create WAL: %w", err)
+		}
+	}
+
+	recoveryTarget, err := ParseRecoveryTarget(cfg.WalConfig.RecoverToTime, cfg.WalConfig.RecoverToPosition)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create WAL: %w", err)
+	}
+
 	settings := Settings{
 		MaxSegmentSize:       segmentSize,
 		FlushingBatchTimeout: timeout,
 		FlushingBatchSize:    defaultFlushingBatchSize,
 		DataDirectory:        cfg.WalConfig.DataDirectory,
+		SyncMode:             syncMode,
+		SyncInterval:         syncInterval,
+		Preallocate:          cfg.WalConfig.Preallocate,
+		Datasync:             cfg.WalConfig.Datasync,
+		Compression:          compression,
+		Keyring:              keyring,
+		RecoveryTarget:       recoveryTarget,
 	}
 
 	segmentSize, err = parser.ParseSize(cfg.WalConfig.MaxSegmentSize)
@@ -196,16 +453,30 @@ func walSettings(cfg *config.WALCfg) (*Settings, error) {
 		settings.FlushingBatchTimeout = batchTimeout
 	}
 
+	interval, err := time.ParseDuration(cfg.WalConfig.SyncInterval)
+	if err == nil && interval != 0 {
+		settings.SyncInterval = interval
+	}
+
 	return &settings, nil
 }
 
 func getLogsManager(settings *Settings) (LogsManager, error) {
 	fileLib := filesystem.NewFileLib()
 
+	opts := []filesystem.SegmentOption{filesystem.WithMetrics()}
+	if settings.Preallocate {
+		opts = append(opts, filesystem.WithPreallocation())
+	}
+	if settings.Datasync {
+		opts = append(opts, filesystem.WithDatasync())
+	}
+
 	segment := filesystem.NewSegment(settings.DataDirectory,
-		settings.MaxSegmentSize, fileLib)
+		settings.MaxSegmentSize, fileLib, opts...)
 
-	logsManager, err := NewLogsManager(segment)
+	logsManager, err := NewLogsManager(segment, settings.SyncMode, settings.SyncInterval,
+		WithCompression(settings.Compression), WithEncryption(settings.Keyring))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,82 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/pkg/logger"
+)
+
+// BenchmarkWAL_ConcurrentWriters measures throughput and latency of writes
+// synced to disk by concurrent writers. Group commit shares one fsync
+// between writers, so throughput grows with number of writers
+func BenchmarkWAL_ConcurrentWriters(b *testing.B) {
+	logger.MockLogger()
+
+	for _, writers := range []int{1, 4, 16, 64, 256} {
+		b.Run(fmt.Sprintf("writers=%d", writers), func(b *testing.B) {
+			wal, err := New(&config.WALCfg{
+				WalConfig: &config.WALSettings{
+					FlushingBatchSize:    writers,
+					FlushingBatchTimeout: "1ms",
+					MaxSegmentSize:       "64MB",
+					DataDirectory:        b.TempDir(),
+					SyncMode:             string(SyncModeAlways),
+				},
+			})
+			if err != nil {
+				b.Fatalf("unable to create WAL: %s", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wal.Start(ctx)
+
+			var next atomic.Int64
+			latencies := make([][]time.Duration, writers)
+
+			b.ResetTimer()
+
+			var wg sync.WaitGroup
+			wg.Add(writers)
+			for i := range writers {
+				go func() {
+					defer wg.Done()
+
+					for n := next.Add(1); n <= int64(b.N); n = next.Add(1) {
+						start := time.Now()
+						if err := wal.Set(fmt.Sprintf("key%d", n), "value"); err != nil {
+							b.Errorf("unable to write: %s", err)
+							return
+						}
+						latencies[i] = append(latencies[i], time.Since(start))
+					}
+				}()
+			}
+			wg.Wait()
+
+			b.StopTimer()
+			cancel()
+			<-wal.Done()
+
+			all := slices.Concat(latencies...)
+			slices.Sort(all)
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "writes/s")
+			b.ReportMetric(float64(percentile(all, 0.5).Microseconds()), "p50-us")
+			b.ReportMetric(float64(percentile(all, 0.99).Microseconds()), "p99-us")
+		})
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
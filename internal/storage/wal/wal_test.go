@@ -39,6 +39,9 @@ func TestNewWAL(t *testing.T) {
 				FlushingBatchTimeout: 100 * time.Millisecond,
 				MaxSegmentSize:       1024 * 1024,
 				DataDirectory:        "tmp",
+				SyncMode:             SyncModeAlways,
+				SyncInterval:         100 * time.Millisecond,
+				Compression:          CompressionNone,
 			},
 		},
 		{
@@ -56,6 +59,9 @@ func TestNewWAL(t *testing.T) {
 				FlushingBatchTimeout: 100 * time.Millisecond,
 				MaxSegmentSize:       1024 * 1024,
 				DataDirectory:        "tmp",
+				SyncMode:             SyncModeAlways,
+				SyncInterval:         100 * time.Millisecond,
+				Compression:          CompressionNone,
 			},
 		},
 		{
@@ -73,6 +79,9 @@ func TestNewWAL(t *testing.T) {
 				FlushingBatchTimeout: 10 * time.Millisecond,
 				MaxSegmentSize:       1024 * 1024,
 				DataDirectory:        "tmp",
+				SyncMode:             SyncModeAlways,
+				SyncInterval:         100 * time.Millisecond,
+				Compression:          CompressionNone,
 			},
 		},
 		{
@@ -90,6 +99,9 @@ func TestNewWAL(t *testing.T) {
 				FlushingBatchTimeout: 20 * time.Millisecond,
 				MaxSegmentSize:       10 * 1024 * 1024,
 				DataDirectory:        "tmp",
+				SyncMode:             SyncModeAlways,
+				SyncInterval:         100 * time.Millisecond,
+				Compression:          CompressionNone,
 			},
 		},
 	}
@@ -116,6 +128,16 @@ func TestNewWALNeg(t *testing.T) {
 			cfg:  nil,
 			err:  fmt.Errorf("unable to create WAL: cfg is empty"),
 		},
+		{
+			name: "Unknown sync mode (error)",
+			cfg: &config.WALCfg{
+				WalConfig: &config.WALSettings{
+					DataDirectory: "tmp",
+					SyncMode:      "sometimes",
+				},
+			},
+			err: fmt.Errorf("unable to create WAL: %w", fmt.Errorf("unknown sync mode: sometimes")),
+		},
 	}
 
 	for _, tt := range tests {
@@ -265,3 +287,179 @@ func TestWAL_Recover(t *testing.T) {
 		t.Errorf("recover error: got args %+v, expected %+v", requests[2].Args, []string{"lemmy"})
 	}
 }
+
+// recordingLogsManager records written requests
+type recordingLogsManager struct {
+	mutex    sync.Mutex
+	written  map[string]struct{}
+	batches  int
+	writeErr error
+}
+
+func (l *recordingLogsManager) Write(requests []Request) {
+	l.mutex.Lock()
+	for _, req := range requests {
+		l.written[req.Args[0]] = struct{}{}
+	}
+	l.batches++
+	l.mutex.Unlock()
+
+	for _, req := range requests {
+		req.doneStatus <- l.writeErr
+		close(req.doneStatus)
+	}
+}
+
+func (l *recordingLogsManager) Sync() error { return nil }
+
+func (l *recordingLogsManager) ReadAll() ([]Request, error) { return nil, nil }
+
+func (l *recordingLogsManager) ReadUntil(*RecoveryTarget) ([]Request, error) { return nil, nil }
+
+func (l *recordingLogsManager) Rotate() (string, error) { return "", nil }
+
+func (l *recordingLogsManager) isWritten(key string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, ok := l.written[key]
+	return ok
+}
+
+func TestWAL_ConcurrentWriters(t *testing.T) {
+	logger.MockLogger()
+
+	logsManager := &recordingLogsManager{written: make(map[string]struct{})}
+	wal := newWAL(&Settings{
+		FlushingBatchSize:    10,
+		FlushingBatchTimeout: time.Millisecond,
+	}, logsManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wal.Start(ctx)
+
+	const writers = 100
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := range writers {
+		go func() {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", i)
+			assert.NoError(t, wal.Set(key, "value"))
+			// request is acknowledged by its own group commit only
+			assert.True(t, logsManager.isWritten(key), "key %s is not written", key)
+		}()
+	}
+	wg.Wait()
+
+	assert.Less(t, logsManager.batches, writers)
+}
+
+func TestWAL_WriteError(t *testing.T) {
+	logger.MockLogger()
+
+	writeErr := fmt.Errorf("disk is full")
+	logsManager := &recordingLogsManager{written: make(map[string]struct{}), writeErr: writeErr}
+	wal := newWAL(&Settings{
+		FlushingBatchSize:    10,
+		FlushingBatchTimeout: time.Millisecond,
+	}, logsManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wal.Start(ctx)
+
+	assert.Equal(t, writeErr, wal.Del("key"))
+}
+
+func TestWAL_WriteAfterStop(t *testing.T) {
+	logger.MockLogger()
+
+	logsManager := &recordingLogsManager{written: make(map[string]struct{})}
+	wal := newWAL(&Settings{
+		FlushingBatchSize:    10,
+		FlushingBatchTimeout: time.Hour,
+	}, logsManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wal.Start(ctx)
+
+	status := wal.push(context.Background(), 0, compute.CommandSet, []string{"key", "value"})
+	cancel()
+	<-wal.Done()
+
+	// pending group is flushed on stop
+	assert.NoError(t, <-status)
+	assert.True(t, logsManager.isWritten("key"))
+
+	assert.ErrorIs(t, wal.Set("key", "value"), ErrStopped)
+}
+
+func TestWAL_LogAll(t *testing.T) {
+	logger.MockLogger()
+
+	logsManager := &recordingLogsManager{written: make(map[string]struct{})}
+	wal := newWAL(&Settings{
+		FlushingBatchSize:    100,
+		FlushingBatchTimeout: time.Millisecond,
+	}, logsManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wal.Start(ctx)
+
+	const keys = 1000
+
+	requests := make([]Request, 0, keys)
+	for i := range keys {
+		requests = append(requests, Request{Command: compute.CommandSet, Args: []string{fmt.Sprintf("key%d", i), "value"}})
+	}
+
+	assert.NoError(t, wal.LogAll(requests))
+	for i := range keys {
+		assert.True(t, logsManager.isWritten(fmt.Sprintf("key%d", i)))
+	}
+	// requests aren't committed one by one
+	assert.Less(t, logsManager.batches, keys/10)
+}
+
+func TestWAL_LogContext(t *testing.T) {
+	logger.MockLogger()
+
+	logsManager := &recordingLogsManager{written: make(map[string]struct{})}
+	wal := newWAL(&Settings{
+		FlushingBatchSize:    100,
+		FlushingBatchTimeout: 50 * time.Millisecond,
+	}, logsManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wal.Start(ctx)
+
+	expired, cancelExpired := context.WithCancel(context.Background())
+	cancelExpired()
+	assert.ErrorIs(t, wal.Log(expired, 0, compute.CommandSet, []string{"expired", "value"}), context.Canceled)
+
+	// request is dropped from group when deadline is exceeded before commit
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, wal.Log(context.Background(), 0, compute.CommandSet, []string{"kept", "value"}))
+	}()
+
+	start := time.Now()
+	assert.ErrorIs(t, wal.Log(timeout, 0, compute.CommandSet, []string{"dropped", "value"}), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	wg.Wait()
+	assert.True(t, logsManager.isWritten("kept"))
+	assert.False(t, logsManager.isWritten("dropped"))
+	assert.False(t, logsManager.isWritten("expired"))
+}
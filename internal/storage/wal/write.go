@@ -0,0 +1,41 @@
+package wal
+
+import (
+	"fmt"
+
+	"concurrency_go_course/internal/filesystem"
+)
+
+const writeBatchSize = 1000
+
+// WriteRequests writes requests to new segments in dir using segment size,
+// compression and encryption of settings. Requests are written by batches
+// without sync, segments are synced once when all of them are written
+func WriteRequests(dir string, settings *Settings, requests []Request) error {
+	segment := filesystem.NewSegment(dir, settings.MaxSegmentSize, filesystem.NewFileLib())
+	logsManager, err := NewLogsManager(segment, SyncModeNone, 0,
+		WithCompression(settings.Compression), WithEncryption(settings.Keyring))
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(requests); start += writeBatchSize {
+		end := min(start+writeBatchSize, len(requests))
+
+		batch := make([]Request, 0, end-start)
+		for _, request := range requests[start:end] {
+			written := NewRequest(request.Command, request.Args)
+			written.DB = request.DB
+			batch = append(batch, written)
+		}
+
+		logsManager.Write(batch)
+		for _, request := range batch {
+			if err := <-request.Done(); err != nil {
+				return fmt.Errorf("unable to write requests: %w", err)
+			}
+		}
+	}
+
+	return logsManager.Sync()
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// watchers is a registry of clients waiting for key changes.
+// It has own lock, so partition locks are not held while waiting
+type watchers struct {
+	mutex   sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+	// subscribers are signaled on every change until they are removed
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func newWatchers() *watchers {
+	return &watchers{
+		waiters:     make(map[string]map[chan struct{}]struct{}),
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// register adds waiter for key. Returned channel is closed on key change
+func (w *watchers) register(key string) chan struct{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	waiter := make(chan struct{})
+
+	keyWaiters, ok := w.waiters[key]
+	if !ok {
+		keyWaiters = make(map[chan struct{}]struct{})
+		w.waiters[key] = keyWaiters
+	}
+	keyWaiters[waiter] = struct{}{}
+
+	return waiter
+}
+
+func (w *watchers) unregister(key string, waiter chan struct{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	remove(w.waiters, key, waiter)
+}
+
+// subscribe adds subscriber of key. Returned channel is signaled on every
+// key change, changes made before signal is received are signaled once
+func (w *watchers) subscribe(key string) chan struct{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	subscriber := make(chan struct{}, 1)
+
+	keySubscribers, ok := w.subscribers[key]
+	if !ok {
+		keySubscribers = make(map[chan struct{}]struct{})
+		w.subscribers[key] = keySubscribers
+	}
+	keySubscribers[subscriber] = struct{}{}
+
+	return subscriber
+}
+
+func (w *watchers) unsubscribe(key string, subscriber chan struct{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	remove(w.subscribers, key, subscriber)
+}
+
+func remove(registry map[string]map[chan struct{}]struct{}, key string, ch chan struct{}) {
+	keyChannels := registry[key]
+	delete(keyChannels, ch)
+	if len(keyChannels) == 0 {
+		delete(registry, key)
+	}
+}
+
+// wake wakes up all waiters of key and signals its subscribers
+func (w *watchers) wake(key string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for waiter := range w.waiters[key] {
+		close(waiter)
+	}
+	delete(w.waiters, key)
+
+	for subscriber := range w.subscribers[key] {
+		select {
+		case subscriber <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch blocks until value of key is changed or deleted and returns
+// new value. Context error is returned if context is done first.
+// Only string values are watched, wrong type error is returned if key
+// holds value of another type before or after change
+func (s *storage) Watch(ctx context.Context, key string) (string, bool, error) {
+	waiter := s.watchers.register(key)
+
+	// type is checked after registration, so change isn't missed
+	if _, _, err := s.engine.GetString(key); err != nil {
+		s.watchers.unregister(key, waiter)
+		return "", false, err
+	}
+
+	select {
+	case <-waiter:
+	case <-ctx.Done():
+		s.watchers.unregister(key, waiter)
+		return "", false, ctx.Err()
+	}
+
+	return s.engine.GetString(key)
+}
+
+// Subscribe returns channel signaled on every change or deletion of key
+// until returned func is called. Changes made before signal is received
+// are signaled once, so subscriber reads the latest value and misses none
+func (s *storage) Subscribe(key string) (<-chan struct{}, func()) {
+	subscriber := s.watchers.subscribe(key)
+
+	return subscriber, func() {
+		s.watchers.unsubscribe(key, subscriber)
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	logger.MockLogger()
+
+	t.Run("wake on set", func(t *testing.T) {
+		stor, err := New(NewEngine(4), nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = stor.Set(context.Background(), "key1", "value1")
+		}()
+
+		value, found, err := stor.Watch(context.Background(), "key1")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "value1", value)
+	})
+
+	t.Run("wake on delete", func(t *testing.T) {
+		stor, err := New(NewEngine(4), nil, "master", nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, stor.Set(context.Background(), "key1", "value1"))
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = stor.Del(context.Background(), "key1")
+		}()
+
+		_, found, err := stor.Watch(context.Background(), "key1")
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("wake on replication stream", func(t *testing.T) {
+		stream := make(chan []wal.Request)
+		stor, err := New(NewEngine(4), nil, "slave", stream, nil)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			stream <- []wal.Request{wal.NewRequest("SET", []string{"key1", "value1"})}
+		}()
+
+		value, found, err := stor.Watch(context.Background(), "key1")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "value1", value)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		st, err := New(NewEngine(4), nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err = st.Watch(ctx, "key1")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		stor := st.(*storage)
+		require.Empty(t, stor.watchers.waiters)
+	})
+	t.Run("wrong type", func(t *testing.T) {
+		st, err := New(NewEngine(4), nil, "master", nil, nil)
+		require.NoError(t, err)
+		_, err = st.HSet(context.Background(), "hash", []string{"field", "value"})
+		require.NoError(t, err)
+
+		// key holding another type isn't watched
+		_, _, err = st.Watch(context.Background(), "hash")
+		require.ErrorIs(t, err, ErrWrongType)
+
+		stor := st.(*storage)
+		require.Empty(t, stor.watchers.waiters)
+
+		// key changed to another type isn't returned as missing
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = st.RPush(context.Background(), "list", []string{"value"})
+		}()
+
+		_, _, err = st.Watch(context.Background(), "list")
+		require.ErrorIs(t, err, ErrWrongType)
+	})
+	t.Run("subscription signals every change until it is removed", func(t *testing.T) {
+		st, err := New(NewEngine(4), nil, "master", nil, nil)
+		require.NoError(t, err)
+
+		changes, unsubscribe := st.Subscribe("key1")
+
+		// changes made before signal is received are signaled once
+		require.NoError(t, st.Set(context.Background(), "key1", "value1"))
+		require.NoError(t, st.Set(context.Background(), "key1", "value2"))
+		<-changes
+		require.Empty(t, changes)
+
+		require.NoError(t, st.Del(context.Background(), "key1"))
+		<-changes
+
+		unsubscribe()
+		stor := st.(*storage)
+		require.Empty(t, stor.watchers.subscribers)
+	})
+}
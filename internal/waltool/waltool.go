@@ -0,0 +1,286 @@
+// Package waltool provides offline inspection and repair of WAL segments
+package waltool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+)
+
+const (
+	defaultPartitionsNumber = 256
+	defaultMaxSegmentSize   = 10 << 20
+)
+
+// ErrCorrupted is returned if segment can't be decoded
+var ErrCorrupted = errors.New("WAL is corrupted")
+
+// Settings is a struct of WAL settings needed to read and write segments
+type Settings struct {
+	Keyring        *wal.Keyring
+	Compression    wal.Compression
+	MaxSegmentSize int
+}
+
+// Tool is a WAL tool working with segments of data directory
+type Tool struct {
+	dir      string
+	settings Settings
+	fileLib  filesystem.FileLib
+	out      io.Writer
+}
+
+// New returns new tool writing its output to out
+func New(dir string, settings Settings, out io.Writer) *Tool {
+	if settings.Compression == "" {
+		settings.Compression = wal.CompressionNone
+	}
+	if settings.MaxSegmentSize == 0 {
+		settings.MaxSegmentSize = defaultMaxSegmentSize
+	}
+
+	return &Tool{
+		dir:      dir,
+		settings: settings,
+		fileLib:  filesystem.NewFileLib(),
+		out:      out,
+	}
+}
+
+// Record is a request of segment. Segment and offset are position of
+// request which can be used as recovery target. Time of write is empty
+// for requests written by older versions, DB is omitted for database 0
+type Record struct {
+	Segment string   `json:"segment"`
+	Offset  int      `json:"offset"`
+	Time    string   `json:"time,omitempty"`
+	DB      int      `json:"db,omitempty"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// segmentInfo is a result of segment scan
+type segmentInfo struct {
+	name     string
+	size     int
+	requests int
+	// valid is size of data decoded successfully
+	valid int
+	err   error
+}
+
+// List prints segments with their sizes and numbers of requests
+func (t *Tool) List() error {
+	infos, err := t.scan(nil)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(t.out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "SEGMENT\tSIZE\tREQUESTS\tSTATUS")
+	for _, info := range infos {
+		status := "ok"
+		if info.err != nil {
+			status = fmt.Sprintf("corrupted at offset %d", info.valid)
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%d\t%d\t%s\n", info.name, info.size, info.requests, status)
+	}
+
+	return writer.Flush()
+}
+
+// Dump prints requests of segments as JSON lines. All segments are
+// dumped if segment name is empty. Offsets are relative to written data
+// of segment, header of preallocated segment isn't counted
+func (t *Tool) Dump(segment string) error {
+	encoder := json.NewEncoder(t.out)
+
+	var encodeErr error
+	infos, err := t.scan(func(name string, offset int, request wal.Request) {
+		if encodeErr != nil || (segment != "" && name != segment) {
+			return
+		}
+
+		record := Record{
+			Segment: name,
+			Offset:  offset,
+			DB:      request.DB,
+			Command: request.Command,
+			Args:    request.Args,
+		}
+		if request.Timestamp != 0 {
+			record.Time = time.Unix(0, request.Timestamp).UTC().Format(time.RFC3339Nano)
+		}
+
+		encodeErr = encoder.Encode(record)
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	return corrupted(infos)
+}
+
+// Verify checks that every segment decodes
+func (t *Tool) Verify() error {
+	infos, err := t.scan(nil)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.err != nil {
+			_, _ = fmt.Fprintf(t.out, "%s: corrupted at offset %d: %s\n", info.name, info.valid, info.err)
+			continue
+		}
+		_, _ = fmt.Fprintf(t.out, "%s: ok, %d requests\n", info.name, info.requests)
+	}
+
+	return corrupted(infos)
+}
+
+// Truncate cuts corrupted tail of segment, the last segment is truncated
+// if segment name is empty. Requests decoded before corruption are kept
+func (t *Tool) Truncate(segment string, dryRun bool) error {
+	infos, err := t.scan(nil)
+	if err != nil {
+		return err
+	}
+
+	if len(infos) == 0 {
+		return fmt.Errorf("no segments found in %s", t.dir)
+	}
+
+	info := infos[len(infos)-1]
+	if segment != "" {
+		found := false
+		for _, i := range infos {
+			if i.name == segment {
+				info, found = i, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("segment %s isn't found", segment)
+		}
+	}
+
+	if info.err == nil {
+		_, _ = fmt.Fprintf(t.out, "%s: ok, nothing to truncate\n", info.name)
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(t.out, "%s: cutting %d bytes from offset %d: %s\n",
+		info.name, info.size-info.valid, info.valid, info.err)
+	if dryRun {
+		return nil
+	}
+
+	return filesystem.TruncateSegment(filepath.Join(t.dir, info.name), info.valid)
+}
+
+// Replay applies requests of all segments and writes state of database
+// to fresh segments in out directory. Out directory can be used as data
+// directory, it has one request per key, so it's faster to recover
+func (t *Tool) Replay(outDir string) error {
+	var requests []wal.Request
+	infos, err := t.scan(func(_ string, _ int, request wal.Request) {
+		requests = append(requests, request)
+	})
+	if err != nil {
+		return err
+	}
+	if err := corrupted(infos); err != nil {
+		return fmt.Errorf("%w, truncate corrupted tail before replay", err)
+	}
+
+	stor, err := storage.New(storage.NewEngine(defaultPartitionsNumber), nil, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	stor.Restore(requests)
+
+	if err := emptyDir(outDir); err != nil {
+		return err
+	}
+
+	snapshot := stor.Snapshot()
+	if err := wal.WriteRequests(outDir, &wal.Settings{
+		MaxSegmentSize: t.settings.MaxSegmentSize,
+		Compression:    t.settings.Compression,
+		Keyring:        t.settings.Keyring,
+	}, snapshot); err != nil {
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(t.out, "replayed %d requests of %d segments into %d requests in %s\n",
+		len(requests), len(infos), len(snapshot), outDir)
+	return nil
+}
+
+// scan decodes all segments of directory and calls fn with their requests
+func (t *Tool) scan(fn func(segment string, offset int, request wal.Request)) ([]segmentInfo, error) {
+	filenames, err := t.fileLib.FilenamesFromDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]segmentInfo, 0, len(filenames))
+	for _, name := range filenames {
+		data, err := t.fileLib.DataFromFiles(t.dir, []string{name})
+		if err != nil {
+			return nil, err
+		}
+
+		payload := filesystem.SegmentPayload(data[0])
+		info := segmentInfo{name: name, size: len(payload)}
+		info.valid, info.err = wal.ScanRequests(payload, t.settings.Keyring,
+			func(offset int, request wal.Request) {
+				info.requests++
+				if fn != nil {
+					fn(name, offset, request)
+				}
+			})
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func corrupted(infos []segmentInfo) error {
+	for _, info := range infos {
+		if info.err != nil {
+			return fmt.Errorf("%w: segment %s at offset %d: %w", ErrCorrupted, info.name, info.valid, info.err)
+		}
+	}
+
+	return nil
+}
+
+func emptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0o750)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(entries) != 0 {
+		return fmt.Errorf("directory %s isn't empty", dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package waltool
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"concurrency_go_course/internal/filesystem"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.MockLogger()
+	os.Exit(m.Run())
+}
+
+// writeSegment writes batches to new segment of dir
+func writeSegment(t *testing.T, dir string, batches ...[]wal.Request) string {
+	t.Helper()
+
+	before, _ := filesystem.NewFileLib().FilenamesFromDir(dir)
+
+	segment := filesystem.NewSegment(dir, 1<<20, filesystem.NewFileLib())
+	logsManager, err := wal.NewLogsManager(segment, wal.SyncModeAlways, 0,
+		wal.WithCompression(wal.CompressionGzip))
+	assert.NoError(t, err)
+
+	for _, batch := range batches {
+		logsManager.Write(batch)
+		for _, request := range batch {
+			assert.NoError(t, <-request.Done())
+		}
+	}
+
+	after, err := filesystem.NewFileLib().FilenamesFromDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, after, len(before)+1)
+
+	return after[len(after)-1]
+}
+
+func testDir(t *testing.T) (string, string) {
+	dir := t.TempDir()
+
+	writeSegment(t, dir, []wal.Request{
+		wal.NewRequest("SET", []string{"key1", "value1"}),
+		wal.NewRequest("SET", []string{"key2", "value2"}),
+	})
+	// segment names are based on time in milliseconds
+	time.Sleep(2 * time.Millisecond)
+	last := writeSegment(t, dir,
+		[]wal.Request{wal.NewRequest("DEL", []string{"key1"})},
+		[]wal.Request{wal.NewRequest("RPUSH", []string{"list", "a", "b"})},
+	)
+
+	return dir, last
+}
+
+func TestListAndVerify(t *testing.T) {
+	dir, _ := testDir(t)
+
+	var out bytes.Buffer
+	tool := New(dir, Settings{}, &out)
+
+	assert.NoError(t, tool.List())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], " 2 ")
+	assert.Contains(t, lines[2], " 2 ")
+
+	out.Reset()
+	assert.NoError(t, tool.Verify())
+	assert.Equal(t, 2, strings.Count(out.String(), ": ok"))
+}
+
+func TestDump(t *testing.T) {
+	dir, last := testDir(t)
+
+	var out bytes.Buffer
+	assert.NoError(t, New(dir, Settings{}, &out).Dump(last))
+
+	var records []Record
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var record Record
+		assert.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+
+	assert.Len(t, records, 2)
+	assert.NotEmpty(t, records[0].Time)
+	records[0].Time = ""
+	assert.Equal(t, Record{Segment: last, Offset: 0, Command: "DEL", Args: []string{"key1"}}, records[0])
+	assert.Equal(t, "RPUSH", records[1].Command)
+	assert.Positive(t, records[1].Offset)
+}
+
+func TestTruncateCorruptedTail(t *testing.T) {
+	dir, last := testDir(t)
+
+	file, err := os.OpenFile(filepath.Join(dir, last), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte{0xC5, 0x01, 0xFF})
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	var out bytes.Buffer
+	tool := New(dir, Settings{}, &out)
+
+	assert.ErrorIs(t, tool.Verify(), ErrCorrupted)
+	assert.ErrorIs(t, tool.Replay(t.TempDir()), ErrCorrupted)
+
+	assert.NoError(t, tool.Truncate("", true))
+	assert.ErrorIs(t, tool.Verify(), ErrCorrupted)
+
+	assert.NoError(t, tool.Truncate("", false))
+	assert.NoError(t, tool.Verify())
+
+	out.Reset()
+	assert.NoError(t, tool.Dump(last))
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"))
+}
+
+func TestReplay(t *testing.T) {
+	dir, _ := testDir(t)
+	outDir := filepath.Join(t.TempDir(), "snapshot")
+
+	var out bytes.Buffer
+	assert.NoError(t, New(dir, Settings{}, &out).Replay(outDir))
+
+	out.Reset()
+	assert.NoError(t, New(outDir, Settings{}, &out).Dump(""))
+
+	commands := make(map[string][]string)
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var record Record
+		assert.NoError(t, decoder.Decode(&record))
+		commands[record.Command] = record.Args
+	}
+
+	assert.Equal(t, map[string][]string{
+		"SET":   {"key2", "value2"},
+		"RPUSH": {"list", "a", "b"},
+	}, commands)
+
+	// out directory must be empty
+	assert.Error(t, New(dir, Settings{}, &out).Replay(outDir))
+}
@@ -0,0 +1,232 @@
+// Package client provides client of database text protocol
+// with connection pooling and retries
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"concurrency_go_course/internal/compute"
+)
+
+const (
+	responseOK       = "OK"
+	responseNotFound = "value not found"
+)
+
+var (
+	// ErrClosed is returned if client is closed
+	ErrClosed = errors.New("client is closed")
+	// ErrReadOnly is returned if write is sent to replication slave
+	ErrReadOnly = errors.New("server is read only replication slave")
+	// ErrSessionCommand is returned by Do for commands changing state of
+	// connection, which would be used by other requests of pool
+	ErrSessionCommand = errors.New("command changes connection state and can't be sent on pooled connection")
+)
+
+// sessionCommands change state of connection: selected database,
+// authenticated user, or they block it or make server push messages to it
+var sessionCommands = []string{
+	compute.CommandSelect, compute.CommandUse, compute.CommandAuth,
+	compute.CommandWatch, compute.CommandSubscribe,
+}
+
+// ServerError is an error returned by server
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return e.Message
+}
+
+// Client is a database client. It is safe for concurrent use
+type Client struct {
+	opts *options
+
+	master   *pool
+	replicas []*pool
+	next     atomic.Uint64
+
+	closed atomic.Bool
+}
+
+// New returns new client of server on address. Connections are
+// established on demand. If replicas are set, address is master address
+func New(address string, opts ...Option) (*Client, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is empty")
+	}
+
+	o := &options{
+		poolSize:       defaultPoolSize,
+		dialTimeout:    defaultDialTimeout,
+		requestTimeout: defaultRequestTimeout,
+		maxRetries:     defaultMaxRetries,
+		minBackoff:     defaultMinBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		maxMessageSize: defaultMaxMessageSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.poolSize <= 0 {
+		return nil, fmt.Errorf("pool size must be positive")
+	}
+
+	c := &Client{
+		opts:   o,
+		master: newPool(address, o),
+	}
+	for _, replica := range o.replicas {
+		c.replicas = append(c.replicas, newPool(replica, o))
+	}
+
+	return c, nil
+}
+
+// Get returns value of key and whether key exists
+func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
+	response, err := c.read(ctx, compute.NewQuery(compute.CommandGet, []string{key}))
+	if err != nil {
+		return "", false, err
+	}
+
+	if response == responseNotFound {
+		return "", false, nil
+	}
+
+	// values can't contain whitespaces, so response with them is an error
+	if strings.ContainsFunc(response, unicode.IsSpace) {
+		return "", false, serverError(response)
+	}
+
+	return response, true, nil
+}
+
+// Set sets value of key
+func (c *Client) Set(ctx context.Context, key, value string) error {
+	return c.write(ctx, compute.NewQuery(compute.CommandSet, []string{key, value}))
+}
+
+// Delete deletes key
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return c.write(ctx, compute.NewQuery(compute.CommandDelete, []string{key}))
+}
+
+// Do sends raw request to master and returns response as is. Request
+// isn't idempotent in general, so it is retried only if it wasn't sent.
+// Commands changing connection state are rejected with ErrSessionCommand
+func (c *Client) Do(ctx context.Context, request string) (string, error) {
+	if fields := strings.Fields(request); len(fields) != 0 &&
+		slices.Contains(sessionCommands, strings.ToUpper(fields[0])) {
+		return "", fmt.Errorf("%w: %s", ErrSessionCommand, fields[0])
+	}
+
+	return c.send(ctx, c.master, []byte(strings.TrimSpace(request)+"\n"), false)
+}
+
+// Close closes idle connections. Connections in use are closed
+// when requests are finished
+func (c *Client) Close() error {
+	if c.closed.Swap(true) {
+		return ErrClosed
+	}
+
+	c.master.close()
+	for _, replica := range c.replicas {
+		replica.close()
+	}
+
+	return nil
+}
+
+// read sends request to next replica. Master is used if there are
+// no replicas or replica is unavailable
+func (c *Client) read(ctx context.Context, query compute.Query) (string, error) {
+	request, err := query.Format()
+	if err != nil {
+		return "", err
+	}
+
+	if len(c.replicas) == 0 {
+		return c.send(ctx, c.master, []byte(request), true)
+	}
+
+	replica := c.replicas[c.next.Add(1)%uint64(len(c.replicas))]
+	response, err := c.send(ctx, replica, []byte(request), true)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, ErrClosed) {
+		return c.send(ctx, c.master, []byte(request), true)
+	}
+
+	return response, err
+}
+
+func (c *Client) write(ctx context.Context, query compute.Query) error {
+	request, err := query.Format()
+	if err != nil {
+		return err
+	}
+
+	response, err := c.send(ctx, c.master, []byte(request), true)
+	if err != nil {
+		return err
+	}
+
+	if response != responseOK {
+		return serverError(response)
+	}
+
+	return nil
+}
+
+// send sends request to server. Request is retried with backoff if it
+// is failed by network error. Request which isn't idempotent is retried
+// only if it wasn't sent, because server might have executed it
+func (c *Client) send(ctx context.Context, p *pool, request []byte, idempotent bool) (string, error) {
+	backoff := c.opts.minBackoff
+
+	for attempt := 0; ; attempt++ {
+		if c.closed.Load() {
+			return "", ErrClosed
+		}
+
+		response, err := p.send(ctx, request)
+		if err == nil {
+			return string(response), nil
+		}
+
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		if attempt >= c.opts.maxRetries || !idempotent && !notSent(err) {
+			return "", err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		}
+
+		backoff = min(2*backoff, c.opts.maxBackoff)
+	}
+}
+
+func serverError(response string) error {
+	if strings.HasPrefix(response, "unable to execute") && strings.HasSuffix(response, "on slave") {
+		return fmt.Errorf("%w: %s", ErrReadOnly, response)
+	}
+
+	return &ServerError{Message: response}
+}
@@ -0,0 +1,329 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"concurrency_go_course/internal/compute"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/database"
+	"concurrency_go_course/internal/network"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.MockLogger()
+
+	os.Exit(m.Run())
+}
+
+func startServer(t *testing.T, address, idleTimeout string, handler network.TCPHandler) {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.Network.MaxConnections = 100
+	cfg.Network.IdleTimeout = idleTimeout
+
+	server, err := network.NewServer(cfg, address)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Run(ctx, handler)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func databaseHandler(t *testing.T, replicaType string) network.TCPHandler {
+	t.Helper()
+
+	stor, err := storage.New(storage.NewEngine(4), nil, replicaType, nil, nil)
+	require.NoError(t, err)
+
+	db := database.NewDatabase(stor, compute.NewCompute(compute.NewRequestParser()), nil)
+
+	return func(ctx context.Context, request []byte) []byte {
+		response, err := db.HandleContext(ctx, string(request))
+		if err != nil {
+			return []byte(err.Error())
+		}
+		return []byte(response)
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	address := "127.0.0.1:5571"
+	startServer(t, address, "5m", databaseHandler(t, "master"))
+
+	client, err := New(address, WithPoolSize(4))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	ctx := context.Background()
+
+	_, found, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, client.Set(ctx, "key", "value"))
+
+	value, found, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	require.NoError(t, client.Delete(ctx, "key"))
+
+	_, found, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	assert.Equal(t, compute.ErrInvalidArgument, client.Set(ctx, "key", "a b"))
+
+	response, err := client.Do(ctx, "INCR counter")
+	require.NoError(t, err)
+	assert.Equal(t, "1", response)
+
+	// state of pooled connection isn't changed for other requests
+	for _, request := range []string{"SELECT 1", "use 1", "AUTH user password", "WATCH key", "SUBSCRIBE news"} {
+		_, err = client.Do(ctx, request)
+		assert.ErrorIs(t, err, ErrSessionCommand)
+	}
+
+	_, _, err = client.Get(ctx, "")
+	assert.Equal(t, compute.ErrInvalidArgument, err)
+}
+
+func TestClientConcurrent(t *testing.T) {
+	t.Parallel()
+
+	address := "127.0.0.1:5572"
+	startServer(t, address, "5m", databaseHandler(t, "master"))
+
+	client, err := New(address, WithPoolSize(4))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", i)
+			value := fmt.Sprintf("value%d", i)
+
+			assert.NoError(t, client.Set(context.Background(), key, value))
+
+			v, found, err := client.Get(context.Background(), key)
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, value, v)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestClientReconnect(t *testing.T) {
+	t.Parallel()
+
+	address := "127.0.0.1:5573"
+	startServer(t, address, "50ms", databaseHandler(t, "master"))
+
+	client, err := New(address, WithRetries(3, time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	require.NoError(t, client.Set(context.Background(), "key", "value"))
+
+	// server closes idle connection
+	time.Sleep(100 * time.Millisecond)
+
+	value, found, err := client.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+}
+
+func TestClientUnavailable(t *testing.T) {
+	t.Parallel()
+
+	client, err := New("127.0.0.1:1", WithRetries(2, time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	_, _, err = client.Get(context.Background(), "key")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = client.Get(ctx, "key")
+	assert.Equal(t, context.Canceled, err)
+
+	require.NoError(t, client.Close())
+	assert.Equal(t, ErrClosed, client.Set(context.Background(), "key", "value"))
+}
+
+func TestClientTopology(t *testing.T) {
+	t.Parallel()
+
+	masterAddress := "127.0.0.1:5574"
+	slaveAddress := "127.0.0.1:5575"
+
+	startServer(t, masterAddress, "5m", databaseHandler(t, "master"))
+	startServer(t, slaveAddress, "5m", func(context.Context, []byte) []byte {
+		return []byte("from_slave")
+	})
+
+	client, err := New(masterAddress, WithReplicas(slaveAddress))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	require.NoError(t, client.Set(context.Background(), "key", "value"))
+
+	value, found, err := client.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "from_slave", value)
+
+	// unavailable replica is replaced by master
+	client, err = New(masterAddress, WithReplicas("127.0.0.1:1"),
+		WithRetries(0, time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	value, found, err = client.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+}
+
+func TestClientReadOnly(t *testing.T) {
+	t.Parallel()
+
+	address := "127.0.0.1:5576"
+	startServer(t, address, "5m", databaseHandler(t, "slave"))
+
+	client, err := New(address)
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	err = client.Set(context.Background(), "key", "value")
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
+
+func TestServerError(t *testing.T) {
+	t.Parallel()
+
+	err := serverError("unable to execute set command on slave")
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	err = serverError("value is not an integer or out of range")
+	assert.Equal(t, &ServerError{Message: "value is not an integer or out of range"}, err)
+}
+
+// startRawServer starts server which handles every connection by handle
+func startRawServer(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestClientSplitResponse(t *testing.T) {
+	t.Parallel()
+
+	address := startRawServer(t, func(conn net.Conn) {
+		if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("first "))
+		time.Sleep(10 * time.Millisecond)
+		_, _ = conn.Write([]byte("second\n"))
+	})
+
+	client, err := New(address)
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	response, err := client.Do(context.Background(), "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "first second", response)
+}
+
+func TestClientDoNotRetried(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	address := startRawServer(t, func(conn net.Conn) {
+		if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+			return
+		}
+		// connection is closed after request is read without response
+		requests.Add(1)
+	})
+
+	client, err := New(address, WithRetries(3, time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	_, err = client.Do(context.Background(), "INCR counter")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// idempotent request is retried
+	_, _, err = client.Get(context.Background(), "counter")
+	assert.Error(t, err)
+	assert.Equal(t, int32(5), requests.Load())
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"time"
+)
+
+const (
+	defaultPoolSize       = 8
+	defaultDialTimeout    = time.Second
+	defaultRequestTimeout = 5 * time.Second
+	defaultMaxRetries     = 3
+	defaultMinBackoff     = 10 * time.Millisecond
+	defaultMaxBackoff     = time.Second
+	defaultMaxMessageSize = 4096
+)
+
+type options struct {
+	poolSize       int
+	dialTimeout    time.Duration
+	requestTimeout time.Duration
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	maxMessageSize int
+
+	replicas []string
+}
+
+// Option is a func configuring client
+type Option func(*options)
+
+// WithPoolSize sets max number of connections to every server
+func WithPoolSize(size int) Option {
+	return func(o *options) {
+		o.poolSize = size
+	}
+}
+
+// WithTimeouts sets timeouts of connection establishing and of request.
+// Request timeout is applied if context has no earlier deadline
+func WithTimeouts(dial, request time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = dial
+		o.requestTimeout = request
+	}
+}
+
+// WithRetries sets number of retries of request failed by network error.
+// Delay between retries grows exponentially from min to max backoff
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.maxRetries = maxRetries
+		o.minBackoff = minBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithMaxMessageSize sets max size of server response.
+// It should be equal to max message size of server
+func WithMaxMessageSize(size int) Option {
+	return func(o *options) {
+		o.maxMessageSize = size
+	}
+}
+
+// WithReplicas makes client topology-aware: reads are sent to slaves
+// on addresses in round robin order, writes are sent to master
+func WithReplicas(addresses ...string) Option {
+	return func(o *options) {
+		o.replicas = addresses
+	}
+}
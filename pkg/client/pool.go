@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"concurrency_go_course/internal/network"
+)
+
+// notSentError is returned if request wasn't written to connection,
+// so it can be retried even if it isn't idempotent
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+// notSent returns true if request failed with err wasn't sent to server
+func notSent(err error) bool {
+	var notSent *notSentError
+	return errors.As(err, &notSent)
+}
+
+// conn is a pooled connection with reader of its responses
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// pool is a pool of connections to server. Number of connections
+// is limited by pool size, idle connections are reused
+type pool struct {
+	address string
+	opts    *options
+
+	slots  chan struct{}
+	idle   chan *conn
+	closed atomic.Bool
+}
+
+func newPool(address string, opts *options) *pool {
+	return &pool{
+		address: address,
+		opts:    opts,
+		slots:   make(chan struct{}, opts.poolSize),
+		idle:    make(chan *conn, opts.poolSize),
+	}
+}
+
+// get returns idle connection or dials new one.
+// It blocks until connection slot is free or context is done
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: p.opts.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		<-p.slots
+		return nil, &notSentError{err: fmt.Errorf("unable to connect to %s: %w", p.address, err)}
+	}
+
+	return &conn{Conn: netConn, reader: bufio.NewReaderSize(netConn, p.opts.maxMessageSize)}, nil
+}
+
+// put returns connection to pool. Broken connection is closed
+func (p *pool) put(conn *conn, broken bool) {
+	defer func() {
+		<-p.slots
+	}()
+
+	if broken || p.closed.Load() {
+		_ = conn.Close()
+		return
+	}
+
+	select {
+	case p.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+
+	// pool could be closed while connection was returned
+	if p.closed.Load() {
+		p.close()
+	}
+}
+
+// send sends request on pooled connection and reads response up to
+// delimiter. Error of request which wasn't written is notSentError
+func (p *pool) send(ctx context.Context, request []byte) ([]byte, error) {
+	conn, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(p.opts.requestTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		p.put(conn, true)
+		return nil, &notSentError{err: fmt.Errorf("unable to set deadline: %w", err)}
+	}
+
+	if cnt, err := conn.Write(request); err != nil {
+		p.put(conn, true)
+		err = fmt.Errorf("unable to send request: %w", err)
+		if cnt == 0 {
+			return nil, &notSentError{err: err}
+		}
+		return nil, err
+	}
+
+	response, err := network.ReadMessage(conn.reader)
+	if err != nil {
+		p.put(conn, true)
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+
+	p.put(conn, false)
+
+	return response, nil
+}
+
+// close closes idle connections. Connections in use are closed
+// when they are returned
+func (p *pool) close() {
+	p.closed.Store(true)
+
+	for {
+		select {
+		case conn := <-p.idle:
+			_ = conn.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package inmem provides in-memory key-value database embedded into process.
+// Data is persisted to write ahead log in directory passed to Open
+package inmem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/app"
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/replication"
+	"concurrency_go_course/internal/storage"
+	"concurrency_go_course/internal/storage/wal"
+	"concurrency_go_course/pkg/logger"
+)
+
+var (
+	// ErrClosed is returned if database is closed
+	ErrClosed = errors.New("database is closed")
+	// ErrReadOnly is returned on writes to replication slave
+	ErrReadOnly = errors.New("database is read only replication slave")
+)
+
+// DB is a handle of embedded database. It is safe for concurrent use
+type DB struct {
+	storage storage.Storage
+	wal     *wal.WAL
+
+	// mutex is held for reading by operations, so Close waits for them
+	mutex  sync.RWMutex
+	closed bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Open opens database with WAL in directory. Data of WAL is restored
+// on open. If directory is empty, data is kept in memory only
+func Open(dir string, opts ...Option) (*DB, error) {
+	o := &options{
+		partitionsNumber: defaultPartitionsNumber,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.logger != nil {
+		logger.Set(o.logger)
+	} else if !logger.Initialized() {
+		logger.Set(zap.NewNop())
+	}
+
+	if o.replication != nil && dir == "" {
+		return nil, fmt.Errorf("unable to open database: replication requires directory")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Engine.PartitionsNumber = o.partitionsNumber
+	cfg.Engine.MaxMemory = fmt.Sprintf("%dB", o.maxMemory)
+	cfg.Engine.EvictionPolicy = o.evictionPolicy
+	cfg.Replication = o.replication
+
+	var walCfg *config.WALCfg
+	if dir != "" {
+		walCfg = &config.WALCfg{
+			WalConfig: &config.WALSettings{
+				FlushingBatchSize:    o.flushingBatchSize,
+				FlushingBatchTimeout: o.flushingBatchTimeout.String(),
+				MaxSegmentSize:       fmt.Sprintf("%dB", o.maxSegmentSize),
+				DataDirectory:        dir,
+				SyncMode:             o.syncMode,
+				SyncInterval:         o.syncInterval.String(),
+				RecoverToPosition:    o.recoverToPosition,
+			},
+		}
+		if !o.recoverToTime.IsZero() {
+			walCfg.WalConfig.RecoverToTime = o.recoverToTime.Format(time.RFC3339Nano)
+		}
+	}
+
+	stor, walObj, repl, _, err := app.InitStorage(cfg, walCfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	db := &DB{
+		storage: stor,
+		cancel:  cancel,
+	}
+
+	// slave gets segments from master and doesn't write own WAL
+	if walObj != nil && (o.replication == nil || o.replication.ReplicaType == replication.ReplicaTypeMaster) {
+		db.wal = walObj
+		walObj.Start(ctx)
+	}
+
+	if repl.Master != nil {
+		db.wg.Add(1)
+		go func() {
+			defer db.wg.Done()
+
+			repl.Master.Start(ctx)
+		}()
+	} else if repl.Slave != nil {
+		db.wg.Add(1)
+		go func() {
+			defer db.wg.Done()
+
+			repl.Slave.Start(ctx)
+		}()
+	}
+
+	return db, nil
+}
+
+// Get returns value of key and whether key exists
+func (db *DB) Get(ctx context.Context, key string) (string, bool, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if db.closed {
+		return "", false, ErrClosed
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+
+	value, found := db.storage.Get(key)
+
+	return value, found, nil
+}
+
+// Set sets value of key. It returns when value is written to WAL
+func (db *DB) Set(ctx context.Context, key, value string) error {
+	return db.write(ctx, func() error {
+		return db.storage.Set(ctx, key, value)
+	})
+}
+
+// Delete deletes key. It returns when deletion is written to WAL
+func (db *DB) Delete(ctx context.Context, key string) error {
+	return db.write(ctx, func() error {
+		return db.storage.Del(ctx, key)
+	})
+}
+
+// Close stops replication, waits for started writes and flushes WAL.
+// Database can't be used after close
+func (db *DB) Close() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+	db.closed = true
+
+	db.cancel()
+	if db.wal != nil {
+		<-db.wal.Done()
+	}
+	db.wg.Wait()
+
+	return nil
+}
+
+// write executes write operation. Context is passed to WAL waiting:
+// if it is done before operation is taken into group commit, operation
+// isn't applied and context error is returned
+func (db *DB) write(ctx context.Context, write func() error) error {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := write()
+
+	var slaveWrite *storage.SlaveWriteError
+	if errors.As(err, &slaveWrite) {
+		return ErrReadOnly
+	}
+
+	return err
+}
@@ -0,0 +1,153 @@
+package inmem
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenPersistent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	db, err := Open(dir, WithPartitions(4), WithFlushing(10, 5*time.Millisecond))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Set(ctx, "key1", "value with spaces"))
+	require.NoError(t, db.Set(ctx, "key2", "value2"))
+	require.NoError(t, db.Delete(ctx, "key2"))
+
+	value, found, err := db.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value with spaces", value)
+
+	require.NoError(t, db.Close())
+
+	db, err = Open(dir)
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	value, found, err = db.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value with spaces", value)
+
+	_, found, err = db.Get(ctx, "key2")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestOpenInMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := Open("")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Set(ctx, "key", "value"))
+
+	value, found, err := db.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	require.NoError(t, db.Close())
+
+	_, _, err = db.Get(ctx, "key")
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, db.Set(ctx, "key", "value"))
+	assert.Equal(t, ErrClosed, db.Close())
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	db, err := Open("")
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err = db.Get(ctx, "key")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, db.Set(ctx, "key", "value"))
+	assert.Equal(t, context.Canceled, db.Delete(ctx, "key"))
+}
+
+func TestOpenSlave(t *testing.T) {
+	t.Parallel()
+
+	_, err := Open("", WithSlave("127.0.0.1:0", time.Second))
+	assert.Error(t, err)
+
+	// slave isn't opened without connection to master
+	_, err = Open(t.TempDir(), WithSlave("127.0.0.1:1", time.Hour))
+	assert.ErrorContains(t, err, "unable to create replication slave server")
+
+	master, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer master.Close()
+
+	db, err := Open(t.TempDir(), WithSlave(master.Addr().String(), time.Hour))
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assert.Equal(t, ErrReadOnly, db.Set(context.Background(), "key", "value"))
+}
+
+func TestOpenRecoveryTime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	db, err := Open(dir, WithFlushing(10, time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, db.Set(ctx, "key1", "value1"))
+	require.NoError(t, db.Set(ctx, "key2", "value2"))
+	require.NoError(t, db.Close())
+
+	time.Sleep(10 * time.Millisecond)
+	beforeDelete := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	db, err = Open(dir, WithFlushing(10, time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(ctx, "key1"))
+	require.NoError(t, db.Delete(ctx, "key2"))
+	require.NoError(t, db.Close())
+
+	db, err = Open(dir, WithFlushing(10, time.Millisecond), WithRecoveryTime(beforeDelete))
+	require.NoError(t, err)
+	require.NoError(t, db.Set(ctx, "key3", "value3"))
+	require.NoError(t, db.Close())
+
+	// deletes are archived, so they aren't restored without target
+	db, err = Open(dir)
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	for key, expected := range map[string]string{"key1": "value1", "key2": "value2", "key3": "value3"} {
+		value, found, err := db.Get(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, found, key)
+		assert.Equal(t, expected, value)
+	}
+}
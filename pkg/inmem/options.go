@@ -0,0 +1,133 @@
+package inmem
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"concurrency_go_course/internal/config"
+	"concurrency_go_course/internal/replication"
+)
+
+const (
+	defaultPartitionsNumber = 256
+	defaultSyncInterval     = time.Second
+)
+
+type options struct {
+	partitionsNumber int
+
+	maxMemory      int64
+	evictionPolicy string
+
+	flushingBatchSize    int
+	flushingBatchTimeout time.Duration
+	maxSegmentSize       int
+	syncMode             string
+	syncInterval         time.Duration
+
+	recoverToTime     time.Time
+	recoverToPosition string
+
+	replication *config.ReplicationConfig
+
+	logger *zap.Logger
+}
+
+// Option is a func configuring database
+type Option func(*options)
+
+// WithPartitions sets number of engine partitions
+func WithPartitions(number int) Option {
+	return func(o *options) {
+		o.partitionsNumber = number
+	}
+}
+
+// WithMaxMemory limits memory used by values. Keys are evicted
+// by policy when limit is reached, zero means no limit
+func WithMaxMemory(bytes int64, policy string) Option {
+	return func(o *options) {
+		o.maxMemory = bytes
+		o.evictionPolicy = policy
+	}
+}
+
+// WithFlushing sets size and timeout of WAL batch
+func WithFlushing(batchSize int, timeout time.Duration) Option {
+	return func(o *options) {
+		o.flushingBatchSize = batchSize
+		o.flushingBatchTimeout = timeout
+	}
+}
+
+// WithMaxSegmentSize sets max size of WAL segment file
+func WithMaxSegmentSize(bytes int) Option {
+	return func(o *options) {
+		o.maxSegmentSize = bytes
+	}
+}
+
+// WithSyncMode sets when WAL is synced to disk: "always" (every batch),
+// "interval" (at most once per interval) or "none" (left to OS).
+// Writes acknowledged after the last sync can be lost on machine crash
+func WithSyncMode(mode string, interval time.Duration) Option {
+	return func(o *options) {
+		o.syncMode = mode
+		o.syncInterval = interval
+	}
+}
+
+// WithRecoveryTime makes database restored to state as of time t.
+// Requests written after t are ignored and moved to archive subdirectory
+// of WAL directory, so they aren't restored on the next open
+func WithRecoveryTime(t time.Time) Option {
+	return func(o *options) {
+		o.recoverToTime = t
+	}
+}
+
+// WithRecoveryPosition makes database restored to state before request
+// at offset of WAL segment as printed by waltool dump. The request and
+// later ones are ignored and moved to archive subdirectory of WAL directory
+func WithRecoveryPosition(segment string, offset int) Option {
+	return func(o *options) {
+		o.recoverToPosition = fmt.Sprintf("%s:%d", segment, offset)
+	}
+}
+
+// WithMaster makes database replication master serving WAL segments
+// to slaves on address
+func WithMaster(address string) Option {
+	return func(o *options) {
+		o.replication = &config.ReplicationConfig{
+			ReplicaType:   replication.ReplicaTypeMaster,
+			MasterAddress: address,
+		}
+	}
+}
+
+// WithSlave makes database read only replication slave of master
+// on address. Slave syncs with master every sync interval, one second by default
+func WithSlave(masterAddress string, syncInterval time.Duration) Option {
+	return func(o *options) {
+		if syncInterval <= 0 {
+			syncInterval = defaultSyncInterval
+		}
+
+		o.replication = &config.ReplicationConfig{
+			ReplicaType:   replication.ReplicaTypeSlave,
+			MasterAddress: masterAddress,
+			SyncInterval:  syncInterval,
+		}
+	}
+}
+
+// WithLogger sets logger. Logger is global for the process,
+// messages are discarded by default
+func WithLogger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
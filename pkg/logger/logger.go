@@ -33,6 +33,16 @@ func Init(core zapcore.Core, options ...zap.Option) {
 	globalLogger = zap.New(core, options...)
 }
 
+// Set sets global logger
+func Set(logger *zap.Logger) {
+	globalLogger = logger
+}
+
+// Initialized reports whether global logger is initialized
+func Initialized() bool {
+	return globalLogger != nil
+}
+
 // Debug is used for debug logging
 func Debug(msg string, fields ...zap.Field) {
 	globalLogger.Debug(msg, fields...)
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets is a default histogram buckets in seconds
+var DefaultBuckets = []float64{
+	0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry is a struct for metrics registry
+type Registry struct {
+	mutex      sync.RWMutex
+	collectors map[string]collector
+}
+
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns new registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.collectors[c.name()]; ok {
+		panic(fmt.Sprintf("metric %s is already registered", c.name()))
+	}
+
+	r.collectors[c.name()] = c
+}
+
+// Write writes metrics in Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		r.collectors[name].write(w)
+	}
+}
+
+// Handler returns HTTP handler of default registry
+func Handler() http.Handler {
+	return HandlerFor(defaultRegistry)
+}
+
+// HandlerFor returns HTTP handler of registry
+func HandlerFor(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	})
+}
+
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	label      string
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, d.help, d.metricName, d.kind)
+}
+
+// labels returns labels string for label value and extra pairs
+func (d desc) labels(value string, extra ...string) string {
+	pairs := make([]string, 0, 1+len(extra)/2)
+	if d.label != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", d.label, value))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// atomicFloat is a float64 with atomic operations
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) Store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// vec is a set of metrics with different label values
+type vec[T any] struct {
+	children sync.Map
+	create   func() *T
+}
+
+func (v *vec[T]) with(value string) *T {
+	if child, ok := v.children.Load(value); ok {
+		return child.(*T)
+	}
+
+	child, _ := v.children.LoadOrStore(value, v.create())
+	return child.(*T)
+}
+
+func (v *vec[T]) each(fn func(value string, child *T)) {
+	var values []string
+	v.children.Range(func(key, _ any) bool {
+		values = append(values, key.(string))
+		return true
+	})
+	slices.Sort(values)
+
+	for _, value := range values {
+		fn(value, v.with(value))
+	}
+}
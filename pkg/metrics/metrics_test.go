@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	counter := registry.NewCounter("test_counter_total", "Test counter.")
+	counter.Add(2)
+
+	gauge := registry.NewGauge("test_gauge", "Test gauge.")
+	gauge.Set(1.5)
+
+	registry.NewGaugeFunc("test_gauge_func", "Test gauge func.", func() float64 { return 3 })
+
+	histogram := registry.NewHistogram("test_histogram", "Test histogram.", []float64{1, 10})
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	vec := registry.NewCounterVec("test_vec_total", "Test vec.", "command")
+	vec.With("GET").Inc()
+
+	buffer := &bytes.Buffer{}
+	registry.Write(buffer)
+
+	expected := `# HELP test_counter_total Test counter.
+# TYPE test_counter_total counter
+test_counter_total 2
+# HELP test_gauge Test gauge.
+# TYPE test_gauge gauge
+test_gauge 1.5
+# HELP test_gauge_func Test gauge func.
+# TYPE test_gauge_func gauge
+test_gauge_func 3
+# HELP test_histogram Test histogram.
+# TYPE test_histogram histogram
+test_histogram_bucket{le="1"} 1
+test_histogram_bucket{le="10"} 2
+test_histogram_bucket{le="+Inf"} 2
+test_histogram_sum 5.5
+test_histogram_count 2
+# HELP test_vec_total Test vec.
+# TYPE test_vec_total counter
+test_vec_total{command="GET"} 1
+`
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "Test counter.")
+
+	assert.Panics(t, func() {
+		registry.NewGauge("test_total", "Test gauge.")
+	})
+}
+
+func TestHandlerFor(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "Test counter.").Inc()
+
+	recorder := httptest.NewRecorder()
+	HandlerFor(registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "test_total 1\n")
+}
@@ -0,0 +1,287 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a monotonically increasing metric
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc increments counter by 1
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments counter by delta
+func (c *Counter) Add(delta float64) {
+	c.value.Add(delta)
+}
+
+// Value returns counter value
+func (c *Counter) Value() float64 {
+	return c.value.Load()
+}
+
+// Gauge is a metric which can go up and down
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set sets gauge value
+func (g *Gauge) Set(v float64) {
+	g.value.Store(v)
+}
+
+// Add adds delta to gauge value
+func (g *Gauge) Add(delta float64) {
+	g.value.Add(delta)
+}
+
+// Inc increments gauge by 1
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec decrements gauge by 1
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Value returns gauge value
+func (g *Gauge) Value() float64 {
+	return g.value.Load()
+}
+
+// Histogram is a metric counting observations in buckets
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+	count   atomic.Uint64
+	sum     atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)),
+	}
+}
+
+// Observe adds observation to histogram
+func (h *Histogram) Observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	h.sum.Add(v)
+}
+
+// ObserveDuration adds observation of time passed since start in seconds
+func (h *Histogram) ObserveDuration(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// Count returns number of observations
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+func (h *Histogram) write(w io.Writer, d desc, value string) {
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket%s %d\n", d.metricName,
+			d.labels(value, "le", formatFloat(bound)), h.counts[i].Load())
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", d.metricName, d.labels(value, "le", "+Inf"), h.count.Load())
+	fmt.Fprintf(w, "%s_sum%s %s\n", d.metricName, d.labels(value), formatFloat(h.sum.Load()))
+	fmt.Fprintf(w, "%s_count%s %d\n", d.metricName, d.labels(value), h.count.Load())
+}
+
+type counterCollector struct {
+	desc
+	counter *Counter
+}
+
+func (c *counterCollector) write(w io.Writer) {
+	c.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", c.metricName, formatFloat(c.counter.Value()))
+}
+
+type gaugeCollector struct {
+	desc
+	gauge *Gauge
+	fn    func() float64
+}
+
+func (c *gaugeCollector) write(w io.Writer) {
+	c.writeHeader(w)
+
+	value := c.gauge.Value()
+	if c.fn != nil {
+		value = c.fn()
+	}
+	fmt.Fprintf(w, "%s %s\n", c.metricName, formatFloat(value))
+}
+
+type histogramCollector struct {
+	desc
+	histogram *Histogram
+}
+
+func (c *histogramCollector) write(w io.Writer) {
+	c.writeHeader(w)
+	c.histogram.write(w, c.desc, "")
+}
+
+// CounterVec is a set of counters partitioned by label
+type CounterVec struct {
+	desc
+	vec[Counter]
+}
+
+// With returns counter for label value
+func (c *CounterVec) With(value string) *Counter {
+	return c.with(value)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w)
+	c.each(func(value string, counter *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labels(value), formatFloat(counter.Value()))
+	})
+}
+
+// GaugeVec is a set of gauges partitioned by label
+type GaugeVec struct {
+	desc
+	vec[Gauge]
+}
+
+// With returns gauge for label value
+func (g *GaugeVec) With(value string) *Gauge {
+	return g.with(value)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.writeHeader(w)
+	g.each(func(value string, gauge *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labels(value), formatFloat(gauge.Value()))
+	})
+}
+
+// HistogramVec is a set of histograms partitioned by label
+type HistogramVec struct {
+	desc
+	vec[Histogram]
+}
+
+// With returns histogram for label value
+func (h *HistogramVec) With(value string) *Histogram {
+	return h.with(value)
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+	h.each(func(value string, histogram *Histogram) {
+		histogram.write(w, h.desc, value)
+	})
+}
+
+// NewCounter registers new counter in registry
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &counterCollector{desc: desc{metricName: name, help: help, kind: "counter"}, counter: &Counter{}}
+	r.register(c)
+
+	return c.counter
+}
+
+// NewGauge registers new gauge in registry
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	c := &gaugeCollector{desc: desc{metricName: name, help: help, kind: "gauge"}, gauge: &Gauge{}}
+	r.register(c)
+
+	return c.gauge
+}
+
+// NewGaugeFunc registers gauge which value is computed by fn on collection
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeCollector{desc: desc{metricName: name, help: help, kind: "gauge"},
+		gauge: &Gauge{}, fn: fn})
+}
+
+// NewHistogram registers new histogram in registry
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	c := &histogramCollector{desc: desc{metricName: name, help: help, kind: "histogram"},
+		histogram: newHistogram(buckets)}
+	r.register(c)
+
+	return c.histogram
+}
+
+// NewCounterVec registers new counter vector in registry
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{desc: desc{metricName: name, help: help, kind: "counter", label: label}}
+	c.create = func() *Counter { return &Counter{} }
+	r.register(c)
+
+	return c
+}
+
+// NewGaugeVec registers new gauge vector in registry
+func (r *Registry) NewGaugeVec(name, help, label string) *GaugeVec {
+	g := &GaugeVec{desc: desc{metricName: name, help: help, kind: "gauge", label: label}}
+	g.create = func() *Gauge { return &Gauge{} }
+	r.register(g)
+
+	return g
+}
+
+// NewHistogramVec registers new histogram vector in registry
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{desc: desc{metricName: name, help: help, kind: "histogram", label: label}}
+	h.create = func() *Histogram { return newHistogram(buckets) }
+	r.register(h)
+
+	return h
+}
+
+// NewCounter registers new counter in default registry
+func NewCounter(name, help string) *Counter {
+	return defaultRegistry.NewCounter(name, help)
+}
+
+// NewGauge registers new gauge in default registry
+func NewGauge(name, help string) *Gauge {
+	return defaultRegistry.NewGauge(name, help)
+}
+
+// NewGaugeFunc registers gauge func in default registry
+func NewGaugeFunc(name, help string, fn func() float64) {
+	defaultRegistry.NewGaugeFunc(name, help, fn)
+}
+
+// NewHistogram registers new histogram in default registry
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return defaultRegistry.NewHistogram(name, help, buckets)
+}
+
+// NewCounterVec registers new counter vector in default registry
+func NewCounterVec(name, help, label string) *CounterVec {
+	return defaultRegistry.NewCounterVec(name, help, label)
+}
+
+// NewGaugeVec registers new gauge vector in default registry
+func NewGaugeVec(name, help, label string) *GaugeVec {
+	return defaultRegistry.NewGaugeVec(name, help, label)
+}
+
+// NewHistogramVec registers new histogram vector in default registry
+func NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	return defaultRegistry.NewHistogramVec(name, help, label, buckets)
+}
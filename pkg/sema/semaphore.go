@@ -1,42 +1,57 @@
 package sema
 
 import (
-	"sync"
+	"context"
 )
 
 // Semaphore is a struct for semaphore
 type Semaphore struct {
-	cond  *sync.Cond
-	count int
-	max   int
+	tokens chan struct{}
 }
 
 // NewSemaphore returns new semaphore
 func NewSemaphore(max int) *Semaphore {
-	mutex := &sync.Mutex{}
+	if max < 0 {
+		max = 0
+	}
+
 	return &Semaphore{
-		max:  max,
-		cond: sync.NewCond(mutex),
+		tokens: make(chan struct{}, max),
 	}
 }
 
 // Acquire acquires semaphore
 func (s *Semaphore) Acquire() {
-	s.cond.L.Lock()
-	defer s.cond.L.Unlock()
+	s.tokens <- struct{}{}
+}
+
+// AcquireContext acquires semaphore or returns error of context
+// if it is done before semaphore is released
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	for s.count >= s.max {
-		s.cond.Wait()
+	select {
+	case s.tokens <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	s.count++
+// TryAcquire acquires semaphore without waiting. It returns false
+// if semaphore is already acquired max times
+func (s *Semaphore) TryAcquire() bool {
+	select {
+	case s.tokens <- struct{}{}:
+		return true
+	default:
+		return false
+	}
 }
 
 // Release releases semaphore
 func (s *Semaphore) Release() {
-	s.cond.L.Lock()
-	defer s.cond.L.Unlock()
-
-	s.count--
-	s.cond.Signal()
+	<-s.tokens
 }
@@ -0,0 +1,46 @@
+package sema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphoreTryAcquire(t *testing.T) {
+	t.Parallel()
+
+	s := NewSemaphore(2)
+
+	assert.True(t, s.TryAcquire())
+	assert.True(t, s.TryAcquire())
+	assert.False(t, s.TryAcquire())
+
+	s.Release()
+	assert.True(t, s.TryAcquire())
+}
+
+func TestSemaphoreAcquireContext(t *testing.T) {
+	t.Parallel()
+
+	s := NewSemaphore(1)
+	s.Acquire()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.AcquireContext(ctx), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Release()
+	}()
+	assert.NoError(t, s.AcquireContext(context.Background()))
+
+	// done context fails even if semaphore is free
+	s.Release()
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, s.AcquireContext(ctx), context.Canceled)
+	assert.True(t, s.TryAcquire())
+}